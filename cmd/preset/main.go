@@ -97,6 +97,9 @@ func encodeAll() error {
 		if err := prototext.Unmarshal(data, &p); err != nil {
 			return fmt.Errorf("%s: %w", f, err)
 		}
+		if err := preset.ValidateProto(&p); err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
 
 		outBin := strings.TrimSuffix(f, filepath.Ext(f)) + ".preset"
 		bin, _ := proto.Marshal(&p)
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"synth/dsp"
+	"synth/msg"
+	"synth/preset"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	dir        = "assets/presets"
+	sampleRate = 44100
+)
+
+// Render a preset offline and print the dsp load per node
+func main() {
+	presetF := flag.String("preset", "", "preset name, the first preset if empty")
+	notesF := flag.Int("notes", 8, "number of notes held")
+	secondsF := flag.Float64("seconds", 5, "seconds of audio rendered")
+	workersF := flag.Int("workers", 0, "voice rendering worker goroutines")
+	flag.Parse()
+
+	messenger := msg.NewMessenger(msg.NewQueue(16), msg.NewQueue(4096), 0)
+	manager := preset.NewManager(sampleRate, zerolog.Nop(), messenger, dir)
+
+	if *presetF != "" {
+		index := slices.Index(manager.GetPresets(), *presetF)
+		if index < 0 {
+			fmt.Println("❌ unknown preset:", *presetF)
+			os.Exit(1)
+		}
+		manager.HandleMessage(msg.Message{Kind: preset.LoadSavePresetKind, Key: uint8(index)})
+	}
+
+	if *workersF > 0 {
+		renderer := dsp.NewParallelRenderer(*workersF)
+		defer renderer.Close()
+		manager.SetRenderer(renderer)
+	}
+
+	profiler := dsp.NewProfiler(sampleRate, manager)
+	manager.Profile(profiler)
+
+	for i := range *notesF {
+		manager.NoteOn(48+i, 0.8)
+	}
+
+	block := &dsp.Block{}
+	blocks := int(*secondsF * sampleRate / dsp.BlockSize)
+	for range blocks {
+		profiler.Process(block)
+		block.Cycle++
+	}
+
+	name := *presetF
+	if name == "" {
+		name = manager.GetPresets()[0]
+	}
+	fmt.Printf("%s, %d notes, %d blocks of %d samples @ %d Hz\n\n", name, *notesF, blocks, dsp.BlockSize, sampleRate)
+	fmt.Printf("%-12s %8s %8s\n", "Node", "Avg", "Peak")
+	for _, load := range profiler.Loads() {
+		fmt.Printf("%-12s %7.2f%% %7.2f%%\n", load.Name, load.Avg*100, load.Peak*100)
+	}
+}
@@ -5,17 +5,27 @@ import (
 	"os"
 	"synth/assets"
 	"synth/dsp"
+	"synth/harmony"
+	"synth/meter"
 	"synth/midi"
 	"synth/msg"
 	"synth/preset"
 	"synth/settings"
 	"synth/tree"
+	"synth/tuning"
 	"synth/ui"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/rs/zerolog"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// Quick preset switch buttons, on undefined CCs
+const (
+	presetPrevCC = 102
+	presetNextCC = 103
 )
 
 func main() {
@@ -26,6 +36,9 @@ func main() {
 	debugF := flag.Bool("debug", false, "enable debug mode")
 	fullsF := flag.Bool("full-screen", false, "enable full screen mode")
 	buffF := flag.Int("buffer", 25, "buffer size in milliseconds")
+	workersF := flag.Int("workers", 0, "voice rendering worker goroutines, 0 renders on the audio thread only")
+	profileF := flag.Bool("profile", false, "measure the dsp load per node, see Visualizer > CPU")
+	playF := flag.String("play", "", "play a MIDI file (.mid) at start, see MIDI files")
 	flag.Parse()
 
 	// Help
@@ -58,31 +71,87 @@ func main() {
 	setsInQ := router.AddInput(1024)
 	setsOutQ := router.AddOutput(1024)
 
+	tuningOutQ := router.AddOutput(64)
+	devicesOutQ := router.AddOutput(1024) // every device setting is needed
+
+	libraryInQ := router.AddInput(64)
+
+	filesInQ := router.AddInput(1024)
+	filesOutQ := router.AddOutput(256)
+
+	midiOutQ := router.AddOutput(1024)
+
 	// Routing: MIDI to audio
 	router.AddRoute(midiInQ, midi.NoteOnKind, audioOutQ)
 	router.AddRoute(midiInQ, midi.NoteOffKind, audioOutQ)
 	router.AddRoute(midiInQ, midi.PitchBendKind, audioOutQ)
+	router.AddRoute(midiInQ, midi.ControlChangeKind, audioOutQ)
+	router.AddRoute(midiInQ, midi.ProgramChangeKind, audioOutQ)
+	router.AddRoute(midiInQ, midi.BankSelectKind, audioOutQ)
+	router.AddRoute(midiInQ, midi.ControlChange14Kind, audioOutQ)
+	router.AddRoute(midiInQ, midi.NrpnKind, audioOutQ)
+
+	// Routing: MIDI RPNs (pitch bend range, tuning) and device slots to settings
+	router.AddRoute(midiInQ, settings.SettingUpdateKind, setsOutQ)
+
+	// Routing: MIDI output, parameters echoed by the engine, settings and thru
+	router.AddRoute(audioInQ, preset.UpdateParameterKind, midiOutQ)
+	router.AddRoute(setsInQ, settings.SettingUpdateKind, midiOutQ)
+	for _, kind := range []msg.Kind{midi.NoteOnKind, midi.NoteOffKind, midi.PitchBendKind, midi.ControlChangeKind, midi.ProgramChangeKind, midi.BankSelectKind, midi.ControlChange14Kind, midi.NrpnKind} {
+		router.AddRoute(midiInQ, kind, midiOutQ)
+	}
+
+	// Routing: SysEx dump requests to audio
+	router.AddRoute(libraryInQ, preset.DumpPresetKind, audioOutQ)
+
+	// Routing: MIDI files to audio, commands from UI and settings
+	router.AddRoute(filesInQ, midi.NoteOnKind, audioOutQ)
+	router.AddRoute(filesInQ, midi.NoteOffKind, audioOutQ)
+	router.AddRoute(filesInQ, midi.PitchBendKind, audioOutQ)
+	router.AddRoute(filesInQ, midi.ControlChangeKind, audioOutQ)
+	router.AddRoute(filesInQ, midi.FilePlayKind, uiOutQ)
+	router.AddRoute(uiInQ, midi.FilePlayKind, filesOutQ)
 
 	// Routing: UI to audio
 	router.AddRoute(uiInQ, preset.LoadSavePresetKind, audioOutQ)
 	router.AddRoute(uiInQ, preset.UpdateParameterKind, audioOutQ)
 	router.AddRoute(uiInQ, preset.ModulationUpdateKind, audioOutQ)
+	router.AddRoute(uiInQ, preset.MacroUpdateKind, audioOutQ)
+	router.AddRoute(uiInQ, preset.PresetStepKind, audioOutQ)
 	router.AddRoute(uiInQ, midi.NoteOnKind, audioOutQ)
 	router.AddRoute(uiInQ, midi.NoteOffKind, audioOutQ)
 
 	// Routing: audio to UI
 	router.AddRoute(audioInQ, preset.UpdateParameterKind, uiOutQ)
 	router.AddRoute(audioInQ, preset.ModulationUpdateKind, uiOutQ)
+	router.AddRoute(audioInQ, preset.MacroUpdateKind, uiOutQ)
+	router.AddRoute(audioInQ, preset.PresetLoadedKind, uiOutQ)
+	router.AddRoute(audioInQ, meter.CpuLoadKind, uiOutQ)
+	router.AddRoute(audioInQ, meter.SafetyEventKind, uiOutQ)
+	router.AddRoute(audioInQ, meter.CompressorReductionKind, uiOutQ)
+
+	// Routing: played notes to UI, see the oscilloscope pitch trigger
+	router.AddRoute(midiInQ, midi.NoteOnKind, uiOutQ)
+	router.AddRoute(uiInQ, midi.NoteOnKind, uiOutQ)
+	router.AddRoute(filesInQ, midi.NoteOnKind, uiOutQ)
 
 	// Routing: settings to audio/UI + ui to settings
 	router.AddRoute(uiInQ, settings.SettingUpdateKind, setsOutQ)
 	router.AddRoute(setsInQ, settings.SettingUpdateKind, audioOutQ)
 	router.AddRoute(setsInQ, settings.SettingUpdateKind, uiOutQ)
+	router.AddRoute(setsInQ, settings.SettingUpdateKind, tuningOutQ)
+	router.AddRoute(setsInQ, settings.SettingUpdateKind, devicesOutQ)
+	router.AddRoute(setsInQ, settings.SettingUpdateKind, filesOutQ)
 
 	go router.Route()
 
+	// Audio clock, timestamps MIDI events, the latency covers the output buffer
+	latency := SampleRate**buffF/1000 + 2*dsp.BlockSize
+	clock := msg.NewClock(SampleRate, dsp.BlockSize, latency)
+
 	// Main signal
 	audioMessenger := msg.NewMessenger(audioOutQ, audioInQ, 10)
+	audioMessenger.SetClock(clock, 1024)
 	presetManager := preset.NewManager(
 		SampleRate,
 		logger().With().Str("component", "presets").Logger(),
@@ -90,38 +159,191 @@ func main() {
 		"assets/presets",
 	)
 
+	// SysEx preset dumps, imported presets are a bank from the next start
+	librarian := preset.NewLibrarian(
+		"assets/presets/user",
+		msg.NewMessenger(nil, libraryInQ, 0),
+		logger().With().Str("component", "librarian").Logger(),
+	)
+	presetManager.SetLibrarian(librarian)
+
+	// Parallel voice rendering
+	if *workersF > 0 {
+		renderer := dsp.NewParallelRenderer(*workersF)
+		defer renderer.Close()
+		presetManager.SetRenderer(renderer)
+	}
+
+	// Master bus dynamics, parameters are settings
+	busParams := settings.Params{}
+	busParam := func(key uint8) dsp.Param {
+		busParams[key] = dsp.NewParam(0)
+		return busParams[key]
+	}
+	compressor := dsp.NewCompressor(dsp.CompressorOpts{
+		SampleRate:  SampleRate,
+		Src:         presetManager,
+		Enabled:     busParam(settings.CompEnabled),
+		Threshold:   busParam(settings.CompThreshold),
+		Ratio:       busParam(settings.CompRatio),
+		Attack:      busParam(settings.CompAttack),
+		Release:     busParam(settings.CompRelease),
+		Knee:        busParam(settings.CompKnee),
+		Makeup:      busParam(settings.CompMakeup),
+		Detector:    busParam(settings.CompDetector),
+		Duck:        busParam(settings.DuckMode),
+		DuckDepth:   busParam(settings.DuckDepth),
+		DuckRelease: busParam(settings.DuckRelease),
+		Tempo:       busParam(settings.Tempo),
+	})
+
+	// Harmony stage, transforms the notes before the player
+	harmonyStage := harmony.NewStage(SampleRate, midi.NewPlayer(presetManager, clock), presetManager, audioMessenger)
+
 	// Audio messenger injection
 	withMessenger := dsp.NewCallback(func(block *dsp.Block) {
+		clock.Tick()
 		audioMessenger.Process()
-	}, presetManager)
+		harmonyStage.Process()
+	}, compressor)
 
-	audioMessenger.RegisterHandler(midi.NewPlayer(presetManager))
+	audioMessenger.RegisterHandler(harmonyStage)
 	audioMessenger.RegisterHandler(presetManager)
+	audioMessenger.RegisterHandler(newControlMapper(presetManager, audioMessenger))
+	audioMessenger.RegisterHandler(midi.NewControlTrigger(presetNextCC, msg.Message{Kind: preset.PresetStepKind, ValF: 1}, presetManager))
+	audioMessenger.RegisterHandler(midi.NewControlTrigger(presetPrevCC, msg.Message{Kind: preset.PresetStepKind, ValF: -1}, presetManager))
+	audioMessenger.RegisterHandler(busParams)
+	audioMessenger.RegisterHandler(&duckTrigger{compressor, clock})
+
+	// Profiling, opt-in
+	var synth dsp.Node = withMessenger
+	var profiler *dsp.Profiler
+	var cpuNames []string
+	if *profileF {
+		profiler = dsp.NewProfiler(SampleRate, withMessenger)
+		presetManager.Profile(profiler)
+		synth = profiler
+		cpuNames = profiler.Names()
+	}
 
-	// Audio tap
+	// Master EQ and stereo width, parameters are settings
+	eqParam := func(key uint8, init float32) dsp.Param {
+		busParams[key] = dsp.NewParam(init)
+		return busParams[key]
+	}
+	flat := dsp.NewParam(0.707)
+	eq := dsp.NewEqualizer(dsp.EqualizerOpts{
+		SampleRate: SampleRate,
+		Src:        synth,
+		Bands: []dsp.EqBand{
+			{Type: dsp.BiquadHighPass, Freq: eqParam(settings.EqLowCut, dsp.EqLowCutOff), Q: flat},
+			{Type: dsp.BiquadLowShelf, Freq: eqParam(settings.EqLowShelfFreq, 120), Q: flat, Gain: eqParam(settings.EqLowShelfGain, 0)},
+			{Type: dsp.BiquadPeak, Freq: eqParam(settings.EqPeak1Freq, 500), Q: eqParam(settings.EqPeak1Q, 1), Gain: eqParam(settings.EqPeak1Gain, 0)},
+			{Type: dsp.BiquadPeak, Freq: eqParam(settings.EqPeak2Freq, 3000), Q: eqParam(settings.EqPeak2Q, 1), Gain: eqParam(settings.EqPeak2Gain, 0)},
+			{Type: dsp.BiquadHighShelf, Freq: eqParam(settings.EqHighShelfFreq, 8000), Q: flat, Gain: eqParam(settings.EqHighShelfGain, 0)},
+			{Type: dsp.BiquadLowPass, Freq: eqParam(settings.EqHighCut, 18000), Q: flat},
+		},
+		Width: eqParam(settings.StereoWidth, 1),
+	})
+
+	// Master safety, NaN reset, denormals and limiter
+	ceiling := dsp.NewParam(-0.3)
+	safety := dsp.NewSafety(SampleRate, eq, ceiling)
+	safetyInput := dsp.NewLevels(SampleRate)
+	safety.Input = safetyInput
+	audioMessenger.RegisterHandler(settings.Params{settings.MasterCeiling: ceiling})
+
+	// Audio tap + output levels, after the safety: what is actually played.
+	// Clips and NaNs are counted on the safety input, the output has none.
 	uiAudioQueue := ui.NewAudioQueue(32) // 32 blocks x 256 samples
-	synthTap := ui.NewAudioPuller(withMessenger, uiAudioQueue)
+	levels := dsp.NewLevels(SampleRate)
+	outputTap := ui.NewAudioPuller(safety, uiAudioQueue, levels)
+
+	go watchLevels(safetyInput, safety, logger().With().Str("component", "levels").Logger())
 
-	// Clean presetManager
-	clean := dsp.NewLowPassSVF(SampleRate, synthTap, dsp.NewParam(18000), dsp.NewParam(0.5))
+	// Readings of the master nodes, published to the UI
+	publisher := meter.NewPublisher(SampleRate, outputTap, audioMessenger)
+	publisher.WatchSafety(safety)
+	publisher.WatchCompressor(compressor)
+	if profiler != nil {
+		publisher.WatchProfiler(profiler)
+	}
 
 	// Midi setup
 	mdi := midi.NewListener(
 		logger().With().Str("component", "midi").Logger(),
 		midiInQ,
+		clock,
 	)
 	defer mdi.Close()
+	mdi.HandleSysEx(librarian.Receive)
 	go mdi.ListenAll()
 
+	// MIDI devices settings, ports are opened once they are known
+	devicesMessenger := msg.NewMessenger(devicesOutQ, nil, 0)
+	devicesMessenger.RegisterHandler(mdi)
+	go func() {
+		for range time.Tick(10 * time.Millisecond) {
+			devicesMessenger.Process()
+		}
+	}()
+
 	// Player
 	ctx := audio.NewContext(SampleRate)
-	player, err := ctx.NewPlayerF32(dsp.NewStream(clean))
+	player, err := ctx.NewPlayerF32(dsp.NewStream(publisher))
 	onError(err, "failed to create player")
 	defer player.Close()
 
 	player.SetBufferSize(time.Millisecond * time.Duration(*buffF))
 	player.Play()
 
+	// Tuning, tables are built off the audio thread
+	tuner := tuning.NewTuner("assets/tunings", logger().With().Str("component", "tuning").Logger())
+	tuningMessenger := msg.NewMessenger(tuningOutQ, nil, 0)
+	tuningMessenger.RegisterHandler(tuner)
+	go func() {
+		for range time.Tick(10 * time.Millisecond) {
+			tuningMessenger.Process()
+		}
+	}()
+
+	// MIDI files, events are scheduled ahead of the clock
+	filesMessenger := msg.NewMessenger(filesOutQ, filesInQ, 0)
+	files := midi.NewFilePlayer(
+		SampleRate,
+		logger().With().Str("component", "files").Logger(),
+		filesMessenger,
+		clock,
+		"assets/midi",
+	)
+	filesMessenger.RegisterHandler(files)
+	if *playF != "" {
+		onError(files.Play(*playF), "failed to play midi file")
+	}
+	go func() {
+		for range time.Tick(5 * time.Millisecond) {
+			filesMessenger.Process()
+			files.Schedule()
+		}
+	}()
+
+	// MIDI output and SysEx dumps, sends may block on the port
+	outLogger := logger().With().Str("component", "midi-out").Logger()
+	outPorts, err := drivers.Outs()
+	if err != nil {
+		outLogger.Warn().Err(err).Msg("no midi out ports")
+	}
+	output := newOutput(outPorts, outLogger)
+	defer output.Close()
+	outputMessenger := msg.NewMessenger(midiOutQ, nil, 0)
+	outputMessenger.RegisterHandler(output)
+	go func() {
+		for range time.Tick(2 * time.Millisecond) {
+			outputMessenger.Process()
+			librarian.Process(output)
+		}
+	}()
+
 	// Settings
 	sets := settings.NewSettings(
 		"assets/settings.cfg",
@@ -143,11 +365,16 @@ func main() {
 	uiMessenger := msg.NewMessenger(uiOutQ, uiInQ, 0)
 
 	// Menu tree
-	menuTree := tree.NewTree(presetManager.GetPresets())
+	menuTree := tree.NewTree(presetManager.GetPresets(), cpuNames, tuner.Names(), files.Names(), output.Names(), mdi)
 	menuTree.AttachMessenger(uiMessenger)
 
 	// UI Components
-	components, err := ui.NewComponents(asts, menuTree, uiAudioQueue)
+	components, err := ui.NewComponents(asts, menuTree, ui.Taps{
+		Audio:      uiAudioQueue,
+		Levels:     levels,
+		Input:      safetyInput,
+		SampleRate: SampleRate,
+	})
 	onError(err, "failed to create ui components")
 
 	// Controls
@@ -161,6 +388,8 @@ func main() {
 	gui, err := ui.NewUi(asts, uiMessenger, controls, components, menuTree)
 	onError(err, "failed to create gui")
 
+	gui.ShowLevels(levels)
+
 	// Debug FTPS display
 	if debugMode {
 		gui.ToggleFtpsDisplay()
@@ -171,6 +400,70 @@ func main() {
 	onError(err, "failed to run gui")
 }
 
+// newControlMapper binds the default MIDI CCs of the preset parameters, and
+// the NRPN of their ID, see newOutput
+func newControlMapper(h msg.Handler, echo *msg.Messenger) *midi.ControlMapper {
+	mapper := midi.NewControlMapper(preset.UpdateParameterKind, h, echo)
+	for _, meta := range preset.ParamsMeta() {
+		mapping := midi.ControlMapping{Key: meta.ID, Scale: meta.Denormalize}
+		if meta.CC != preset.NoCC {
+			mapper.Map(meta.CC, mapping)
+		}
+		mapper.MapNRPN(uint16(meta.ID), mapping)
+	}
+
+	return mapper
+}
+
+// newOutput echoes the preset parameters on their default MIDI CC, the others as NRPN
+// of their ID
+func newOutput(ports []drivers.Out, logger zerolog.Logger) *midi.Output {
+	output := midi.NewOutput(preset.UpdateParameterKind, ports, logger)
+	for _, meta := range preset.ParamsMeta() {
+		output.Map(meta.ID, midi.OutputMapping{CC: meta.CC, NRPN: uint16(meta.ID), Normalize: meta.Normalize})
+	}
+
+	return output
+}
+
+// duckTrigger fires the master bus ducking on note ons
+type duckTrigger struct {
+	compressor *dsp.Compressor
+	clock      *msg.Clock
+}
+
+func (d *duckTrigger) HandleMessage(m msg.Message) {
+	if m.Kind == midi.NoteOnKind && m.Val8 > 0 {
+		d.compressor.TriggerAt(d.clock.Offset(m.Frame))
+	}
+}
+
+// watchLevels logs the samples above 0 dBFS and the NaN samples reaching the
+// safety, the safety resets and limiting
+func watchLevels(input *dsp.Levels, safety *dsp.Safety, l zerolog.Logger) {
+	var clips, nans, resets uint64
+	for range time.Tick(time.Second) {
+		if r := safety.Resets(); r > resets {
+			l.Error().Uint64("resets", r-resets).Msg("non finite output, audio chain reset")
+			resets = r
+		}
+		if g := safety.Reduction(); g < 1 {
+			l.Debug().Float32("reduction_db", dsp.ToDb(g, -60)).Msg("output limited")
+		}
+
+		c, n := input.Clips(), input.NaNs()
+		if c > clips || n > nans {
+			l.Warn().
+				Uint64("clipped", c-clips).
+				Uint64("nan", n-nans).
+				Uint64("clipped_total", c).
+				Uint64("nan_total", n).
+				Msg("master bus exceeds 0 dBFS or is not a number")
+		}
+		clips, nans = c, n
+	}
+}
+
 func onError(err error, msg string) {
 	if err != nil {
 		l := logger().With().Str("component", "main").Logger()
@@ -25,6 +25,11 @@ type ADSR struct {
 	value float32
 	gate  bool
 
+	// Gate change scheduled at a sample offset of the next block
+	event   bool
+	eventAt int
+	eventOn bool
+
 	aCoef, dCoef, rCoef float32
 
 	buf       [BlockSize]float32
@@ -45,8 +50,41 @@ func NewADSR(sr float64, atk, dec, sus, rel Param) *ADSR {
 }
 
 func (a *ADSR) NoteOn() {
-	a.gate = true
-	a.setState(EnvAttack)
+	a.NoteOnAt(0)
+}
+
+// NoteOnAt opens the gate at the given sample offset of the next block
+func (a *ADSR) NoteOnAt(offset int) {
+	a.schedule(true, offset)
+}
+
+// NoteOffAt closes the gate at the given sample offset of the next block
+func (a *ADSR) NoteOffAt(offset int) {
+	a.schedule(false, offset)
+}
+
+// schedule a gate change, a previously scheduled one lands immediately
+func (a *ADSR) schedule(on bool, offset int) {
+	if a.event {
+		a.applyGate(a.eventOn)
+	}
+
+	a.event = offset > 0
+	if !a.event {
+		a.applyGate(on)
+		return
+	}
+	a.eventAt, a.eventOn = min(offset, BlockSize-1), on
+}
+
+func (a *ADSR) applyGate(on bool) {
+	a.event = false
+	a.gate = on
+	if on {
+		a.setState(EnvAttack)
+	} else {
+		a.setState(EnvRelease)
+	}
 }
 
 func (a *ADSR) Reset() {
@@ -55,8 +93,7 @@ func (a *ADSR) Reset() {
 }
 
 func (a *ADSR) NoteOff() {
-	a.gate = false
-	a.setState(EnvRelease)
+	a.NoteOffAt(0)
 }
 
 func coefFromTime(t float32, sr float64) float32 {
@@ -85,10 +122,17 @@ func (a *ADSR) Resolve(cycle uint64) []float32 {
 	if a.stampedAt == cycle {
 		return a.buf[:]
 	}
+	// Stamp early, an envelope modulating its own parameters
+	// reads its previous block instead of recursing
+	a.stampedAt = cycle
 
 	a.recalc(cycle)
 
 	for i := 0; i < BlockSize; i++ {
+		if a.event && i == a.eventAt {
+			a.applyGate(a.eventOn)
+		}
+
 		switch a.state {
 		case EnvIdle:
 			a.value = 0
@@ -118,10 +162,9 @@ func (a *ADSR) Resolve(cycle uint64) []float32 {
 		}
 		a.buf[i] = a.value
 	}
-	a.stampedAt = cycle
 	return a.buf[:]
 }
 
 func (a *ADSR) IsIdle() bool {
-	return a.state == EnvIdle
+	return a.state == EnvIdle && !(a.event && a.eventOn)
 }
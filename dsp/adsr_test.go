@@ -0,0 +1,56 @@
+package dsp
+
+import "testing"
+
+func TestADSR_ResolveNoAlloc(t *testing.T) {
+	adsr := NewADSR(44100, NewConstParam(.01), NewConstParam(.1), NewConstParam(.5), NewConstParam(.2))
+
+	var cycle uint64
+	allocs := testing.AllocsPerRun(1000, func() {
+		cycle++
+		if cycle%2 == 0 {
+			adsr.NoteOnAt(int(cycle % BlockSize))
+		} else {
+			adsr.NoteOffAt(int(cycle % BlockSize))
+		}
+		adsr.Resolve(cycle)
+	})
+
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations, got %v", allocs)
+	}
+}
+
+func TestADSR_NoteAtOffset(t *testing.T) {
+	const offset = 100
+	adsr := NewADSR(44100, NewConstParam(.001), NewConstParam(.001), NewConstParam(.5), NewConstParam(.001))
+
+	// Gate opens at the offset, not at the block start
+	adsr.NoteOnAt(offset)
+	if adsr.IsIdle() {
+		t.Fatal("expected a pending note on not to be idle")
+	}
+
+	buf := adsr.Resolve(1)
+	for i := 0; i < offset; i++ {
+		if buf[i] != 0 {
+			t.Fatalf("expected silence before offset, got %g at %d", buf[i], i)
+		}
+	}
+	if buf[offset] == 0 {
+		t.Errorf("expected attack to start at %d", offset)
+	}
+
+	// Let it reach sustain, then release at the offset
+	for c := uint64(2); c < 10; c++ {
+		adsr.Resolve(c)
+	}
+	adsr.NoteOffAt(offset)
+	buf = adsr.Resolve(10)
+	if buf[offset-1] < .499 || buf[offset-1] > .501 {
+		t.Errorf("expected sustain until offset, got %g", buf[offset-1])
+	}
+	if buf[offset+10] >= buf[offset-1] {
+		t.Errorf("expected release after offset, got %g", buf[offset+10])
+	}
+}
@@ -0,0 +1,102 @@
+package dsp
+
+import "math"
+
+type BiquadType int
+
+const (
+	BiquadLowPass BiquadType = iota
+	BiquadHighPass
+	BiquadPeak
+	BiquadLowShelf
+	BiquadHighShelf
+)
+
+// Biquad stereo second order filter, transposed direct form II, RBJ cookbook
+// coefficients. Double precision, low shelves and cuts stay stable.
+type Biquad struct {
+	b0, b1, b2, a1, a2 float64
+	zL, zR             [2]float64
+}
+
+// SetCoefs gainDb is ignored by the cuts, q is the shelf slope for shelves
+func (f *Biquad) SetCoefs(t BiquadType, sr, freq, q, gainDb float64) {
+	freq = min(max(freq, 1), sr*0.49)
+	q = max(q, 0.01)
+
+	w := 2 * math.Pi * freq / sr
+	cos, sin := math.Cos(w), math.Sin(w)
+	alpha := sin / (2 * q)
+	a := math.Pow(10, gainDb/40)
+
+	var b0, b1, b2, a0, a1, a2 float64
+	switch t {
+	case BiquadLowPass:
+		b0, b1, b2 = (1-cos)/2, 1-cos, (1-cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BiquadHighPass:
+		b0, b1, b2 = (1+cos)/2, -(1 + cos), (1+cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BiquadPeak:
+		b0, b1, b2 = 1+alpha*a, -2*cos, 1-alpha*a
+		a0, a1, a2 = 1+alpha/a, -2*cos, 1-alpha/a
+	case BiquadLowShelf:
+		sq := 2 * math.Sqrt(a) * alpha
+		b0 = a * ((a + 1) - (a-1)*cos + sq)
+		b1 = 2 * a * ((a - 1) - (a+1)*cos)
+		b2 = a * ((a + 1) - (a-1)*cos - sq)
+		a0 = (a + 1) + (a-1)*cos + sq
+		a1 = -2 * ((a - 1) + (a+1)*cos)
+		a2 = (a + 1) + (a-1)*cos - sq
+	case BiquadHighShelf:
+		sq := 2 * math.Sqrt(a) * alpha
+		b0 = a * ((a + 1) + (a-1)*cos + sq)
+		b1 = -2 * a * ((a - 1) + (a+1)*cos)
+		b2 = a * ((a + 1) + (a-1)*cos - sq)
+		a0 = (a + 1) - (a-1)*cos + sq
+		a1 = 2 * ((a - 1) - (a+1)*cos)
+		a2 = (a + 1) - (a-1)*cos - sq
+	}
+
+	f.b0, f.b1, f.b2 = b0/a0, b1/a0, b2/a0
+	f.a1, f.a2 = a1/a0, a2/a0
+}
+
+// Process filters l and r in place
+func (f *Biquad) Process(l, r []float32) {
+	f.process(l, &f.zL)
+	f.process(r, &f.zR)
+}
+
+func (f *Biquad) process(buf []float32, z *[2]float64) {
+	b0, b1, b2, a1, a2 := f.b0, f.b1, f.b2, f.a1, f.a2
+	z1, z2 := z[0], z[1]
+	for i, x := range buf {
+		in := float64(x)
+		y := b0*in + z1
+		z1 = b1*in - a1*y + z2
+		z2 = b2*in - a2*y
+		buf[i] = float32(y)
+	}
+
+	// Flush the tail, avoids denormals once silent
+	if math.Abs(z1) < 1e-20 && math.Abs(z2) < 1e-20 {
+		z1, z2 = 0, 0
+	}
+	z[0], z[1] = z1, z2
+}
+
+// Response magnitude at freq
+func (f *Biquad) Response(sr, freq float64) float64 {
+	w := 2 * math.Pi * freq / sr
+	// z^-1 = e^-jw
+	c1, s1 := math.Cos(w), -math.Sin(w)
+	c2, s2 := math.Cos(2*w), -math.Sin(2*w)
+	nr, ni := f.b0+f.b1*c1+f.b2*c2, f.b1*s1+f.b2*s2
+	dr, di := 1+f.a1*c1+f.a2*c2, f.a1*s1+f.a2*s2
+	return math.Sqrt((nr*nr + ni*ni) / (dr*dr + di*di))
+}
+
+func (f *Biquad) Reset() {
+	f.zL, f.zR = [2]float64{}, [2]float64{}
+}
@@ -0,0 +1,65 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBiquad_Response(t *testing.T) {
+	const sr = 44100
+
+	for _, test := range []struct {
+		name      string
+		typ       BiquadType
+		freq, q   float64
+		gain      float64
+		at, expDb float64
+	}{
+		{"peak center", BiquadPeak, 1000, 1, 6, 1000, 6},
+		{"peak far", BiquadPeak, 1000, 4, 6, 10000, 0},
+		{"low shelf bottom", BiquadLowShelf, 200, .707, -9, 20, -9},
+		{"low shelf top", BiquadLowShelf, 200, .707, -9, 10000, 0},
+		{"high shelf top", BiquadHighShelf, 4000, .707, 4, 18000, 4},
+		{"high pass corner", BiquadHighPass, 100, .707, 0, 100, -3},
+		{"high pass octave below", BiquadHighPass, 100, .707, 0, 50, -12.3},
+		{"low pass passband", BiquadLowPass, 8000, .707, 0, 100, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var f Biquad
+			f.SetCoefs(test.typ, sr, test.freq, test.q, test.gain)
+
+			db := 20 * math.Log10(f.Response(sr, test.at))
+			if math.Abs(db-test.expDb) > .3 {
+				t.Fatalf("expected %.1f dB at %.0f Hz, got %.2f", test.expDb, test.at, db)
+			}
+		})
+	}
+}
+
+func TestBiquad_ProcessMatchesResponse(t *testing.T) {
+	const sr, freq = 44100, 1000
+
+	var f Biquad
+	f.SetCoefs(BiquadPeak, sr, freq, 2, -12)
+
+	l, r := make([]float32, sr), make([]float32, sr)
+	for i := range l {
+		l[i] = float32(math.Sin(2 * math.Pi * freq * float64(i) / sr))
+		r[i] = l[i]
+	}
+	f.Process(l, r)
+
+	// Steady state peak, skips the transient
+	var peak float32
+	for _, x := range l[sr/2:] {
+		peak = max(peak, x)
+	}
+
+	exp := f.Response(sr, freq)
+	if math.Abs(float64(peak)-exp) > .01 {
+		t.Fatalf("expected a peak of %.3f, got %.3f", exp, peak)
+	}
+	if r[sr-1] != l[sr-1] {
+		t.Fatal("expected identical channels")
+	}
+}
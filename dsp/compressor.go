@@ -0,0 +1,198 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+const (
+	CompDetectPeak = 0
+	CompDetectRms  = 1
+)
+
+const (
+	DuckOff   = 0
+	DuckNotes = 1 // on every note on, see Compressor.TriggerAt
+	DuckTempo = 2 // on every beat
+)
+
+const (
+	compRmsTime     = 0.01  // seconds, RMS detector window
+	compDuckAttack  = 0.002 // seconds, ducking ramp down
+	compMinDb       = -120
+	compMaxTriggers = 16 // pending note on triggers per block
+)
+
+type CompressorOpts struct {
+	SampleRate float64
+	Src        Node
+
+	Enabled   Param // 0 bypass
+	Threshold Param // dBFS
+	Ratio     Param // >= 1
+	Attack    Param // seconds
+	Release   Param // seconds
+	Knee      Param // dB
+	Makeup    Param // dB
+	Detector  Param // CompDetect*
+
+	Duck        Param // Duck*
+	DuckDepth   Param // dB, <= 0
+	DuckRelease Param // seconds
+	Tempo       Param // BPM for DuckTempo
+}
+
+// Compressor stereo linked feed forward compressor with a soft knee, plus a
+// ducking envelope fired by note ons or a tempo clock, for pumping pads.
+type Compressor struct {
+	CompressorOpts
+
+	env      float32 // smoothed gain reduction, dB
+	ms       float32 // detector mean square
+	duck     float32 // ducking amount 0..1
+	duckUp   bool    // ramping down to the depth
+	beat     float64 // samples since the last beat
+	triggers [compMaxTriggers]int
+	pending  int
+
+	reduction atomic.Uint32 // float bits, dB
+}
+
+func NewCompressor(o CompressorOpts) *Compressor {
+	return &Compressor{CompressorOpts: o}
+}
+
+// TriggerAt fires the ducking at the sample offset of the next block, in DuckNotes mode
+func (c *Compressor) TriggerAt(offset int) {
+	if c.pending < compMaxTriggers {
+		c.triggers[c.pending] = offset
+		c.pending++
+	}
+}
+
+// Reduction gain reduction in dB of the last block, <= 0
+func (c *Compressor) Reduction() float32 {
+	return math.Float32frombits(c.reduction.Load())
+}
+
+func (c *Compressor) Process(b *Block) {
+	c.Src.Process(b)
+
+	cyc := b.Cycle
+	if c.Enabled.Resolve(cyc)[0] == 0 {
+		c.env, c.duck, c.pending = 0, 0, 0
+		c.store(0)
+		return
+	}
+
+	sr := float32(c.SampleRate)
+	threshold := c.Threshold.Resolve(cyc)[0]
+	slope := 1/max(c.Ratio.Resolve(cyc)[0], 1) - 1
+	knee := max(c.Knee.Resolve(cyc)[0], 0)
+	makeup := c.Makeup.Resolve(cyc)[0]
+	rms := c.Detector.Resolve(cyc)[0] == CompDetectRms
+	attack := timeCoef(c.Attack.Resolve(cyc)[0], sr)
+	release := timeCoef(c.Release.Resolve(cyc)[0], sr)
+	msCoef := 1 - timeCoef(compRmsTime, sr)
+
+	duckMode := int(c.Duck.Resolve(cyc)[0])
+	depth := min(c.DuckDepth.Resolve(cyc)[0], 0)
+	duckRelease := timeCoef(c.DuckRelease.Resolve(cyc)[0], sr)
+	duckAttack := 1 / (compDuckAttack * sr)
+	beatLen := 60 * c.SampleRate / math.Max(float64(c.Tempo.Resolve(cyc)[0]), 1)
+
+	peakRed := float32(0)
+	next := 0
+	for i := 0; i < BlockSize; i++ {
+		l, r := b.L[i], b.R[i]
+
+		// Detector, linked stereo
+		var level float32
+		if rms {
+			c.ms += (max(l*l, r*r) - c.ms) * msCoef
+			level = ToDb(c.ms, 2*compMinDb) / 2
+		} else {
+			level = ToDb(max(l, -l, r, -r), compMinDb)
+		}
+
+		// Gain computer, soft knee
+		over := level - threshold
+		var gr float32
+		switch {
+		case 2*over <= -knee:
+			gr = 0
+		case 2*over < knee:
+			x := over + knee/2
+			gr = slope * x * x / (2 * knee)
+		default:
+			gr = slope * over
+		}
+
+		// Ballistics
+		if gr < c.env {
+			c.env = gr + (c.env-gr)*attack
+		} else {
+			c.env = gr + (c.env-gr)*release
+		}
+
+		// Ducking triggers
+		switch duckMode {
+		case DuckNotes:
+			for next < c.pending && c.triggers[next] <= i {
+				c.duckUp = true
+				next++
+			}
+		case DuckTempo:
+			c.beat++
+			if c.beat >= beatLen {
+				c.beat -= beatLen
+				c.duckUp = true
+			}
+		}
+		if c.duckUp {
+			c.duck += duckAttack
+			if c.duck >= 1 {
+				c.duck, c.duckUp = 1, false
+			}
+		} else {
+			c.duck *= duckRelease
+		}
+
+		red := c.env + depth*c.duck
+		peakRed = min(peakRed, red)
+
+		g := fastDbToGain(red + makeup)
+		b.L[i] = l * g
+		b.R[i] = r * g
+	}
+	c.pending = 0
+	if duckMode != DuckTempo {
+		c.beat = 0
+	}
+
+	c.store(peakRed)
+}
+
+func (c *Compressor) store(red float32) {
+	c.reduction.Store(math.Float32bits(red))
+}
+
+func (c *Compressor) Reset(soft bool) {
+	if !soft {
+		c.env, c.ms, c.duck, c.duckUp, c.beat, c.pending = 0, 0, 0, false, 0, 0
+	}
+	c.Src.Reset(soft)
+}
+
+// timeCoef one pole coefficient reaching 1/e of the target in t seconds
+func timeCoef(t, sr float32) float32 {
+	if t <= 0 {
+		return 0
+	}
+	return float32(math.Exp(-1 / float64(t*sr)))
+}
+
+// fastDbToGain 10^(db/20) = 2^(db*log2(10)/20)
+func fastDbToGain(db float32) float32 {
+	return fastExp2(db * 0.16609640474)
+}
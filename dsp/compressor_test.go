@@ -0,0 +1,149 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func compressorFixture(src Node) *Compressor {
+	return NewCompressor(CompressorOpts{
+		SampleRate:  44100,
+		Src:         src,
+		Enabled:     NewParam(1),
+		Threshold:   NewParam(-20),
+		Ratio:       NewParam(4),
+		Attack:      NewParam(.001),
+		Release:     NewParam(.05),
+		Knee:        NewParam(0),
+		Makeup:      NewParam(0),
+		Detector:    NewParam(CompDetectPeak),
+		Duck:        NewParam(DuckOff),
+		DuckDepth:   NewParam(-12),
+		DuckRelease: NewParam(.1),
+		Tempo:       NewParam(120),
+	})
+}
+
+// dc constant source on both channels
+type dc struct{ v float32 }
+
+func (d *dc) Process(b *Block) {
+	for i := range b.L {
+		b.L[i], b.R[i] = d.v, d.v
+	}
+}
+func (d *dc) Reset(bool) {}
+
+func runCompressor(c *Compressor, blocks int) *Block {
+	b := &Block{}
+	for range blocks {
+		b.Cycle++
+		c.Process(b)
+	}
+	return b
+}
+
+func TestCompressor_StaticCurve(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		in, knee float32
+		expDb    float32
+	}{
+		{"below threshold", .05, 0, 20 * float32(math.Log10(.05))},
+		{"above threshold", 1, 0, -15},      // -20 + 20/4
+		{"knee center", .1, 6, -20 - .5625}, // (1/4-1) * 3^2 / 12
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := compressorFixture(&dc{v: test.in})
+			c.Knee.SetBase(test.knee)
+
+			b := runCompressor(c, 50)
+			out := 20 * math.Log10(float64(b.L[BlockSize-1]))
+			if math.Abs(out-float64(test.expDb)) > .05 {
+				t.Fatalf("expected %.2f dB, got %.2f dB", test.expDb, out)
+			}
+		})
+	}
+}
+
+func TestCompressor_Bypass(t *testing.T) {
+	c := compressorFixture(&dc{v: 1})
+	c.Enabled.SetBase(0)
+
+	b := runCompressor(c, 10)
+	if b.L[0] != 1 || c.Reduction() != 0 {
+		t.Fatalf("expected an untouched signal, got %f, reduction %f", b.L[0], c.Reduction())
+	}
+}
+
+func TestCompressor_DuckNotes(t *testing.T) {
+	c := compressorFixture(&dc{v: .01}) // under the threshold
+	c.Duck.SetBase(DuckNotes)
+
+	runCompressor(c, 2)
+	c.TriggerAt(100)
+	b := runCompressor(c, 1)
+
+	if b.L[99] != .01 {
+		t.Fatalf("expected no ducking before the trigger, got %f", b.L[99])
+	}
+	if red := c.Reduction(); math.Abs(float64(red+12)) > .1 {
+		t.Fatalf("expected the ducking depth, got %.2f dB", red)
+	}
+
+	// Recovers over the ducking release
+	runCompressor(c, 100)
+	if red := c.Reduction(); red < -.1 {
+		t.Fatalf("expected recovery, got %.2f dB", red)
+	}
+}
+
+func TestCompressor_DuckTempo(t *testing.T) {
+	c := compressorFixture(&dc{v: .01})
+	c.Duck.SetBase(DuckTempo)
+	c.Tempo.SetBase(600) // beat every 4410 samples
+
+	beats := 0
+	prev := float32(0)
+	b := &Block{}
+	for range 44100 / BlockSize {
+		b.Cycle++
+		c.Process(b)
+		red := c.Reduction()
+		if red < -11 && prev >= -11 {
+			beats++
+		}
+		prev = red
+	}
+
+	if beats < 9 || beats > 10 {
+		t.Fatalf("expected 10 beats in a second, got %d", beats)
+	}
+}
+
+func TestCompressor_ProcessNoAlloc(t *testing.T) {
+	c := compressorFixture(&dc{v: .5})
+	c.Detector.SetBase(CompDetectRms)
+	c.Duck.SetBase(DuckTempo)
+
+	var b Block
+	allocs := testing.AllocsPerRun(100, func() {
+		b.Cycle++
+		c.Process(&b)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs, got %.1f", allocs)
+	}
+}
+
+func BenchmarkCompressor_Process(b *testing.B) {
+	c := compressorFixture(&dc{v: .5})
+
+	var block Block
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		block.Cycle++
+		c.Process(&block)
+	}
+}
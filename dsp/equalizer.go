@@ -0,0 +1,110 @@
+package dsp
+
+import "math"
+
+// Cut bands are bypassed at the edges of the audio range
+const (
+	EqLowCutOff  = 20    // Hz, high pass at or below is off
+	EqHighCutOff = 20000 // Hz, low pass at or above is off
+)
+
+// eqFlatGain dB, peaks and shelves closer to 0 are bypassed
+const eqFlatGain = 0.05
+
+type EqBand struct {
+	Type BiquadType
+	Freq Param // Hz
+	Q    Param // 0.707 flattest for cuts and shelves
+	Gain Param // dB, nil for cuts
+}
+
+type EqualizerOpts struct {
+	SampleRate float64
+	Src        Node
+	Bands      []EqBand
+	Width      Param // stereo width, 0 mono, 1 unchanged, 2 wide
+}
+
+// Equalizer parametric EQ of biquad bands followed by a mid/side width
+// stage. Coefficients follow the band parameters once per block, flat
+// bands cost nothing.
+type Equalizer struct {
+	EqualizerOpts
+
+	filters []Biquad
+	last    [][3]float32 // freq, q, gain of the current coefficients
+	active  []bool
+}
+
+func NewEqualizer(o EqualizerOpts) *Equalizer {
+	return &Equalizer{
+		EqualizerOpts: o,
+		filters:       make([]Biquad, len(o.Bands)),
+		last:          make([][3]float32, len(o.Bands)),
+		active:        make([]bool, len(o.Bands)),
+	}
+}
+
+func (e *Equalizer) Process(b *Block) {
+	e.Src.Process(b)
+
+	for i, band := range e.Bands {
+		freq := band.Freq.Resolve(b.Cycle)[0]
+		q := band.Q.Resolve(b.Cycle)[0]
+		var gain float32
+		if band.Gain != nil {
+			gain = band.Gain.Resolve(b.Cycle)[0]
+		}
+
+		active := true
+		switch band.Type {
+		case BiquadHighPass:
+			active = freq > EqLowCutOff
+		case BiquadLowPass:
+			active = freq < EqHighCutOff
+		default:
+			active = math.Abs(float64(gain)) >= eqFlatGain
+		}
+
+		if !active {
+			e.active[i] = false
+			continue
+		}
+
+		f := &e.filters[i]
+		if !e.active[i] {
+			f.Reset()
+			e.active[i] = true
+			e.last[i] = [3]float32{-1}
+		}
+		if params := [3]float32{freq, q, gain}; params != e.last[i] {
+			f.SetCoefs(band.Type, e.SampleRate, float64(freq), float64(q), float64(gain))
+			e.last[i] = params
+		}
+
+		f.Process(b.L[:], b.R[:])
+	}
+
+	// Mid/side width
+	if e.Width == nil {
+		return
+	}
+	width := e.Width.Resolve(b.Cycle)[0]
+	if width == 1 {
+		return
+	}
+	for i := 0; i < BlockSize; i++ {
+		mid := (b.L[i] + b.R[i]) * 0.5
+		side := (b.L[i] - b.R[i]) * 0.5 * width
+		b.L[i], b.R[i] = mid+side, mid-side
+	}
+}
+
+func (e *Equalizer) Reset(soft bool) {
+	if !soft {
+		for i := range e.filters {
+			e.filters[i].Reset()
+		}
+	}
+	e.Src.Reset(soft)
+}
@@ -0,0 +1,131 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func equalizerFixture(src Node) *Equalizer {
+	flat := NewParam(.707)
+	return NewEqualizer(EqualizerOpts{
+		SampleRate: 44100,
+		Src:        src,
+		Bands: []EqBand{
+			{Type: BiquadHighPass, Freq: NewParam(EqLowCutOff), Q: flat},
+			{Type: BiquadLowShelf, Freq: NewParam(120), Q: flat, Gain: NewParam(0)},
+			{Type: BiquadPeak, Freq: NewParam(1000), Q: NewParam(1), Gain: NewParam(0)},
+			{Type: BiquadHighShelf, Freq: NewParam(8000), Q: flat, Gain: NewParam(0)},
+			{Type: BiquadLowPass, Freq: NewParam(EqHighCutOff), Q: flat},
+		},
+		Width: NewParam(1),
+	})
+}
+
+func runEqualizer(e *Equalizer, blocks int) *Block {
+	b := &Block{}
+	for range blocks {
+		b.Cycle++
+		e.Process(b)
+	}
+	return b
+}
+
+func TestEqualizer_FlatIsIdentity(t *testing.T) {
+	src := &blockSource{fn: func(n int) float32 { return float32(math.Sin(float64(n) * .05)) }}
+	e := equalizerFixture(src)
+
+	b := runEqualizer(e, 4)
+
+	for i := range b.L {
+		n := 3*BlockSize + i
+		if exp := float32(math.Sin(float64(n) * .05)); b.L[i] != exp || b.R[i] != -exp/2 {
+			t.Fatalf("expected untouched samples at %d, got %f %f", i, b.L[i], b.R[i])
+		}
+	}
+}
+
+func TestEqualizer_LowCut(t *testing.T) {
+	e := equalizerFixture(&dc{v: .5})
+	e.Bands[0].Freq.SetBase(80)
+
+	b := runEqualizer(e, 100)
+
+	if math.Abs(float64(b.L[BlockSize-1])) > 1e-3 {
+		t.Fatalf("expected the DC to be removed, got %f", b.L[BlockSize-1])
+	}
+}
+
+func TestEqualizer_ShelfGain(t *testing.T) {
+	e := equalizerFixture(&dc{v: .5})
+	e.Bands[1].Gain.SetBase(6)
+
+	b := runEqualizer(e, 100)
+
+	exp := .5 * math.Pow(10, 6./20)
+	if math.Abs(float64(b.L[BlockSize-1])-exp) > 1e-3 {
+		t.Fatalf("expected %.3f, got %f", exp, b.L[BlockSize-1])
+	}
+}
+
+func TestEqualizer_Width(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		width  float32
+		expL   float32
+		expR   float32
+		source float32
+	}{
+		{"mono", 0, .25, .25, 1},        // mid of 1 and -.5
+		{"unchanged", 1, 1, -.5, 1},     // identity
+		{"wide", 2, 1.75, -1.25, 1},     // mid .25, side .75 doubled
+		{"half", .5, .625, -.125, 1},    // side .375
+		{"silent", 2, 0, 0, 0},          // nothing to widen
+		{"negative", 0, -.25, -.25, -1}, // mid of -1 and .5
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			src := &blockSource{fn: func(int) float32 { return test.source }}
+			e := equalizerFixture(src)
+			e.Width.SetBase(test.width)
+
+			b := runEqualizer(e, 1)
+
+			if b.L[0] != test.expL || b.R[0] != test.expR {
+				t.Fatalf("expected %f %f, got %f %f", test.expL, test.expR, b.L[0], b.R[0])
+			}
+		})
+	}
+}
+
+func TestEqualizer_ProcessNoAlloc(t *testing.T) {
+	e := equalizerFixture(&dc{v: .5})
+	e.Bands[0].Freq.SetBase(40)
+	e.Bands[2].Gain.SetBase(3)
+	e.Width.SetBase(1.5)
+
+	var b Block
+	allocs := testing.AllocsPerRun(100, func() {
+		b.Cycle++
+		e.Process(&b)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs, got %.1f", allocs)
+	}
+}
+
+func BenchmarkEqualizer_Process(b *testing.B) {
+	e := equalizerFixture(&dc{v: .5})
+	e.Bands[0].Freq.SetBase(40)
+	e.Bands[1].Gain.SetBase(2)
+	e.Bands[2].Gain.SetBase(-3)
+	e.Bands[3].Gain.SetBase(1)
+	e.Bands[4].Freq.SetBase(16000)
+	e.Width.SetBase(1.2)
+
+	var block Block
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		block.Cycle++
+		e.Process(&block)
+	}
+}
@@ -5,7 +5,7 @@ import (
 )
 
 type FeedbackDelay struct {
-	Src Source
+	Src Node
 
 	Time     Param // secondes (0..max)
 	Feedback Param // 0..~0.95 (clampé)
@@ -21,10 +21,14 @@ type FeedbackDelay struct {
 	// LPF state
 	lpfL, lpfR float32
 
+	// Consecutive samples written under SilenceThreshold,
+	// the tail is over once the whole line is quiet
+	quiet int
+
 	tmp Block
 }
 
-func NewFeedbackDelay(sr float64, maxDelaySeconds float64, src Source,
+func NewFeedbackDelay(sr float64, maxDelaySeconds float64, src Node,
 	time Param, feedback Param, mix Param, toneHz Param,
 ) *FeedbackDelay {
 	if maxDelaySeconds <= 0 {
@@ -46,6 +50,7 @@ func NewFeedbackDelay(sr float64, maxDelaySeconds float64, src Source,
 		maxSamps: len(bufL),
 		bufL:     bufL,
 		bufR:     bufR,
+		quiet:    len(bufL),
 	}
 }
 
@@ -67,6 +72,12 @@ func readInterp(buf []float32, idx float64) float32 {
 }
 
 func (d *FeedbackDelay) Process(b *Block) {
+	if d.IsSilent() {
+		d.lpfL, d.lpfR = 0, 0
+		b.L, b.R = zeroBlock, zeroBlock
+		return
+	}
+
 	d.tmp.Cycle = b.Cycle
 	d.Src.Process(&d.tmp)
 
@@ -84,6 +95,7 @@ func (d *FeedbackDelay) Process(b *Block) {
 
 	N := float64(d.maxSamps)
 	w := float64(d.wpos)
+	var peak float32
 
 	for i := 0; i < BlockSize; i++ {
 		xL := d.tmp.L[i]
@@ -121,8 +133,10 @@ func (d *FeedbackDelay) Process(b *Block) {
 		if wp >= len(d.bufL) {
 			wp -= len(d.bufL)
 		}
-		d.bufL[wp] = xL + fbk*fyL
-		d.bufR[wp] = xR + fbk*fyR
+		wL, wR := xL+fbk*fyL, xR+fbk*fyR
+		d.bufL[wp] = wL
+		d.bufR[wp] = wR
+		peak = max(peak, wL, -wL, wR, -wR)
 
 		// Dry/Wet mix
 		mix := clamp01(mb[i])
@@ -140,6 +154,18 @@ func (d *FeedbackDelay) Process(b *Block) {
 	}
 
 	d.wpos = int(w)
+
+	if peak < SilenceThreshold {
+		d.quiet += BlockSize
+	} else {
+		d.quiet = 0
+	}
+}
+
+// IsSilent the source is silent and the delay line has decayed,
+// echoes are never cut
+func (d *FeedbackDelay) IsSilent() bool {
+	return d.quiet >= d.maxSamps && IsSilent(d.Src)
 }
 
 func (d *FeedbackDelay) safeResolve(p Param, cycle uint64) []float32 {
@@ -149,8 +175,16 @@ func (d *FeedbackDelay) safeResolve(p Param, cycle uint64) []float32 {
 	return p.Resolve(cycle)
 }
 
-func (d *FeedbackDelay) Reset(bool) {
-	// delay comes after voicing, no reset needed
+// Reset a hard reset clears the delay line, the echoes of a non finite
+// sample would feed back forever otherwise
+func (d *FeedbackDelay) Reset(soft bool) {
+	if !soft {
+		clear(d.bufL)
+		clear(d.bufR)
+		d.lpfL, d.lpfR = 0, 0
+		d.quiet = len(d.bufL)
+	}
+	d.Src.Reset(soft)
 }
 
 // todo move this elsewhere
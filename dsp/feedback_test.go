@@ -21,3 +21,65 @@ func TestFeedbackDelay_ProcessNoAlloc(t *testing.T) {
 		t.Errorf("expected 0 allocations, got %v", allocs)
 	}
 }
+
+// impulse outputs a single sample then reports silence
+type impulse struct{ done bool }
+
+func (i *impulse) Process(b *Block) {
+	b.L, b.R = zeroBlock, zeroBlock
+	if !i.done {
+		b.L[0], b.R[0] = 1, 1
+		i.done = true
+	}
+}
+
+func (i *impulse) Reset(bool)     {}
+func (i *impulse) IsSilent() bool { return i.done }
+
+func TestFeedbackDelay_SilentAfterTail(t *testing.T) {
+	const sr = 44100.0
+	src := &impulse{done: true}
+	fb := NewFeedbackDelay(
+		sr, 1.0, src,
+		NewConstParam(0.1), NewConstParam(0.5), NewConstParam(1), nil,
+	)
+
+	if !fb.IsSilent() {
+		t.Fatal("expected an empty delay to be silent once its source is")
+	}
+	src.done = false
+
+	var block Block
+	echoes := 0
+	for n := 0; n < 1000 && !fb.IsSilent(); n++ {
+		block.Cycle++
+		fb.Process(&block)
+		if block.L[peakIndex(block.L[:])] > SilenceThreshold {
+			echoes++
+		}
+	}
+
+	// 0.5^n reaches -100 dB after ~17 repeats
+	if echoes < 16 {
+		t.Errorf("expected the tail to ring for at least 16 echoes, got %d", echoes)
+	}
+	if !fb.IsSilent() {
+		t.Fatal("expected the delay to be silent after its tail")
+	}
+
+	block.Cycle++
+	fb.Process(&block)
+	if block.L != zeroBlock || block.R != zeroBlock {
+		t.Error("expected a silent delay to output zeros")
+	}
+}
+
+func peakIndex(b []float32) int {
+	idx := 0
+	for i, v := range b {
+		if v > b[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
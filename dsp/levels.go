@@ -0,0 +1,130 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+const (
+	levelRmsTime   = 0.3  // seconds, RMS averaging
+	levelPeakFall  = 20   // dB per second, peak fall back
+	levelHoldTime  = 1.5  // seconds, peak hold before falling back
+	levelHoldFall  = 1e-3 // hold floor once released (-60 dB)
+	levelClipLevel = 1    // 0 dBFS
+)
+
+// ChannelLevel linear levels of a channel, 1 = 0 dBFS
+type ChannelLevel struct {
+	Peak float32
+	Rms  float32
+	Hold float32
+}
+
+type levelState struct {
+	peak, ms, hold float32
+	holdAge        int // blocks since the hold was set
+}
+
+// Levels measures the peak, RMS and peak hold of a stereo output, block by
+// block on the audio thread. Samples above 0 dBFS and non finite samples
+// are counted. Readers on other goroutines get the last measured levels.
+type Levels struct {
+	rmsCoef    float32
+	peakDecay  float32
+	holdBlocks int
+
+	state [2]levelState
+	out   [2][3]atomic.Uint32 // peak, rms, hold float bits
+
+	clips atomic.Uint64
+	nans  atomic.Uint64
+}
+
+func NewLevels(sr float64) *Levels {
+	blockTime := BlockSize / sr
+	return &Levels{
+		rmsCoef:    float32(1 - math.Exp(-blockTime/levelRmsTime)),
+		peakDecay:  float32(math.Pow(10, -levelPeakFall*blockTime/20)),
+		holdBlocks: int(levelHoldTime / blockTime),
+	}
+}
+
+// Measure updates the levels with b, no allocation
+func (l *Levels) Measure(b *Block) {
+	l.measure(0, b.L[:])
+	l.measure(1, b.R[:])
+}
+
+func (l *Levels) measure(ch int, buf []float32) {
+	var peak, sum float32
+	var clips, nans uint64
+	for _, x := range buf {
+		if x != x || x > math.MaxFloat32 || x < -math.MaxFloat32 {
+			nans++
+			continue
+		}
+		a := max(x, -x)
+		if a > levelClipLevel {
+			clips++
+		}
+		peak = max(peak, a)
+		sum += x * x
+	}
+
+	if clips > 0 {
+		l.clips.Add(clips)
+	}
+	if nans > 0 {
+		l.nans.Add(nans)
+	}
+
+	s := &l.state[ch]
+	s.peak = max(peak, s.peak*l.peakDecay)
+	s.ms += (sum/float32(len(buf)) - s.ms) * l.rmsCoef
+
+	s.holdAge++
+	if peak >= s.hold {
+		s.hold = peak
+		s.holdAge = 0
+	} else if s.holdAge > l.holdBlocks {
+		s.hold = max(s.peak, s.hold*l.peakDecay)
+		if s.hold < levelHoldFall {
+			s.hold = 0
+		}
+	}
+
+	l.out[ch][0].Store(math.Float32bits(s.peak))
+	l.out[ch][1].Store(math.Float32bits(float32(math.Sqrt(float64(s.ms)))))
+	l.out[ch][2].Store(math.Float32bits(s.hold))
+}
+
+// Read last levels, L then R, safe from any goroutine
+func (l *Levels) Read() [2]ChannelLevel {
+	var levels [2]ChannelLevel
+	for ch := range levels {
+		levels[ch] = ChannelLevel{
+			Peak: math.Float32frombits(l.out[ch][0].Load()),
+			Rms:  math.Float32frombits(l.out[ch][1].Load()),
+			Hold: math.Float32frombits(l.out[ch][2].Load()),
+		}
+	}
+	return levels
+}
+
+// Clips number of samples above 0 dBFS since the start
+func (l *Levels) Clips() uint64 {
+	return l.clips.Load()
+}
+
+// NaNs number of NaN or infinite samples since the start
+func (l *Levels) NaNs() uint64 {
+	return l.nans.Load()
+}
+
+// ToDb converts a linear level to dBFS, floored to min
+func ToDb(level, min float32) float32 {
+	if level <= 0 {
+		return min
+	}
+	return max(min, float32(20*math.Log10(float64(level))))
+}
@@ -0,0 +1,98 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLevels_Sine(t *testing.T) {
+	const sr = 44100.0
+	l := NewLevels(sr)
+
+	var b Block
+	phase := 0.0
+	for range 200 {
+		for i := range b.L {
+			b.L[i] = float32(.5 * math.Sin(2*math.Pi*phase))
+			b.R[i] = b.L[i] / 2
+			phase += 440 / sr
+		}
+		l.Measure(&b)
+	}
+
+	levels := l.Read()
+	if p := levels[0].Peak; math.Abs(float64(p-.5)) > 1e-3 {
+		t.Errorf("expected L peak .5, got %f", p)
+	}
+	if r := levels[0].Rms; math.Abs(float64(r)-.5/math.Sqrt2) > 1e-2 {
+		t.Errorf("expected L rms %f, got %f", .5/math.Sqrt2, r)
+	}
+	if p := levels[1].Peak; math.Abs(float64(p-.25)) > 1e-3 {
+		t.Errorf("expected R peak .25, got %f", p)
+	}
+	if l.Clips() != 0 || l.NaNs() != 0 {
+		t.Errorf("expected no clip nor nan, got %d, %d", l.Clips(), l.NaNs())
+	}
+}
+
+func TestLevels_HoldAndFall(t *testing.T) {
+	l := NewLevels(44100)
+
+	var b Block
+	b.L[0] = .8
+	l.Measure(&b)
+
+	b.L[0] = 0
+	for range l.holdBlocks {
+		l.Measure(&b)
+	}
+
+	levels := l.Read()
+	if levels[0].Hold != .8 {
+		t.Errorf("expected hold .8, got %f", levels[0].Hold)
+	}
+	if levels[0].Peak >= .8 {
+		t.Errorf("expected the peak to fall, got %f", levels[0].Peak)
+	}
+
+	for range 10 * l.holdBlocks {
+		l.Measure(&b)
+	}
+	if hold := l.Read()[0].Hold; hold != 0 {
+		t.Errorf("expected hold released, got %f", hold)
+	}
+}
+
+func TestLevels_ClipsAndNaNs(t *testing.T) {
+	l := NewLevels(44100)
+
+	var b Block
+	b.L[0] = 1.5
+	b.R[1] = -1.1
+	b.R[2] = float32(math.NaN())
+	b.L[3] = float32(math.Inf(1))
+	l.Measure(&b)
+
+	if c := l.Clips(); c != 2 {
+		t.Errorf("expected 2 clips, got %d", c)
+	}
+	if n := l.NaNs(); n != 2 {
+		t.Errorf("expected 2 nans, got %d", n)
+	}
+	if p := l.Read()[0].Peak; p != 1.5 {
+		t.Errorf("expected peak 1.5 ignoring inf, got %f", p)
+	}
+}
+
+func TestLevels_MeasureNoAlloc(t *testing.T) {
+	l := NewLevels(44100)
+
+	var b Block
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Measure(&b)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs, got %.1f", allocs)
+	}
+}
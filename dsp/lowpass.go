@@ -1,6 +1,10 @@
 package dsp
 
-import "math"
+import (
+	"math"
+
+	"github.com/viterin/vek/vek32"
+)
 
 type LowPassSVF struct {
 	Src    Node
@@ -8,8 +12,11 @@ type LowPassSVF struct {
 	ResonQ Param // Q (≈ resonance)
 	sr     float64
 
-	ic1L, ic2L float64
-	ic1R, ic2R float64
+	ic1L, ic2L float32
+	ic1R, ic2R float32
+
+	// Coefficients per sample (modulated cutoff or Q)
+	a1, a2, a3 [BlockSize]float32
 
 	tmp Block
 }
@@ -25,63 +32,112 @@ func NewLowPassSVF(sr float64, src Node, cutoff Param, q Param) *LowPassSVF {
 
 // Process low-pass TPT SVF (Zavalishin) on tmp -> b.
 func (f *LowPassSVF) Process(b *Block) {
+	if f.IsSilent() {
+		f.ic1L, f.ic2L, f.ic1R, f.ic2R = 0, 0, 0, 0 // flush the tail
+		b.L, b.R = zeroBlock, zeroBlock
+		return
+	}
+
 	f.tmp.Cycle = b.Cycle
 	f.Src.Process(&f.tmp)
 
 	cb := f.Cutoff.Resolve(b.Cycle)
 	qb := f.ResonQ.Resolve(b.Cycle)
 
+	if isFlat(cb) && isFlat(qb) {
+		a1, a2, a3 := f.coefs(cb[0], qb[0])
+		f.ic1L, f.ic2L, f.ic1R, f.ic2R = svf(b, &f.tmp, f.ic1L, f.ic2L, f.ic1R, f.ic2R, a1, a2, a3)
+		return
+	}
+
+	f.coefsBlock(cb, qb)
+	f.ic1L, f.ic2L, f.ic1R, f.ic2R = svfBlock(b, &f.tmp, f.ic1L, f.ic2L, f.ic1R, f.ic2R, &f.a1, &f.a2, &f.a3)
+}
+
+// coefs computes the coefficients for a single cutoff and Q:
+// g = tan(π fc / sr), a1 = 1 / (1 + g/Q + g²), a2 = g a1, a3 = g a2
+func (f *LowPassSVF) coefs(fc, q float32) (a1, a2, a3 float32) {
 	nyq := 0.5 * f.sr
-	minFc := 5.0
-	maxFc := 0.49 * nyq
+	fc64 := min(max(float64(fc), 5), 0.49*nyq)
+	R := 1 / min(max(q, 0.3), 20)
 
-	for i := 0; i < BlockSize; i++ {
-		fc := float64(cb[i])
-		if fc < minFc {
-			fc = minFc
-		}
-		if fc > maxFc {
-			fc = maxFc
-		}
+	g := float32(math.Tan(math.Pi * fc64 / f.sr))
+	a1 = 1 / (1 + R*g + g*g)
 
-		Q := float64(qb[i])
-		if Q < 0.3 {
-			Q = 0.3
-		}
-		if Q > 20 {
-			Q = 20
-		}
+	return a1, g * a1, g * g * a1
+}
 
-		g := math.Tan(math.Pi * fc / f.sr)
-		R := 1.0 / Q
-		h := 1.0 / (1.0 + R*g + g*g)
+// coefsBlock computes the coefficients per sample, tan is approximated (fc < sr/4)
+func (f *LowPassSVF) coefsBlock(cb, qb []float32) {
+	nyq := 0.5 * f.sr
+	g := f.a2[:] // until a1 is known
 
-		// Left channel
-		xL := float64(f.tmp.L[i])
+	vek32.MaximumNumber_Into(g, cb, 5)
+	vek32.MinimumNumber_Inplace(g, float32(0.49*nyq))
+	vek32.MulNumber_Inplace(g, float32(math.Pi/f.sr))
+	for i, x := range g {
+		g[i] = fastTan(x)
+	}
 
-		v1L := (f.ic1L + g*(xL-f.ic2L)) * h
-		v2L := f.ic2L + g*v1L
+	// a1 = 1 / (1 + g(R + g)), R = 1/Q
+	vek32.MaximumNumber_Into(f.a1[:], qb, 0.3)
+	vek32.MinimumNumber_Inplace(f.a1[:], 20)
+	vek32.Inv_Inplace(f.a1[:])
+	vek32.Add_Inplace(f.a1[:], g)
+	vek32.Mul_Inplace(f.a1[:], g)
+	vek32.AddNumber_Inplace(f.a1[:], 1)
+	vek32.Inv_Inplace(f.a1[:])
+
+	vek32.Mul_Into(f.a3[:], g, f.a1[:])
+	vek32.Mul_Inplace(f.a3[:], g)
+	vek32.Mul_Inplace(f.a2[:], f.a1[:])
+}
 
-		lpL := v2L
+// svf runs both channels with fixed coefficients, returns the new state.
+// Channels are interleaved, their recurrences overlap.
+func svf(out, in *Block, ic1L, ic2L, ic1R, ic2R, a1, a2, a3 float32) (float32, float32, float32, float32) {
+	for i := range in.L {
+		v3L := in.L[i] - ic2L
+		v3R := in.R[i] - ic2R
 
-		f.ic1L = 2*v1L - f.ic1L
-		f.ic2L = 2*v2L - f.ic2L
+		v1L := a1*ic1L + a2*v3L
+		v2L := ic2L + a2*ic1L + a3*v3L
+		v1R := a1*ic1R + a2*v3R
+		v2R := ic2R + a2*ic1R + a3*v3R
 
-		b.L[i] = float32(lpL)
+		ic1L, ic2L = 2*v1L-ic1L, 2*v2L-ic2L
+		ic1R, ic2R = 2*v1R-ic1R, 2*v2R-ic2R
 
-		// Right channel
-		xR := float64(f.tmp.R[i])
+		out.L[i] = v2L
+		out.R[i] = v2R
+	}
+	return ic1L, ic2L, ic1R, ic2R
+}
 
-		v1R := (f.ic1R + g*(xR-f.ic2R)) * h
-		v2R := f.ic2R + g*v1R
+// svfBlock runs both channels with per sample coefficients, returns the new state
+func svfBlock(out, in *Block, ic1L, ic2L, ic1R, ic2R float32, a1, a2, a3 *[BlockSize]float32) (float32, float32, float32, float32) {
+	for i := range in.L {
+		v3L := in.L[i] - ic2L
+		v3R := in.R[i] - ic2R
 
-		lpR := v2R
+		v1L := a1[i]*ic1L + a2[i]*v3L
+		v2L := ic2L + a2[i]*ic1L + a3[i]*v3L
+		v1R := a1[i]*ic1R + a2[i]*v3R
+		v2R := ic2R + a2[i]*ic1R + a3[i]*v3R
 
-		f.ic1R = 2*v1R - f.ic1R
-		f.ic2R = 2*v2R - f.ic2R
+		ic1L, ic2L = 2*v1L-ic1L, 2*v2L-ic2L
+		ic1R, ic2R = 2*v1R-ic1R, 2*v2R-ic2R
 
-		b.R[i] = float32(lpR)
+		out.L[i] = v2L
+		out.R[i] = v2R
 	}
+	return ic1L, ic2L, ic1R, ic2R
+}
+
+// IsSilent the source is silent and the filter has rung out
+func (f *LowPassSVF) IsSilent() bool {
+	return IsSilent(f.Src) &&
+		max(f.ic1L, -f.ic1L, f.ic2L, -f.ic2L, f.ic1R, -f.ic1R, f.ic2R, -f.ic2R) < SilenceThreshold
 }
 
 func (f *LowPassSVF) Reset(soft bool) {
@@ -1,6 +1,9 @@
 package dsp
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestLowPassSVF_ProcessNoAlloc(t *testing.T) {
 	const sr = 44100.0
@@ -18,3 +21,106 @@ func TestLowPassSVF_ProcessNoAlloc(t *testing.T) {
 		t.Errorf("expected 0 allocations, got %v", allocs)
 	}
 }
+
+func BenchmarkLowPassSVF_Process(b *testing.B) {
+	const sr = 44100.0
+	lfo := NewRegOscillator(sr, sineRegistry(), NewConstParam(0), NewConstParam(2), nil, nil)
+	cutoff := NewParam(2000)
+	cutoff.AddModInput(NewModInput(lfo, NewConstParam(1500), nil))
+
+	cases := []struct {
+		name   string
+		cutoff Param
+	}{
+		{"Const", NewConstParam(1000)},
+		{"Modulated", cutoff},
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			filter := NewLowPassSVF(sr, NewNoise(NewConstParam(NoiseWhite)), c.cutoff, NewConstParam(0.707))
+
+			var block Block
+			b.ResetTimer()
+
+			for n := 0; n < b.N; n++ {
+				block.Cycle++
+				filter.Process(&block)
+			}
+		})
+	}
+}
+
+// refLowPass is the float64 filter the float32 kernels replaced
+type refLowPass struct {
+	sr         float64
+	ic1L, ic2L float64
+	ic1R, ic2R float64
+}
+
+func (f *refLowPass) process(out, in *Block, cb, qb []float32) {
+	for i := 0; i < BlockSize; i++ {
+		fc := min(max(float64(cb[i]), 5), 0.49*0.5*f.sr)
+		q := min(max(float64(qb[i]), 0.3), 20)
+
+		g := math.Tan(math.Pi * fc / f.sr)
+		h := 1 / (1 + g/q + g*g)
+
+		v1 := (f.ic1L + g*(float64(in.L[i])-f.ic2L)) * h
+		v2 := f.ic2L + g*v1
+		f.ic1L, f.ic2L = 2*v1-f.ic1L, 2*v2-f.ic2L
+		out.L[i] = float32(v2)
+
+		v1 = (f.ic1R + g*(float64(in.R[i])-f.ic2R)) * h
+		v2 = f.ic2R + g*v1
+		f.ic1R, f.ic2R = 2*v1-f.ic1R, 2*v2-f.ic2R
+		out.R[i] = float32(v2)
+	}
+}
+
+func TestLowPassSVF_MatchesReference(t *testing.T) {
+	const sr = 44100.0
+	const tolerance = 1e-4
+
+	modulated := func(base, depth, rate float32) Param {
+		lfo := NewRegOscillator(sr, sineRegistry(), NewConstParam(0), NewConstParam(rate), nil, nil)
+		p := NewParam(base)
+		p.AddModInput(NewModInput(lfo, NewConstParam(depth), nil))
+		return p
+	}
+
+	cases := []struct {
+		name      string
+		cutoff, q Param
+	}{
+		{"Const", NewConstParam(1000), NewConstParam(0.707)},
+		{"Low", NewConstParam(40), NewConstParam(4)},
+		{"High", NewConstParam(20000), NewConstParam(12)},
+		{"Cutoff modulated", modulated(4000, 3900, 3), NewConstParam(2)},
+		{"Both modulated", modulated(8000, 7000, 7), modulated(5, 4, 1)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			filter := NewLowPassSVF(sr, NewNoise(NewConstParam(NoiseWhite)), c.cutoff, c.q)
+			ref := refLowPass{sr: sr}
+
+			var got, want Block
+			worst := 0.0
+
+			for cycle := uint64(1); cycle <= 200; cycle++ {
+				got.Cycle = cycle
+				filter.Process(&got)
+				ref.process(&want, &filter.tmp, c.cutoff.Resolve(cycle), c.q.Resolve(cycle))
+
+				for i := range want.L {
+					worst = max(worst, math.Abs(float64(got.L[i]-want.L[i])), math.Abs(float64(got.R[i]-want.R[i])))
+				}
+			}
+
+			if worst > tolerance {
+				t.Errorf("max deviation from reference %g, want <= %g", worst, tolerance)
+			}
+		})
+	}
+}
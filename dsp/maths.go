@@ -5,24 +5,34 @@ import (
 )
 
 // polyBLEP Polynomial Band-Limited step
-func polyBLEP(t, dt float64) float32 {
+func polyBLEP(t, dt float32) float32 {
 	if dt <= 0 {
 		return 0
 	}
 
 	if t < dt {
 		x := t / dt
-		return float32(x + x - x*x - 1.0) // 2x - x^2 - 1
+		return x + x - x*x - 1 // 2x - x^2 - 1
 	}
 
-	if t > 1.0-dt {
-		x := (t - 1.0) / dt
-		return float32(x*x + 2.0*x + 1.0) // (x+1)^2
+	if t > 1-dt {
+		x := (t - 1) / dt
+		return x*x + 2*x + 1 // (x+1)^2
 	}
 
 	return 0
 }
 
+// isFlat reports whether all values of the block are equal
+func isFlat(b []float32) bool {
+	for _, v := range b[1:] {
+		if v != b[0] {
+			return false
+		}
+	}
+	return true
+}
+
 // softClip applies a soft clipping function to the input x
 func softClip(x float32) float32 {
 	ax := float32(math.Abs(float64(x)))
@@ -79,41 +89,35 @@ func fastPanGains(p float32) (gl, gr float32) {
 	return gl, gr
 }
 
-// fastExpSemi computes 2^(semi/12) using a LUT for cents and ldexp for octaves
+// fastExpSemi computes 2^(semi/12)
 func fastExpSemi(semi float32) float32 {
-	oct := int(math.Floor(float64(semi) / 12.0))
-	remSemi := semi - float32(oct*12)
+	return fastExp2(semi * (1.0 / 12))
+}
 
-	intSemi := int(math.Floor(float64(remSemi)))
-	if intSemi < 0 {
-		intSemi = 0
+// fastExp2 computes 2^x (float32 precision): polynomial for the fraction
+// (Cephes exp2f), the integer part goes straight into the exponent bits
+func fastExp2(x float32) float32 {
+	if x > 126 {
+		x = 126
+	} else if x < -126 {
+		x = -126
 	}
-	fracSemi := remSemi - float32(intSemi)
 
-	cents := fracSemi * centsPerSemi
-	if cents < 0 {
-		cents = 0
-	}
-	if cents > centsPerSemi {
-		cents = centsPerSemi
-	}
-	i := int(cents)
-	if i >= centsMax {
-		oct += 0
-		i = centsMax
-	}
-	t := cents - float32(i)
+	n := float32(math.Floor(float64(x) + 0.5))
+	x -= n // -0.5..0.5
 
-	rFrac := centLUT[i]
-	if i < centsMax {
-		rFrac = rFrac + t*(centLUT[i+1]-rFrac)
-	}
+	px := ((((1.535336188319500e-4*x+1.339887440266574e-3)*x+
+		9.618437357674640e-3)*x+5.550332471162809e-2)*x+
+		2.402264791363012e-1)*x + 6.931472028550421e-1
+	px = 1 + px*x
 
-	if intSemi > 0 {
-		rFrac *= float32(math.Exp2(float64(intSemi) / 12.0))
-	}
+	return px * math.Float32frombits(uint32(int32(n)+127)<<23)
+}
 
-	return float32(math.Ldexp(float64(rFrac), oct))
+// fastTan computes tan(x) for x in 0..π/4 (Padé [5/4], < 1e-6 error at π/4)
+func fastTan(x float32) float32 {
+	x2 := x * x
+	return x * (945 + x2*(-105+x2)) / (945 + x2*(-420+15*x2))
 }
 
 // fastSmoothAlpha computes the smoothing coefficient alpha for a 1-pole
@@ -145,25 +149,20 @@ func fastSmoothAlpha(tc, sr float64) float32 {
 	return 1 - en
 }
 
+// blockRamp 0, 1, 2... BlockSize-1
+var blockRamp [BlockSize]float32
+
 const expNegXMax = math.Pi // ~2π*fc/sr at fc≈sr/2
 var expNegLUT [4097]float32
 
-const (
-	centsPerSemi = 100.0
-	centsMax     = 100
-)
-
-var centLUT [centsMax + 1]float32
-
 func init() {
+	for i := range blockRamp {
+		blockRamp[i] = float32(i)
+	}
+
 	// LUT expNeg
 	for i := 0; i <= 4096; i++ {
 		x := float64(i) * expNegXMax / 4096.0
 		expNegLUT[i] = float32(math.Exp(-x))
 	}
-
-	// LUT cent exp
-	for c := 0; c <= centsMax; c++ {
-		centLUT[c] = float32(math.Exp2(float64(c) / 1200.0))
-	}
 }
@@ -57,10 +57,11 @@ func (m *Mixer) Process(b *Block) {
 	m.tmp.Cycle = b.Cycle
 
 	for _, in := range m.Inputs {
-		if in == nil || in.Src == nil || in.Mute {
+		if in == nil || in.Src == nil {
 			continue
 		}
 
+		// Resolved even when skipped, ramps keep moving between notes
 		var gainB, panB []float32
 		if in.Gain != nil {
 			gainB = in.Gain.Resolve(b.Cycle)
@@ -69,6 +70,10 @@ func (m *Mixer) Process(b *Block) {
 			panB = in.Pan.Resolve(b.Cycle)
 		}
 
+		if in.Mute || IsSilent(in.Src) {
+			continue
+		}
+
 		in.Src.Process(&m.tmp)
 
 		// Fast path: no gain, no pan
@@ -128,6 +133,16 @@ func (m *Mixer) Process(b *Block) {
 	}
 }
 
+// IsSilent all inputs are muted or silent
+func (m *Mixer) IsSilent() bool {
+	for _, in := range m.Inputs {
+		if in != nil && in.Src != nil && !in.Mute && !IsSilent(in.Src) {
+			return false
+		}
+	}
+	return true
+}
+
 func (m *Mixer) Reset(soft bool) {
 	for _, in := range m.Inputs {
 		if in != nil && in.Src != nil {
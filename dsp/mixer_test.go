@@ -76,3 +76,39 @@ func TestMixer_ProcessNoAlloc(t *testing.T) {
 		})
 	}
 }
+
+// gate outputs 1 unless silent
+type gate struct{ silent bool }
+
+func (g *gate) Process(b *Block) {
+	for i := range b.L {
+		b.L[i], b.R[i] = 1, 1
+	}
+}
+func (g *gate) Reset(bool)     {}
+func (g *gate) IsSilent() bool { return g.silent }
+
+func TestMixer_RampWhileSilent(t *testing.T) {
+	const sr = 44100.0
+	src := &gate{silent: true}
+	gain := NewRampParam(sr, 0, .02)
+	m := NewMixer(nil, false)
+	m.Add(NewInput(src, gain, nil))
+
+	// The ramp runs while the input is skipped
+	var b Block
+	gain.SetBase(1)
+	for range int(.02*sr)/BlockSize + 1 {
+		b.Cycle++
+		m.Process(&b)
+	}
+
+	src.silent = false
+	b.Cycle++
+	m.Process(&b)
+	for i := range b.L {
+		if b.L[i] != 1 {
+			t.Fatalf("expected the gain at its target, got %f at %d", b.L[i], i)
+		}
+	}
+}
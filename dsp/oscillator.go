@@ -1,6 +1,10 @@
 package dsp
 
-import "math"
+import (
+	"math"
+
+	"github.com/viterin/vek/vek32"
+)
 
 type Oscillator struct {
 	shapeRegistry *ShapeRegistry
@@ -14,10 +18,15 @@ type Oscillator struct {
 	width      Param // ShapeSquare only
 	phaseShift Param
 
-	phase float64
+	phase float64 // 0..1 cycle
 	sr    float64
 	invSr float64 // 1/sr
 
+	// Block-wide kernel buffers
+	ph  [BlockSize]float32 // phase per sample
+	dt  [BlockSize]float32 // phase increment per sample
+	tmp [BlockSize]float32
+
 	buf       [BlockSize]float32
 	stampedAt uint64
 }
@@ -51,16 +60,17 @@ func (s *Oscillator) Reset(soft bool) {
 
 func (s *Oscillator) Process(block *Block) {
 	v := s.Resolve(block.Cycle)
-	for i := 0; i < BlockSize; i++ {
-		block.L[i] = v[i]
-		block.R[i] = v[i]
-	}
+	copy(block.L[:], v)
+	copy(block.R[:], v)
 }
 
 func (s *Oscillator) Resolve(cycle uint64) []float32 {
 	if s.stampedAt == cycle {
 		return s.buf[:]
 	}
+	// Stamp early, an oscillator modulating its own parameters
+	// reads its previous block instead of recursing
+	s.stampedAt = cycle
 
 	shapeBuf := s.shapeIndex.Resolve(cycle)
 	sIdx := shapeBuf[0]
@@ -94,136 +104,93 @@ func (s *Oscillator) Resolve(cycle uint64) []float32 {
 		s.processTable(fb, phb)
 	}
 
-	s.stampedAt = cycle
 	return s.buf[:]
 }
 
-func (s *Oscillator) processSaw(fb, phb []float32) {
-	const twoPi = 2 * math.Pi
-	const invTwoPi = 1.0 / twoPi
-	k := twoPi * s.invSr
-
-	for i := 0; i < BlockSize; i++ {
-		p := s.phase * invTwoPi
-		if phb != nil {
-			p += float64(phb[i])
+// accumulate fills ph with the phase of each sample (0..1 cycle, shifted
+// by phb) and dt with the per sample increment. The running phase is kept
+// in float64 across blocks, within the block it is wrapped all at once.
+func (s *Oscillator) accumulate(fb, phb []float32) {
+	phase := s.phase
+	if isFlat(fb) {
+		inc := float64(fb[0]) * s.invSr
+		vek32.MulNumber_Into(s.ph[:], blockRamp[:], float32(inc))
+		vek32.AddNumber_Inplace(s.ph[:], float32(phase))
+		phase += BlockSize * inc
+	} else {
+		// Increments in float64, float32 ones drift by ~1e-6 cycle per block
+		for i, f := range fb {
+			s.ph[i] = float32(phase)
+			phase += float64(f) * s.invSr
 		}
+	}
+	s.phase = phase - math.Floor(phase)
 
-		if p >= 1 {
-			p -= 1
-		} else if p < 0 {
-			p += 1
-		}
-
-		f := float64(fb[i])
-		dt := math.Abs(f) * s.invSr
-		if dt > 0.5 {
-			dt = 0.5
-		}
-
-		y := float32(2*p - 1)
-		y -= polyBLEP(p, dt)
-		s.buf[i] = y
-
-		s.phase += k * f
-		if s.phase >= twoPi {
-			s.phase -= twoPi
-		} else if s.phase < 0 {
-			s.phase += twoPi
-		}
+	if phb != nil {
+		vek32.Add_Inplace(s.ph[:], phb)
 	}
-}
+	wrap(s.ph[:], s.tmp[:])
 
-func (s *Oscillator) processTriangle(fb, phb []float32) {
-	const twoPi = 2 * math.Pi
-	const invTwoPi = 1.0 / twoPi
-	k := twoPi * s.invSr
-
-	for i := 0; i < BlockSize; i++ {
-		p := s.phase * invTwoPi
-		if phb != nil {
-			p += float64(phb[i])
-		}
+	vek32.MulNumber_Into(s.dt[:], fb, float32(s.invSr))
+	vek32.Abs_Inplace(s.dt[:])
+	vek32.MinimumNumber_Inplace(s.dt[:], 0.5)
+}
 
-		if p >= 1 {
-			p -= 1
-		} else if p < 0 {
-			p += 1
-		}
+// wrap brings phases back to 0..1, tmp: scratch
+func wrap(ph, tmp []float32) {
+	vek32.Floor_Into(tmp, ph)
+	vek32.Sub_Inplace(ph, tmp)
+}
 
-		tri := 1.0 - 4.0*math.Abs(p-0.5)
-		s.buf[i] = float32(tri)
+// saw writes a band-limited saw for phases ph into out
+func saw(out, ph, dt []float32) {
+	vek32.MulNumber_Into(out, ph, 2)
+	vek32.AddNumber_Inplace(out, -1)
 
-		f := float64(fb[i])
-		s.phase += k * f
-		if s.phase >= twoPi {
-			s.phase -= twoPi
-		} else if s.phase < 0 {
-			s.phase += twoPi
+	// Only samples next to the discontinuity need a correction
+	for i, t := range ph {
+		if d := dt[i]; t < d || t > 1-d {
+			out[i] -= polyBLEP(t, d)
 		}
 	}
 }
 
-func (s *Oscillator) processSquare(fb, wb, phb []float32) {
-	const twoPi = 2 * math.Pi
-	const invTwoPi = 1.0 / twoPi
-	k := twoPi * s.invSr
-
-	for i := 0; i < BlockSize; i++ {
-		p := s.phase * invTwoPi
-		if phb != nil {
-			p += float64(phb[i])
-		}
-
-		if p >= 1 {
-			p -= 1
-		} else if p < 0 {
-			p += 1
-		}
-
-		duty := 0.5
-		if wb != nil {
-			d := float64(wb[i])
-			if d < 0.01 {
-				d = 0.01
-			} else if d > 0.99 {
-				d = 0.99
-			}
-			duty = d
-		}
-
-		f := float64(fb[i])
-		dt := math.Abs(f) * s.invSr
-		if dt > 0.5 {
-			dt = 0.5
-		}
-
-		y1 := float32(2*p - 1)
-		y1 -= polyBLEP(p, dt)
+func (s *Oscillator) processSaw(fb, phb []float32) {
+	s.accumulate(fb, phb)
+	saw(s.buf[:], s.ph[:], s.dt[:])
+}
 
-		pd := p + duty
-		if pd >= 1 {
-			pd -= 1
-		}
-		y2 := float32(2*pd - 1)
-		y2 -= polyBLEP(pd, dt)
+func (s *Oscillator) processTriangle(fb, phb []float32) {
+	s.accumulate(fb, phb)
 
-		s.buf[i] = 0.5 * (y1 - y2)
+	// 1 - 4|p - 0.5|
+	vek32.AddNumber_Into(s.buf[:], s.ph[:], -0.5)
+	vek32.Abs_Inplace(s.buf[:])
+	vek32.MulNumber_Inplace(s.buf[:], -4)
+	vek32.AddNumber_Inplace(s.buf[:], 1)
+}
 
-		s.phase += k * f
-		if s.phase >= twoPi {
-			s.phase -= twoPi
-		} else if s.phase < 0 {
-			s.phase += twoPi
-		}
+// processSquare difference of two saws shifted by the duty cycle
+func (s *Oscillator) processSquare(fb, wb, phb []float32) {
+	s.accumulate(fb, phb)
+	saw(s.buf[:], s.ph[:], s.dt[:])
+
+	// Shifted phases in place, ph is not needed anymore
+	if wb != nil {
+		vek32.MaximumNumber_Into(s.tmp[:], wb, 0.01)
+		vek32.MinimumNumber_Inplace(s.tmp[:], 0.99)
+		vek32.Add_Inplace(s.ph[:], s.tmp[:])
+	} else {
+		vek32.AddNumber_Inplace(s.ph[:], 0.5)
 	}
+	wrap(s.ph[:], s.tmp[:])
+	saw(s.tmp[:], s.ph[:], s.dt[:])
+
+	vek32.Sub_Inplace(s.buf[:], s.tmp[:])
+	vek32.MulNumber_Inplace(s.buf[:], 0.5)
 }
 
 func (s *Oscillator) processTable(fb, phb []float32) {
-	const twoPi = 2 * math.Pi
-	const invTwoPi = 1.0 / twoPi
-	k := twoPi * s.invSr
-
 	if s.wavetable == nil || s.wavetable.Size == 0 {
 		for i := 0; i < BlockSize; i++ {
 			s.buf[i] = 0
@@ -231,44 +198,28 @@ func (s *Oscillator) processTable(fb, phb []float32) {
 		return
 	}
 
-	size := s.wavetable.Size
-	lastIdx := size - 1
-	sizeF := float64(size)
-
-	for i := 0; i < BlockSize; i++ {
-		p := s.phase * invTwoPi
-		if phb != nil {
-			p += float64(phb[i])
-		}
+	s.accumulate(fb, phb)
 
-		if p >= 1 {
-			p -= 1
-		} else if p < 0 {
-			p += 1
+	table := s.wavetable.Table[:s.wavetable.Size]
+	lastIdx := len(table) - 1
+	if lastIdx == 0 {
+		for i := 0; i < BlockSize; i++ {
+			s.buf[i] = table[0]
 		}
+		return
+	}
 
-		pos := p * float64(lastIdx)
-		if pos >= sizeF {
-			pos -= sizeF
-		}
+	pos := s.tmp[:]
+	vek32.MulNumber_Into(pos, s.ph[:], float32(lastIdx))
 
-		idx := int(pos)
-		next := idx + 1
-		if next >= size {
-			next = 0
+	for i, x := range pos {
+		idx := int(x)
+		if idx >= lastIdx { // phase rounded up to a full cycle
+			idx, x = 0, 0
 		}
 
-		f := float32(pos - float64(idx))
-		v1 := s.wavetable.Table[idx]
-		v2 := s.wavetable.Table[next]
-		s.buf[i] = v1 + f*(v2-v1)
-
-		freq := float64(fb[i])
-		s.phase += k * freq
-		if s.phase >= twoPi {
-			s.phase -= twoPi
-		} else if s.phase < 0 {
-			s.phase += twoPi
-		}
+		f := x - float32(idx)
+		v1 := table[idx]
+		s.buf[i] = v1 + f*(table[idx+1]-v1)
 	}
 }
@@ -1,6 +1,9 @@
 package dsp
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 type oscillatorTestCase struct {
 	name  string
@@ -67,3 +70,145 @@ func TestOscillator_ResolveNoAlloc(t *testing.T) {
 		})
 	}
 }
+
+func sineRegistry() *ShapeRegistry {
+	reg := NewShapeRegistry()
+	reg.Add(ShapeTableWave, NewSineWavetable(1024))
+	return reg
+}
+
+func BenchmarkOscillator_ProcessModulated(b *testing.B) {
+	const sr = 44100.0
+
+	for _, test := range getOscTestCases() {
+		b.Run(test.name, func(b *testing.B) {
+			lfo := NewRegOscillator(sr, sineRegistry(), NewConstParam(0), NewConstParam(5), nil, nil)
+			freq := NewParam(440)
+			freq.AddModInput(NewModInput(lfo, NewConstParam(20), nil))
+			osc := NewRegOscillator(sr, test.osc.shapeRegistry, NewConstParam(0), freq, NewConstParam(.25), NewConstParam(.3))
+
+			var block Block
+			b.ResetTimer()
+
+			for n := 0; n < b.N; n++ {
+				block.Cycle++
+				osc.Process(&block)
+			}
+		})
+	}
+}
+
+// refOscillate is the scalar float64 oscillator the block kernels replaced,
+// phase in radians
+func refOscillate(shape OscShape, table *Wavetable, sr float64, phase *float64, fb, wb, phb, out []float32) {
+	const twoPi = 2 * math.Pi
+	k := twoPi / sr
+
+	blep := func(t, dt float64) float64 {
+		if t < dt {
+			x := t / dt
+			return x + x - x*x - 1
+		}
+		if t > 1-dt {
+			x := (t - 1) / dt
+			return x*x + 2*x + 1
+		}
+		return 0
+	}
+
+	for i := range out {
+		p := *phase / twoPi
+		if phb != nil {
+			p += float64(phb[i])
+		}
+		p -= math.Floor(p)
+
+		f := float64(fb[i])
+		dt := min(math.Abs(f)/sr, 0.5)
+
+		switch shape {
+		case ShapeSaw:
+			out[i] = float32(2*p - 1 - blep(p, dt))
+		case ShapeTriangle:
+			out[i] = float32(1 - 4*math.Abs(p-0.5))
+		case ShapeSquare:
+			duty := 0.5
+			if wb != nil {
+				duty = min(max(float64(wb[i]), 0.01), 0.99)
+			}
+			pd := p + duty
+			if pd >= 1 {
+				pd -= 1
+			}
+			y1 := 2*p - 1 - blep(p, dt)
+			y2 := 2*pd - 1 - blep(pd, dt)
+			out[i] = float32(0.5 * (y1 - y2))
+		case ShapeTableWave:
+			pos := p * float64(table.Size-1)
+			idx := int(pos)
+			next := (idx + 1) % table.Size
+			fr := float32(pos - float64(idx))
+			out[i] = table.Table[idx] + fr*(table.Table[next]-table.Table[idx])
+		}
+
+		*phase += k * f
+		*phase -= twoPi * math.Floor(*phase/twoPi)
+	}
+}
+
+func TestOscillator_MatchesReference(t *testing.T) {
+	const sr = 44100.0
+	const tolerance = 1e-3
+
+	// Frequencies never land exactly on a cycle within the test: the table
+	// lookup jumps by one step there and rounding can pick either side
+	mods := []struct {
+		name       string
+		freq       func() Param
+		phaseShift Param
+		width      Param
+	}{
+		{"Const", func() Param { return NewConstParam(440.5) }, nil, nil},
+		{"High", func() Param { return NewConstParam(9000.5) }, NewConstParam(.25), NewConstParam(.2)},
+		{"Modulated", func() Param {
+			lfo := NewRegOscillator(sr, sineRegistry(), NewConstParam(0), NewConstParam(5), nil, nil)
+			freq := NewParam(220)
+			freq.AddModInput(NewModInput(lfo, NewConstParam(300), nil)) // through zero
+			return freq
+		}, NewConstParam(-.3), NewConstParam(.7)},
+	}
+
+	for _, test := range getOscTestCases() {
+		for _, m := range mods {
+			t.Run(test.name+" "+m.name, func(t *testing.T) {
+				freq := m.freq()
+				osc := NewRegOscillator(sr, test.osc.shapeRegistry, NewConstParam(0), freq, m.phaseShift, m.width)
+
+				var phase float64
+				var want [BlockSize]float32
+				worst := 0.0
+
+				for cycle := uint64(1); cycle <= 200; cycle++ {
+					got := osc.Resolve(cycle)
+
+					var wb, phb []float32
+					if m.width != nil {
+						wb = m.width.Resolve(cycle)
+					}
+					if m.phaseShift != nil {
+						phb = m.phaseShift.Resolve(cycle)
+					}
+					refOscillate(test.shape, test.table, sr, &phase, freq.Resolve(cycle), wb, phb, want[:])
+
+					for i := range want {
+						worst = max(worst, math.Abs(float64(got[i]-want[i])))
+					}
+				}
+
+				if worst > tolerance {
+					t.Errorf("max deviation from reference %g, want <= %g", worst, tolerance)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package dsp
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ParallelRenderer processes nodes on a fixed pool of worker goroutines.
+//
+// Handoff is lock-free: jobs are claimed with an atomic counter and the
+// calling goroutine renders too, so a sleeping worker never delays a block,
+// workers are only woken up with non-blocking sends. Nodes rendered in the
+// same call must not share mutable state, shared parameters must be
+// resolved beforehand (see PolyVoice.SetRenderer).
+type ParallelRenderer struct {
+	wake []chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	// Current jobs, written before claim is published
+	nodes  []Node
+	blocks []*Block
+
+	claim atomic.Uint64 // jobs count << 32 | next job
+	done  atomic.Uint32
+}
+
+// NewParallelRenderer workers: helper goroutines, the caller of Render is one more
+func NewParallelRenderer(workers int) *ParallelRenderer {
+	r := &ParallelRenderer{
+		wake: make([]chan struct{}, workers),
+		quit: make(chan struct{}),
+	}
+
+	for i := range r.wake {
+		r.wake[i] = make(chan struct{}, 1)
+		r.wg.Add(1)
+		go r.worker(r.wake[i])
+	}
+
+	return r
+}
+
+// Render processes nodes[i] into blocks[i], returns once all are done.
+// Block cycles must be set by the caller.
+func (r *ParallelRenderer) Render(nodes []Node, blocks []*Block) {
+	n := len(nodes)
+	if n == 0 {
+		return
+	}
+
+	r.nodes, r.blocks = nodes, blocks
+	r.done.Store(0)
+	r.claim.Store(uint64(n) << 32)
+
+	// Wake up as many workers as needed, the caller takes a job too
+	for i := 0; i < n-1 && i < len(r.wake); i++ {
+		select {
+		case r.wake[i] <- struct{}{}:
+		default: // already awake
+		}
+	}
+
+	r.work()
+
+	// Jobs claimed by workers are being rendered
+	for r.done.Load() < uint32(n) {
+		runtime.Gosched()
+	}
+}
+
+// Close stops the workers
+func (r *ParallelRenderer) Close() {
+	close(r.quit)
+	r.wg.Wait()
+}
+
+func (r *ParallelRenderer) worker(wake chan struct{}) {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.quit:
+			return
+		case <-wake:
+			r.work()
+		}
+	}
+}
+
+func (r *ParallelRenderer) work() {
+	for {
+		c := r.claim.Add(1)
+		next, n := uint32(c)-1, uint32(c>>32)
+		if next >= n {
+			return
+		}
+
+		r.nodes[next].Process(r.blocks[next])
+		r.done.Add(1)
+	}
+}
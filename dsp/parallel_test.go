@@ -0,0 +1,114 @@
+package dsp
+
+import (
+	"runtime"
+	"testing"
+)
+
+// unisonPoly 16 voices x 16 unison saws, the detune spread is shared by all voices
+func unisonPoly() (*PolyVoice, Param) {
+	const sr = 44100.0
+
+	reg := NewShapeRegistry()
+	saw := reg.Add(ShapeSaw)
+	spread := NewParam(20)
+
+	fact := func() *Voice {
+		freq := NewParam(440)
+		env := NewADSR(sr, NewConstParam(.01), NewConstParam(.05), NewConstParam(.8), NewConstParam(.1))
+		unison := NewUnison(UnisonOpts{
+			SampleRate: sr,
+			NumVoices:  NewConstParam(16),
+			Factory: func(ph, dt Param) Node {
+				return NewRegOscillator(sr, reg, NewConstParam(saw), NewTunerParam(freq, dt), ph, nil)
+			},
+			PanSpread:    NewConstParam(1),
+			PhaseSpread:  NewConstParam(.5),
+			DetuneSpread: spread,
+			CurveGamma:   NewConstParam(1),
+		})
+
+		return NewVoice(unison, freq, env)
+	}
+
+	poly := NewPolyVoice(16, NewConstParam(16), NewConstParam(PolyStealOldest), fact)
+	for i := 0; i < 16; i++ {
+		poly.NoteOn(40+i, 1.0)
+	}
+
+	return poly, spread
+}
+
+func TestPoly_ParallelMatchesSerial(t *testing.T) {
+	renderer := NewParallelRenderer(3)
+	defer renderer.Close()
+
+	serial, _ := unisonPoly()
+	parallel, spread := unisonPoly()
+	parallel.SetRenderer(renderer, spread)
+
+	var bs, bp Block
+	for c := 0; c < 64; c++ {
+		bs.Cycle++
+		bp.Cycle++
+
+		// Release some notes on the way
+		if c == 32 {
+			for k := 40; k < 48; k++ {
+				serial.NoteOff(k)
+				parallel.NoteOff(k)
+			}
+		}
+
+		serial.Process(&bs)
+		parallel.Process(&bp)
+
+		if bs.L != bp.L || bs.R != bp.R {
+			t.Fatalf("block %d: parallel output differs from serial", c)
+		}
+	}
+}
+
+func TestPoly_ProcessParallelNoAlloc(t *testing.T) {
+	renderer := NewParallelRenderer(3)
+	defer renderer.Close()
+
+	poly, spread := unisonPoly()
+	poly.SetRenderer(renderer, spread)
+
+	var block Block
+	allocs := testing.AllocsPerRun(100, func() {
+		block.Cycle++
+		poly.Process(&block)
+	})
+
+	if allocs != 0 {
+		t.Errorf("expected 0 allocs, got %f", allocs)
+	}
+}
+
+func BenchmarkPoly_ProcessUnison(b *testing.B) {
+	b.Run("serial", func(b *testing.B) {
+		poly, _ := unisonPoly()
+		benchmarkPoly(b, poly)
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		renderer := NewParallelRenderer(runtime.NumCPU() - 1)
+		defer renderer.Close()
+
+		poly, spread := unisonPoly()
+		poly.SetRenderer(renderer, spread)
+		benchmarkPoly(b, poly)
+	})
+}
+
+func benchmarkPoly(b *testing.B, poly *PolyVoice) {
+	var block Block
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		block.Cycle++
+		poly.Process(&block)
+	}
+}
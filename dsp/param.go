@@ -9,8 +9,74 @@ type Param interface {
 	RemoveModInput(ParamModInput)
 }
 
+// TimedParam can change its base at a sample offset of the next block
+type TimedParam interface {
+	SetBaseAt(value float32, offset int)
+}
+
+// SetBaseAt sets the base of p at the given sample offset of the next block,
+// immediately if p is not a TimedParam
+func SetBaseAt(p Param, value float32, offset int) {
+	if tp, ok := p.(TimedParam); ok && offset > 0 {
+		tp.SetBaseAt(value, offset)
+		return
+	}
+	p.SetBase(value)
+}
+
+// pendingBase base change scheduled at a sample offset of the next block
+type pendingBase struct {
+	set bool
+	at  int
+	val float32
+}
+
+// schedule replaces base by v at offset, a previously scheduled change lands immediately
+func (p *pendingBase) schedule(base *float32, v float32, offset int) {
+	if p.set {
+		*base = p.val
+	}
+
+	p.set = offset > 0
+	if !p.set {
+		*base = v
+		return
+	}
+	p.at, p.val = min(offset, BlockSize-1), v
+}
+
+// latest returns the pending value if any, base otherwise
+func (p *pendingBase) latest(base float32) float32 {
+	if p.set {
+		return p.val
+	}
+	return base
+}
+
+// fill writes base to buf, switching to the pending value at its offset,
+// and returns the base of the following blocks
+func (p *pendingBase) fill(buf []float32, base float32) float32 {
+	if !p.set {
+		for i := range buf {
+			buf[i] = base
+		}
+		return base
+	}
+
+	for i := 0; i < p.at; i++ {
+		buf[i] = base
+	}
+	for i := p.at; i < len(buf); i++ {
+		buf[i] = p.val
+	}
+	p.set = false
+
+	return p.val
+}
+
 type ParamSimple struct {
 	base      float32
+	pending   pendingBase
 	inputs    []ParamModInput
 	buf       [BlockSize]float32
 	stampedAt uint64
@@ -22,8 +88,9 @@ func NewParam(base float32) *ParamSimple {
 	}
 }
 
-func (s *ParamSimple) SetBase(v float32)           { s.base = v }
-func (s *ParamSimple) GetBase() float32            { return s.base }
+func (s *ParamSimple) SetBase(v float32)           { s.pending.set = false; s.base = v }
+func (s *ParamSimple) SetBaseAt(v float32, at int) { s.pending.schedule(&s.base, v, at) }
+func (s *ParamSimple) GetBase() float32            { return s.pending.latest(s.base) }
 func (s *ParamSimple) ModInputs() *[]ParamModInput { return &s.inputs }
 
 func (s *ParamSimple) Resolve(cycle uint64) []float32 {
@@ -31,9 +98,7 @@ func (s *ParamSimple) Resolve(cycle uint64) []float32 {
 		return s.buf[:]
 	}
 
-	for i := 0; i < BlockSize; i++ {
-		s.buf[i] = s.base
-	}
+	s.base = s.pending.fill(s.buf[:], s.base)
 
 	for _, mi := range s.inputs {
 		src := mi.Src().Resolve(cycle)
@@ -0,0 +1,127 @@
+package dsp
+
+// RampParam moves linearly toward its base over a fixed time, modulation
+// inputs are added on top, unsmoothed. Cheaper than SmoothedParam, and
+// once the ramp is done without modulation the block stays flat and is
+// only refilled when the value changes.
+type RampParam struct {
+	base   float32
+	cur    float32
+	inc    float32 // per sample increment while ramping
+	steps  int     // ramp length in samples
+	left   int     // samples left in the current ramp
+	delay  int     // samples before the current ramp starts
+	inputs []ParamModInput
+
+	buf       [BlockSize]float32
+	flat      bool // buf holds cur only
+	stampedAt uint64
+}
+
+// NewRampParam time: ramp duration in seconds, ex: 0.02 gain/cutoff
+func NewRampParam(sr float64, base float32, time float64) *RampParam {
+	r := &RampParam{
+		base:  base,
+		cur:   base,
+		steps: max(1, int(time*sr)),
+	}
+
+	for i := range r.buf {
+		r.buf[i] = base
+	}
+	r.flat = true
+
+	return r
+}
+
+func (r *RampParam) SetBase(v float32) {
+	if v == r.base {
+		return
+	}
+
+	r.base = v
+	r.left = r.steps
+	r.delay = 0
+	r.inc = (v - r.cur) / float32(r.steps)
+}
+
+// SetBaseAt starts the ramp toward v at the given sample offset of the next block
+func (r *RampParam) SetBaseAt(v float32, offset int) {
+	r.SetBase(v)
+	if r.left > 0 {
+		r.delay = min(offset, BlockSize-1)
+	}
+}
+
+func (r *RampParam) GetBase() float32            { return r.base }
+func (r *RampParam) ModInputs() *[]ParamModInput { return &r.inputs }
+
+func (r *RampParam) AddModInput(mi ParamModInput) {
+	r.inputs = append(r.inputs, mi)
+}
+
+func (r *RampParam) RemoveModInput(m ParamModInput) {
+	for i, mi := range r.inputs {
+		if mi == m {
+			r.inputs = append(r.inputs[:i], r.inputs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *RampParam) Resolve(cycle uint64) []float32 {
+	if r.stampedAt == cycle {
+		return r.buf[:]
+	}
+	r.stampedAt = cycle
+
+	// Flat fast path: settled, no modulation
+	if r.left == 0 && len(r.inputs) == 0 {
+		if !r.flat {
+			for i := range r.buf {
+				r.buf[i] = r.cur
+			}
+			r.flat = true
+		}
+		return r.buf[:]
+	}
+
+	// Ramp
+	if r.left == 0 {
+		for i := range r.buf {
+			r.buf[i] = r.cur
+		}
+	} else {
+		for i := range r.buf {
+			if r.delay > 0 {
+				r.delay--
+			} else if r.left > 0 {
+				r.cur += r.inc
+				r.left--
+				if r.left == 0 {
+					r.cur = r.base // no drift
+				}
+			}
+			r.buf[i] = r.cur
+		}
+	}
+	r.flat = false
+
+	// Modulation
+	for _, mi := range r.inputs {
+		src := mi.Src().Resolve(cycle)
+		amount := mi.Amount().Resolve(cycle)
+		mapf := mi.Map()
+		if mapf == nil {
+			for i := 0; i < BlockSize; i++ {
+				r.buf[i] += amount[i] * src[i]
+			}
+		} else {
+			for i := 0; i < BlockSize; i++ {
+				r.buf[i] += amount[i] * mapf(src[i])
+			}
+		}
+	}
+
+	return r.buf[:]
+}
@@ -0,0 +1,109 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRampParam_ResolveNoAlloc(t *testing.T) {
+	p := NewRampParam(44100, 0, 0.02)
+	p.AddModInput(NewModInput(NewConstParam(1), NewConstParam(.5), nil))
+
+	var cycle uint64
+	allocs := testing.AllocsPerRun(1000, func() {
+		cycle++
+		p.SetBase(float32(cycle % 2))
+		p.Resolve(cycle)
+	})
+
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations, got %v", allocs)
+	}
+}
+
+// maxStep returns the largest sample to sample difference of a parameter
+// over n blocks, changing its base to v after the first one.
+func maxStep(p Param, v float32, n int) float32 {
+	var step float32
+	prev := p.Resolve(1)[BlockSize-1]
+
+	p.SetBase(v)
+	for c := 2; c < n+2; c++ {
+		for _, x := range p.Resolve(uint64(c)) {
+			step = max(step, float32(math.Abs(float64(x-prev))))
+			prev = x
+		}
+	}
+
+	return step
+}
+
+func TestRampParam_NoStepDiscontinuity(t *testing.T) {
+	const sr = 44100.0
+	const ramp = 0.02
+
+	// A plain param jumps at the block boundary
+	if step := maxStep(NewParam(0), 1, 8); step != 1 {
+		t.Errorf("expected a plain param to jump by 1, got %g", step)
+	}
+
+	// A ramp spreads the change over its duration
+	p := NewRampParam(sr, 0, ramp)
+	expected := float32(1 / (ramp * sr))
+	if step := maxStep(p, 1, int(ramp*sr)/BlockSize+2); step > expected*1.01 {
+		t.Errorf("expected steps of at most %g, got %g", expected, step)
+	}
+	if v := p.Resolve(1000)[0]; v != 1 {
+		t.Errorf("expected ramp to settle at 1, got %g", v)
+	}
+
+	// Retarget mid ramp, from the current value
+	p = NewRampParam(sr, 0, ramp)
+	p.SetBase(1)
+	p.Resolve(1)
+	if step := maxStep(p, -1, 8); step > 2*expected*1.01 {
+		t.Errorf("expected steps of at most %g after retarget, got %g", 2*expected, step)
+	}
+}
+
+func TestRampParam_Flat(t *testing.T) {
+	p := NewRampParam(44100, .5, 0.001)
+	p.SetBase(1)
+
+	var cycle uint64
+	for ; cycle < 4; cycle++ {
+		p.Resolve(cycle + 1)
+	}
+
+	// Settled, the whole block is flat
+	for i, v := range p.Resolve(cycle + 1) {
+		if v != 1 {
+			t.Fatalf("expected flat block at 1, got %g at %d", v, i)
+		}
+	}
+
+	// Modulation is added as is
+	p.AddModInput(NewModInput(NewConstParam(1), NewConstParam(.25), nil))
+	if v := p.Resolve(cycle + 2)[0]; v != 1.25 {
+		t.Errorf("expected 1.25, got %g", v)
+	}
+
+	p.RemoveModInput((*p.ModInputs())[0])
+	if v := p.Resolve(cycle + 3)[BlockSize-1]; v != 1 {
+		t.Errorf("expected 1 once unmodulated, got %g", v)
+	}
+}
+
+func BenchmarkRampParam_Flat(b *testing.B) {
+	p := NewRampParam(44100, 1, 0.02)
+	for i := 0; i < b.N; i++ {
+		p.Resolve(uint64(i + 1))
+	}
+}
+
+func BenchmarkSmoothedParam_Flat(b *testing.B) {
+	p := NewSmoothedParam(44100, 1, NewConstParam(0.02))
+	for i := 0; i < b.N; i++ {
+		p.Resolve(uint64(i + 1))
+	}
+}
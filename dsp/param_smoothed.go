@@ -7,8 +7,9 @@ type SmoothedParam struct {
 	sr    float64
 	tc    Param
 
-	base   float32
-	inputs []ParamModInput
+	base    float32
+	pending pendingBase
+	inputs  []ParamModInput
 
 	last float32
 
@@ -51,8 +52,9 @@ func (s *SmoothedParam) AddModInput(mi ParamModInput) {
 	s.inputs = append(s.inputs, mi)
 }
 
-func (s *SmoothedParam) SetBase(v float32)           { s.base = v }
-func (s *SmoothedParam) GetBase() float32            { return s.base }
+func (s *SmoothedParam) SetBase(v float32)           { s.pending.set = false; s.base = v }
+func (s *SmoothedParam) SetBaseAt(v float32, at int) { s.pending.schedule(&s.base, v, at) }
+func (s *SmoothedParam) GetBase() float32            { return s.pending.latest(s.base) }
 func (s *SmoothedParam) ModInputs() *[]ParamModInput { return &s.inputs }
 
 func (s *SmoothedParam) Resolve(cycle uint64) []float32 {
@@ -61,9 +63,7 @@ func (s *SmoothedParam) Resolve(cycle uint64) []float32 {
 	}
 
 	// Base
-	for i := 0; i < BlockSize; i++ {
-		s.buf[i] = s.base
-	}
+	s.base = s.pending.fill(s.buf[:], s.base)
 
 	// Modulation
 	for _, mi := range s.inputs {
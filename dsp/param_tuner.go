@@ -1,5 +1,7 @@
 package dsp
 
+import "github.com/viterin/vek/vek32"
+
 type TunerParam struct {
 	Param
 	st        Param
@@ -23,29 +25,20 @@ func (p *TunerParam) Resolve(cycle uint64) []float32 {
 	base := p.Param.Resolve(cycle)
 	semi := p.st.Resolve(cycle)
 
-	// Fast path: if semi is (nearly) constant across the block, compute once.
-	const eps = 1e-9
-	s0 := semi[0]
-	isFlat := true
-	for i := 1; i < BlockSize; i++ {
-		if diff := semi[i] - s0; diff > eps || diff < -eps {
-			isFlat = false
-			break
-		}
-	}
-	if isFlat {
-		r := fastExpSemi(s0)
-		for i := 0; i < BlockSize; i++ {
-			p.buf[i] = base[i] * r
-		}
+	// Fast path: constant semi across the block, compute once
+	if isFlat(semi) {
+		vek32.MulNumber_Into(p.buf[:], base, fastExpSemi(semi[0]))
 		p.stampedAt = cycle
 		return p.buf[:]
 	}
 
-	// General path
-	for i := 0; i < BlockSize; i++ {
-		p.buf[i] = base[i] * fastExpSemi(semi[i])
+	// General path: ratio per sample, then scale the base
+	vek32.MulNumber_Into(p.buf[:], semi, 1.0/12)
+	for i, x := range p.buf {
+		p.buf[i] = fastExp2(x)
 	}
+	vek32.Mul_Inplace(p.buf[:], base)
+
 	p.stampedAt = cycle
 	return p.buf[:]
 }
@@ -0,0 +1,88 @@
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func BenchmarkTunerParam_Resolve(b *testing.B) {
+	const sr = 44100.0
+	lfo := NewRegOscillator(sr, sineRegistry(), NewConstParam(0), NewConstParam(5), nil, nil)
+	vibrato := NewParam(0)
+	vibrato.AddModInput(NewModInput(lfo, NewConstParam(.5), nil))
+
+	cases := []struct {
+		name string
+		st   Param
+	}{
+		{"Flat", NewConstParam(7)},
+		{"Modulated", vibrato},
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			p := NewTunerParam(NewConstParam(440), c.st)
+			b.ResetTimer()
+
+			for n := 0; n < b.N; n++ {
+				p.Resolve(uint64(n + 1))
+			}
+		})
+	}
+}
+
+func TestTunerParam_MatchesExp2(t *testing.T) {
+	const sr = 44100.0
+	const tolerance = 1e-6 // relative, ~0.002 cent
+
+	lfo := NewRegOscillator(sr, sineRegistry(), NewConstParam(0), NewConstParam(30), nil, nil)
+	st := NewParam(-3)
+	st.AddModInput(NewModInput(lfo, NewConstParam(60), nil))
+
+	cases := []struct {
+		name string
+		st   Param
+	}{
+		{"Flat", NewConstParam(7.31)},
+		{"Flat negative", NewConstParam(-25.5)},
+		{"Modulated", st},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := NewTunerParam(NewConstParam(440), c.st)
+			worst := 0.0
+
+			for cycle := uint64(1); cycle <= 100; cycle++ {
+				got := p.Resolve(cycle)
+				semi := c.st.Resolve(cycle)
+
+				for i := range got {
+					want := 440 * math.Exp2(float64(semi[i])/12)
+					worst = max(worst, math.Abs(float64(got[i])-want)/want)
+				}
+			}
+
+			if worst > tolerance {
+				t.Errorf("max relative error %g, want <= %g", worst, tolerance)
+			}
+		})
+	}
+}
+
+func TestTunerParam_ResolveNoAlloc(t *testing.T) {
+	lfo := NewRegOscillator(44100, sineRegistry(), NewConstParam(0), NewConstParam(5), nil, nil)
+	st := NewParam(0)
+	st.AddModInput(NewModInput(lfo, NewConstParam(.5), nil))
+	p := NewTunerParam(NewConstParam(440), st)
+
+	var cycle uint64
+	allocs := testing.AllocsPerRun(1000, func() {
+		cycle++
+		p.Resolve(cycle)
+	})
+
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations, got %v", allocs)
+	}
+}
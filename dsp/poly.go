@@ -1,11 +1,14 @@
 package dsp
 
+import "github.com/viterin/vek/vek32"
+
 type polyVoice struct {
 	key   int
 	voice *Voice
 	input *Input
 	index uint64
 	gate  bool
+	out   Block // parallel rendering output
 }
 
 const (
@@ -26,6 +29,12 @@ type PolyVoice struct {
 	stolen     [MaxStolenRetain]int
 	stolenHead int
 	stolenSize int
+
+	// Parallel rendering, see SetRenderer
+	renderer *ParallelRenderer
+	shared   []ParamModulator
+	jobs     []Node
+	outs     []*Block
 }
 
 func NewPolyVoice(maxVoices int, activeVoices Param, stealMode Param, factory func() *Voice) *PolyVoice {
@@ -49,6 +58,11 @@ func NewPolyVoice(maxVoices int, activeVoices Param, stealMode Param, factory fu
 }
 
 func (p *PolyVoice) NoteOn(key int, vel float32) {
+	p.NoteOnAt(key, vel, 0)
+}
+
+// NoteOnAt starts the note at the given sample offset of the next block
+func (p *PolyVoice) NoteOnAt(key int, vel float32, offset int) {
 	p.index++
 	av := int(p.activeVoices.Resolve(p.index)[0])
 
@@ -57,7 +71,7 @@ func (p *PolyVoice) NoteOn(key int, vel float32) {
 		v := p.voices[i]
 		if v.key == key {
 			v.index = p.index
-			v.voice.NoteOn(key, vel)
+			v.voice.NoteOnAt(key, vel, offset)
 			v.input.Mute = false
 			v.gate = true
 			return
@@ -70,7 +84,7 @@ func (p *PolyVoice) NoteOn(key int, vel float32) {
 		if v.voice.IsIdle() {
 			v.key = key
 			v.index = p.index
-			v.voice.NoteOn(key, vel)
+			v.voice.NoteOnAt(key, vel, offset)
 			v.input.Mute = false
 			v.gate = true
 			return
@@ -94,20 +108,25 @@ func (p *PolyVoice) NoteOn(key int, vel float32) {
 
 	lru.key = key
 	lru.index = p.index
-	lru.voice.NoteOff()
-	lru.voice.NoteOn(key, vel)
+	lru.voice.NoteOffAt(offset)
+	lru.voice.NoteOnAt(key, vel, offset)
 	lru.input.Mute = false
 	lru.gate = true
 }
 
 func (p *PolyVoice) NoteOff(key int) {
+	p.NoteOffAt(key, 0)
+}
+
+// NoteOffAt releases the note at the given sample offset of the next block
+func (p *PolyVoice) NoteOffAt(key int, offset int) {
 	if p.dropStolen(key) {
 		return
 	}
 
 	for _, s := range p.voices {
 		if s.key == key {
-			s.voice.NoteOff()
+			s.voice.NoteOffAt(offset)
 			s.input.Mute = true
 			s.key = 0
 			s.index = 0
@@ -117,7 +136,7 @@ func (p *PolyVoice) NoteOff(key int) {
 			if stolenKey, found := p.dequeueStolen(); found {
 				s.key = stolenKey
 				s.index = p.index
-				s.voice.NoteOn(stolenKey, 1.0) // velocity hardcoded to 1.0
+				s.voice.NoteOnAt(stolenKey, 1.0, offset) // velocity hardcoded to 1.0
 				s.input.Mute = false
 				s.gate = true
 			}
@@ -126,11 +145,62 @@ func (p *PolyVoice) NoteOff(key int) {
 	}
 }
 
+// SetRenderer renders the active voices in parallel, nil to go back to serial.
+// shared lists the parameters and modulators used by several voices, they
+// are resolved before the voices are dispatched so that workers only read them.
+func (p *PolyVoice) SetRenderer(r *ParallelRenderer, shared ...ParamModulator) {
+	p.renderer = r
+	p.shared = shared
+	p.jobs = make([]Node, 0, len(p.voices))
+	p.outs = make([]*Block, 0, len(p.voices))
+}
+
 func (p *PolyVoice) Process(b *Block) {
 	for _, s := range p.voices {
 		s.input.Mute = s.voice.IsIdle()
 	}
-	p.Mixer.Process(b)
+
+	if p.renderer == nil {
+		p.Mixer.Process(b)
+		return
+	}
+
+	p.processParallel(b)
+}
+
+// IsSilent all voices are idle, a pending note on wakes the voice up
+func (p *PolyVoice) IsSilent() bool {
+	for _, s := range p.voices {
+		if !s.voice.IsIdle() {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *PolyVoice) processParallel(b *Block) {
+	for _, s := range p.shared {
+		s.Resolve(b.Cycle)
+	}
+
+	p.jobs, p.outs = p.jobs[:0], p.outs[:0]
+	for _, s := range p.voices {
+		if s.input.Mute {
+			continue
+		}
+		s.out.Cycle = b.Cycle
+		p.jobs = append(p.jobs, s.voice)
+		p.outs = append(p.outs, &s.out)
+	}
+
+	p.renderer.Render(p.jobs, p.outs)
+
+	// Sum in voice order, same result as the serial mixer
+	b.L, b.R = [BlockSize]float32{}, [BlockSize]float32{}
+	for _, out := range p.outs {
+		vek32.Add_Inplace(b.L[:], out.L[:])
+		vek32.Add_Inplace(b.R[:], out.R[:])
+	}
 }
 
 func (p *PolyVoice) AllNotesOff() {
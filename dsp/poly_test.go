@@ -51,3 +51,39 @@ func TestPoly_ProcessNoAlloc(t *testing.T) {
 		t.Errorf("expected 0 allocs, got %f", allocs)
 	}
 }
+
+func TestPoly_IsSilent(t *testing.T) {
+	const sr = 44100.0
+
+	// The envelope has to drive the output to run
+	fact := func() *Voice {
+		env := NewADSR(sr, NewConstParam(.01), NewConstParam(.05), NewConstParam(.8), NewConstParam(.1))
+		gain := NewParam(0)
+		gain.AddModInput(NewModInput(env, NewConstParam(1), nil))
+		return NewVoice(NewVca(NewNoise(NewConstParam(NoiseWhite)), gain), NewParam(440), env)
+	}
+
+	poly := NewPolyVoice(4, NewConstParam(4), NewConstParam(PolyStealOldest), fact)
+	if !poly.IsSilent() {
+		t.Fatal("expected no voice to be playing")
+	}
+
+	poly.NoteOn(60, 1)
+	if poly.IsSilent() {
+		t.Fatal("expected a pending note on to wake the voices up")
+	}
+
+	var block Block
+	block.Cycle++
+	poly.Process(&block)
+	poly.NoteOff(60)
+
+	for n := 0; n < 1000 && !poly.IsSilent(); n++ {
+		block.Cycle++
+		poly.Process(&block)
+	}
+
+	if !poly.IsSilent() {
+		t.Error("expected voices to be silent after release")
+	}
+}
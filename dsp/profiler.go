@@ -0,0 +1,167 @@
+package dsp
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	profileAvgTime  = 0.5 // seconds, rolling average
+	profilePeakTime = 2   // seconds, peak fall back
+)
+
+// ProfileLoad rolling load, 1 = the whole block budget (BlockSize/sr)
+type ProfileLoad struct {
+	Name string
+	Avg  float32
+	Peak float32
+	Last float32
+}
+
+type ProfileEntry struct {
+	ProfileLoad
+	elapsed  atomic.Int64 // ns, current block, summed over every node of the entry
+	block    int64        // ns, last block
+	exclude  []*ProfileEntry
+	parallel atomic.Bool // see Profiler.SetParallel
+}
+
+func (e *ProfileEntry) update(load, avgCoef, peakDecay float32) {
+	e.Last = load
+	e.Avg += (load - e.Avg) * avgCoef
+	e.Peak = max(load, e.Peak*peakDecay)
+}
+
+// Profiler measures the time spent per block processing named nodes and
+// the whole graph against the real-time budget. It is opt-in, only nodes
+// wrapped with Wrap are measured and the profiler has to process the root.
+type Profiler struct {
+	root      Node
+	budget    float32 // ns per block
+	avgCoef   float32
+	peakDecay float32
+
+	total   ProfileEntry
+	entries []*ProfileEntry
+	byName  map[string]*ProfileEntry
+}
+
+func NewProfiler(sr float64, root Node) *Profiler {
+	blockTime := BlockSize / sr
+	return &Profiler{
+		root:      root,
+		budget:    float32(blockTime * 1e9),
+		avgCoef:   float32(blockTime / profileAvgTime),
+		peakDecay: float32(1 - blockTime/profilePeakTime),
+		total:     ProfileEntry{ProfileLoad: ProfileLoad{Name: "Total"}},
+		byName:    make(map[string]*ProfileEntry),
+	}
+}
+
+// Wrap measures n under the given name, nodes sharing a name add up (one
+// per voice for instance). The time of a node includes its sources, the
+// time of the excluded entries is subtracted to keep its own share only.
+// Must be called before processing starts.
+func (p *Profiler) Wrap(name string, n Node, exclude ...string) *ProfiledNode {
+	e := p.entry(name)
+	for _, name := range exclude {
+		if ex := p.entry(name); !slices.Contains(e.exclude, ex) {
+			e.exclude = append(e.exclude, ex)
+		}
+	}
+
+	return &ProfiledNode{Node: n, entry: e}
+}
+
+// SetParallel marks the entries whose nodes are rendered on several
+// goroutines at once, by a ParallelRenderer. Their summed time exceeds the
+// wall time of the nodes including them, so it is not subtracted: the
+// including entries then keep the wall time of the parallel nodes in their share.
+func (p *Profiler) SetParallel(parallel bool, names ...string) {
+	for _, name := range names {
+		if e, ok := p.byName[name]; ok {
+			e.parallel.Store(parallel)
+		}
+	}
+}
+
+func (p *Profiler) entry(name string) *ProfileEntry {
+	if e, ok := p.byName[name]; ok {
+		return e
+	}
+
+	e := &ProfileEntry{ProfileLoad: ProfileLoad{Name: name}}
+	p.byName[name] = e
+	p.entries = append(p.entries, e)
+	return e
+}
+
+// Names of the entries in index order, total first
+func (p *Profiler) Names() []string {
+	names := []string{p.total.Name}
+	for _, e := range p.entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// Loads of the entries in index order, total first
+func (p *Profiler) Loads() []ProfileLoad {
+	loads := []ProfileLoad{p.total.ProfileLoad}
+	for _, e := range p.entries {
+		loads = append(loads, e.ProfileLoad)
+	}
+	return loads
+}
+
+// Load of the entry at index, 0 is the total, see Names. No allocation,
+// to be read on the audio thread between two blocks.
+func (p *Profiler) Load(index int) ProfileLoad {
+	if index == 0 {
+		return p.total.ProfileLoad
+	}
+	return p.entries[index-1].ProfileLoad
+}
+
+func (p *Profiler) Process(b *Block) {
+	start := time.Now()
+	p.root.Process(b)
+	total := time.Since(start)
+
+	p.total.update(float32(total)/p.budget, p.avgCoef, p.peakDecay)
+
+	for _, e := range p.entries {
+		e.block = e.elapsed.Swap(0)
+	}
+	for _, e := range p.entries {
+		self := e.block
+		for _, ex := range e.exclude {
+			if !ex.parallel.Load() {
+				self -= ex.block
+			}
+		}
+		e.update(max(0, float32(self)/p.budget), p.avgCoef, p.peakDecay)
+	}
+}
+
+func (p *Profiler) Reset(soft bool) {
+	p.root.Reset(soft)
+}
+
+// ProfiledNode adds the processing time of its node to a profiler entry,
+// safe with parallel rendering
+type ProfiledNode struct {
+	Node
+	entry *ProfileEntry
+}
+
+func (n *ProfiledNode) Process(b *Block) {
+	start := time.Now()
+	n.Node.Process(b)
+	n.entry.elapsed.Add(int64(time.Since(start)))
+}
+
+func (n *ProfiledNode) IsSilent() bool {
+	return IsSilent(n.Node)
+}
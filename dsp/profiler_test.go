@@ -0,0 +1,127 @@
+package dsp
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// spin busy waits d then processes its source, if any
+type spin struct {
+	d   time.Duration
+	src Node
+}
+
+func (s *spin) Process(b *Block) {
+	if s.src != nil {
+		s.src.Process(b)
+	}
+	for start := time.Now(); time.Since(start) < s.d; {
+	}
+}
+
+func (s *spin) Reset(soft bool) {}
+
+func TestProfiler_SelfTime(t *testing.T) {
+	const sr = 44100.0
+	budget := time.Second * BlockSize / sr
+
+	inner := &spin{d: budget / 8}
+	outer := &spin{d: budget / 4}
+	pr := NewProfiler(sr, outer)
+	outer.src = pr.Wrap("inner", inner)
+	pr.root = pr.Wrap("outer", outer, "inner")
+
+	var b Block
+	pr.Process(&b)
+
+	loads := pr.Loads()
+	if names := pr.Names(); !slices.Equal(names, []string{"Total", "inner", "outer"}) {
+		t.Fatalf("unexpected entries %v", names)
+	}
+
+	total, in, out := loads[0].Last, loads[1].Last, loads[2].Last
+	if in < .125 {
+		t.Errorf("inner load %.3f, expected at least .125", in)
+	}
+	if out < .25 {
+		t.Errorf("outer load %.3f, expected at least .25", out)
+	}
+	if total < in+out {
+		t.Errorf("total load %.3f below the sum of its parts %.3f", total, in+out)
+	}
+}
+
+func TestProfiler_SharedEntries(t *testing.T) {
+	pr := NewProfiler(44100, NewMixer(NewParam(1), false))
+	for range 4 {
+		pr.Wrap("voice", &spin{}, "osc")
+		pr.Wrap("osc", &spin{})
+	}
+
+	if names := pr.Names(); !slices.Equal(names, []string{"Total", "voice", "osc"}) {
+		t.Fatalf("unexpected entries %v", names)
+	}
+	if n := len(pr.byName["voice"].exclude); n != 1 {
+		t.Fatalf("expected 1 exclusion, got %d", n)
+	}
+}
+
+// fanOut spins d then renders its jobs in parallel
+type fanOut struct {
+	spin
+	renderer *ParallelRenderer
+	jobs     []Node
+	blocks   []*Block
+}
+
+func (f *fanOut) Process(b *Block) {
+	f.spin.Process(b)
+	f.renderer.Render(f.jobs, f.blocks)
+}
+
+func TestProfiler_Parallel(t *testing.T) {
+	const sr = 44100.0
+	budget := time.Second * BlockSize / sr
+
+	renderer := NewParallelRenderer(3)
+	defer renderer.Close()
+
+	outer := &fanOut{spin: spin{d: budget / 8}, renderer: renderer}
+	pr := NewProfiler(sr, outer)
+	for range 8 {
+		outer.jobs = append(outer.jobs, pr.Wrap("inner", &spin{d: budget / 8}))
+		outer.blocks = append(outer.blocks, &Block{})
+	}
+	pr.root = pr.Wrap("outer", outer, "inner")
+	pr.SetParallel(true, "inner")
+
+	// The inner sum (a whole budget) exceeds the wall time of outer once the
+	// workers are up, outer would drop to 0 if it was subtracted
+	var b Block
+	for range 20 {
+		pr.Process(&b)
+
+		loads := pr.Loads()
+		if inner := loads[1].Last; inner < .9 {
+			t.Fatalf("expected the summed inner load near 1, got %f", inner)
+		}
+		if self := loads[2].Last; self < .9/8 {
+			t.Fatalf("expected the outer load to keep at least its own time, got %f", self)
+		}
+	}
+}
+
+func TestProfiler_ProcessNoAlloc(t *testing.T) {
+	pr := NewProfiler(44100, nil)
+	pr.root = pr.Wrap("node", &spin{})
+
+	var b Block
+	allocs := testing.AllocsPerRun(100, func() {
+		pr.Process(&b)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs, got %.1f", allocs)
+	}
+}
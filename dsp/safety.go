@@ -0,0 +1,189 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+const (
+	safetyLookahead = 0.0015 // seconds
+	safetyRelease   = 0.05   // seconds
+	safetyMinNormal = 0x1p-126
+)
+
+// Safety last stage of the master output. Non finite samples silence the
+// block and hard reset the source chain, denormals are flushed to zero and
+// a brickwall lookahead limiter keeps the output under Ceiling (dBFS).
+// Adds a latency of the lookahead.
+type Safety struct {
+	Src     Node
+	Ceiling Param
+
+	// Input optional, measures the blocks reaching the safety: samples above
+	// 0 dBFS and non finite samples are counted before being limited or silenced
+	Input *Levels
+
+	lookahead int
+	relCoef   float32
+
+	// Delay line, stereo
+	delayL, delayR []float32
+	pos            int
+
+	// Sliding minimum of the required gain over the lookahead
+	minVal  []float32
+	minPos  []int
+	minMask int
+	head    int
+	tail    int
+	n       int
+
+	// Release then moving average over the lookahead
+	rel    float32
+	avg    []float32
+	avgSum float64
+
+	resets    atomic.Uint64
+	reduction atomic.Uint32 // float bits, min gain of the last block
+}
+
+func NewSafety(sr float64, src Node, ceiling Param) *Safety {
+	la := max(1, int(sr*safetyLookahead))
+	size := 1
+	for size < la+1 {
+		size <<= 1
+	}
+
+	s := &Safety{
+		Src:       src,
+		Ceiling:   ceiling,
+		lookahead: la,
+		relCoef:   float32(1 - math.Exp(-1/(safetyRelease*sr))),
+		delayL:    make([]float32, la),
+		delayR:    make([]float32, la),
+		minVal:    make([]float32, size),
+		minPos:    make([]int, size),
+		minMask:   size - 1,
+		avg:       make([]float32, la),
+	}
+	s.flush()
+
+	return s
+}
+
+// Resets number of blocks silenced because of non finite samples
+func (s *Safety) Resets() uint64 {
+	return s.resets.Load()
+}
+
+// Reduction limiter gain of the last block, 1 when not limiting
+func (s *Safety) Reduction() float32 {
+	return math.Float32frombits(s.reduction.Load())
+}
+
+func (s *Safety) Process(b *Block) {
+	s.Src.Process(b)
+	if s.Input != nil {
+		s.Input.Measure(b)
+	}
+
+	if !finite(b.L[:]) || !finite(b.R[:]) {
+		clear(b.L[:])
+		clear(b.R[:])
+		s.Src.Reset(false)
+		s.flush()
+
+		s.resets.Add(1)
+		return
+	}
+
+	ceiling := float32(math.Pow(10, float64(s.Ceiling.Resolve(b.Cycle)[0])/20))
+	minGain := float32(1)
+
+	for i := 0; i < BlockSize; i++ {
+		l, r := b.L[i], b.R[i]
+
+		// Required gain, sliding minimum over the lookahead
+		need := float32(1)
+		if peak := max(l, -l, r, -r); peak > ceiling {
+			need = ceiling / peak
+		}
+		hold := s.pushMin(need)
+
+		// Instant attack, smooth release, averaged over the lookahead so the
+		// gain reaches its target before the delayed peak comes out
+		s.rel += (hold - s.rel) * s.relCoef
+		s.rel = min(s.rel, hold)
+
+		s.avgSum += float64(s.rel - s.avg[s.pos])
+		s.avg[s.pos] = s.rel
+		gain := float32(s.avgSum / float64(s.lookahead))
+		minGain = min(minGain, gain)
+
+		// Delay
+		dl, dr := s.delayL[s.pos], s.delayR[s.pos]
+		s.delayL[s.pos], s.delayR[s.pos] = l, r
+		s.pos++
+		if s.pos == s.lookahead {
+			s.pos = 0
+		}
+
+		b.L[i] = flushDenormal(dl * gain)
+		b.R[i] = flushDenormal(dr * gain)
+		s.n++
+	}
+
+	s.reduction.Store(math.Float32bits(minGain))
+}
+
+// pushMin adds the gain of sample n and returns the minimum over the
+// lookahead plus one samples, every average window then covers the delayed sample
+func (s *Safety) pushMin(v float32) float32 {
+	for s.tail > s.head && s.minVal[(s.tail-1)&s.minMask] >= v {
+		s.tail--
+	}
+	s.minVal[s.tail&s.minMask] = v
+	s.minPos[s.tail&s.minMask] = s.n
+	s.tail++
+
+	if s.minPos[s.head&s.minMask] < s.n-s.lookahead {
+		s.head++
+	}
+
+	return s.minVal[s.head&s.minMask]
+}
+
+// flush clears the delay line and the limiter state
+func (s *Safety) flush() {
+	clear(s.delayL)
+	clear(s.delayR)
+	for i := range s.avg {
+		s.avg[i] = 1
+	}
+	s.avgSum = float64(s.lookahead)
+	s.rel = 1
+	s.head, s.tail, s.n, s.pos = 0, 0, 0, 0
+}
+
+func (s *Safety) Reset(soft bool) {
+	if !soft {
+		s.flush()
+	}
+	s.Src.Reset(soft)
+}
+
+func finite(buf []float32) bool {
+	for _, x := range buf {
+		if x != x || x > math.MaxFloat32 || x < -math.MaxFloat32 {
+			return false
+		}
+	}
+	return true
+}
+
+func flushDenormal(x float32) float32 {
+	if x < safetyMinNormal && x > -safetyMinNormal {
+		return 0
+	}
+	return x
+}
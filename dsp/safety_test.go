@@ -0,0 +1,214 @@
+package dsp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// blockSource plays a function of the absolute sample index, records resets
+type blockSource struct {
+	fn     func(n int) float32
+	n      int
+	resets int
+}
+
+func (s *blockSource) Process(b *Block) {
+	for i := range b.L {
+		b.L[i] = s.fn(s.n)
+		b.R[i] = -b.L[i] / 2
+		s.n++
+	}
+}
+
+func (s *blockSource) Reset(soft bool) {
+	if !soft {
+		s.resets++
+	}
+}
+
+func TestSafety_LimiterCeiling(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	src := &blockSource{fn: func(n int) float32 {
+		// Bursts up to +12 dB over a quiet floor
+		if n/1000%2 == 0 {
+			return (rng.Float32()*2 - 1) * 4
+		}
+		return (rng.Float32()*2 - 1) * .1
+	}}
+
+	s := NewSafety(44100, src, NewParam(-1))
+	ceiling := float32(math.Pow(10, -1.0/20))
+
+	var b Block
+	limited := false
+	for range 200 {
+		b.Cycle++
+		s.Process(&b)
+		for i := range b.L {
+			if a := max(b.L[i], -b.L[i], b.R[i], -b.R[i]); a > ceiling*(1+1e-5) {
+				t.Fatalf("sample above the ceiling: %f > %f", a, ceiling)
+			}
+		}
+		limited = limited || s.Reduction() < 1
+	}
+
+	if !limited {
+		t.Fatal("expected the limiter to engage")
+	}
+}
+
+func TestSafety_TransparentUnderCeiling(t *testing.T) {
+	src := &blockSource{fn: func(n int) float32 { return float32(.5 * math.Sin(float64(n)*.05)) }}
+	s := NewSafety(44100, src, NewParam(-.3))
+	la := s.lookahead
+
+	var b Block
+	for c := range 10 {
+		b.Cycle++
+		s.Process(&b)
+		for i := range b.L {
+			n := c*BlockSize + i - la
+			exp := float32(0)
+			if n >= 0 {
+				exp = float32(.5 * math.Sin(float64(n)*.05))
+			}
+			if math.Abs(float64(b.L[i]-exp)) > 1e-6 {
+				t.Fatalf("sample %d: expected %f delayed by %d, got %f", c*BlockSize+i, exp, la, b.L[i])
+			}
+		}
+	}
+	if s.Reduction() != 1 {
+		t.Fatalf("expected no reduction, got %f", s.Reduction())
+	}
+}
+
+func TestSafety_NaNReset(t *testing.T) {
+	src := &blockSource{fn: func(n int) float32 {
+		if n == 300 {
+			return float32(math.NaN())
+		}
+		return .1
+	}}
+	s := NewSafety(44100, src, NewParam(0))
+
+	var b Block
+	for range 2 { // NaN at 300
+		b.Cycle++
+		s.Process(&b)
+	}
+
+	for i := range b.L {
+		if b.L[i] != 0 || b.R[i] != 0 {
+			t.Fatalf("expected a silent block, got %f at %d", b.L[i], i)
+		}
+	}
+	if s.Resets() != 1 || src.resets != 1 {
+		t.Fatalf("expected 1 reset, got %d, source %d", s.Resets(), src.resets)
+	}
+
+	b.Cycle++
+	s.Process(&b)
+	if b.L[BlockSize-1] != .1 {
+		t.Fatalf("expected the signal back, got %f", b.L[BlockSize-1])
+	}
+}
+
+func TestSafety_NaNResetFeedbackDelay(t *testing.T) {
+	src := &blockSource{fn: func(n int) float32 {
+		if n == 300 {
+			return float32(math.NaN())
+		}
+		return .1
+	}}
+	delay := NewFeedbackDelay(44100, 1, src, NewConstParam(.01), NewConstParam(.9), NewConstParam(.5), nil)
+	s := NewSafety(44100, delay, NewParam(0))
+
+	var b Block
+	for range 200 {
+		b.Cycle++
+		s.Process(&b)
+	}
+
+	// The NaN must not stay in the feedback loop
+	if s.Resets() != 1 {
+		t.Fatalf("expected 1 reset, got %d", s.Resets())
+	}
+	if b.L[BlockSize-1] == 0 {
+		t.Fatal("expected the signal back")
+	}
+}
+
+func TestSafety_InputCounters(t *testing.T) {
+	src := &blockSource{fn: func(n int) float32 {
+		switch n {
+		case 10:
+			return 2
+		case 300:
+			return float32(math.NaN())
+		}
+		return .1
+	}}
+	s := NewSafety(44100, src, NewParam(0))
+	s.Input = NewLevels(44100)
+	output := NewLevels(44100)
+
+	var b Block
+	for range 4 {
+		b.Cycle++
+		s.Process(&b)
+		output.Measure(&b)
+	}
+
+	// Clipped and silenced, only the input sees them
+	if output.Clips() != 0 || output.NaNs() != 0 {
+		t.Fatalf("expected a clean output, got %d clips, %d nan", output.Clips(), output.NaNs())
+	}
+	if s.Input.Clips() != 1 || s.Input.NaNs() != 2 {
+		t.Fatalf("expected 1 clip and 2 nan on the input (L and R), got %d, %d", s.Input.Clips(), s.Input.NaNs())
+	}
+}
+
+func TestSafety_FlushDenormals(t *testing.T) {
+	src := &blockSource{fn: func(n int) float32 { return 1e-39 }}
+	s := NewSafety(44100, src, NewParam(0))
+
+	var b Block
+	for range 2 {
+		b.Cycle++
+		s.Process(&b)
+	}
+
+	for i := range b.L {
+		if b.L[i] != 0 {
+			t.Fatalf("expected denormal flushed, got %g", b.L[i])
+		}
+	}
+}
+
+func TestSafety_ProcessNoAlloc(t *testing.T) {
+	src := &blockSource{fn: func(n int) float32 { return float32(n%100) / 25 }}
+	s := NewSafety(44100, src, NewParam(-.3))
+
+	var b Block
+	allocs := testing.AllocsPerRun(100, func() {
+		b.Cycle++
+		s.Process(&b)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs, got %.1f", allocs)
+	}
+}
+
+func BenchmarkSafety_Process(b *testing.B) {
+	src := &blockSource{fn: func(n int) float32 { return float32(n%100) / 25 }}
+	s := NewSafety(44100, src, NewParam(-.3))
+
+	var block Block
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		block.Cycle++
+		s.Process(&block)
+	}
+}
@@ -0,0 +1,18 @@
+package dsp
+
+// SilenceThreshold absolute level under which a tail is considered silent (-100 dB)
+const SilenceThreshold = 1e-5
+
+// SilenceReporter is implemented by nodes able to tell that they would
+// render silence until their next event (note on, new input...), so that
+// callers can skip processing them.
+type SilenceReporter interface {
+	IsSilent() bool
+}
+
+// IsSilent reports whether src is known to be silent, sources that can't
+// tell are never silent
+func IsSilent(src Source) bool {
+	s, ok := src.(SilenceReporter)
+	return ok && s.IsSilent()
+}
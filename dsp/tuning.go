@@ -0,0 +1,38 @@
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// KeyTable frequency of each MIDI key in Hz, 0 for keys that do not sound
+type KeyTable [128]float32
+
+var tuning atomic.Pointer[KeyTable]
+
+func init() {
+	tuning.Store(EqualTable(440))
+}
+
+// EqualTable 12-TET keys with A4 (69) at a4 Hz
+func EqualTable(a4 float64) *KeyTable {
+	t := &KeyTable{}
+	for k := range t {
+		t[k] = float32(a4 * math.Exp2(float64(k-69)/12))
+	}
+	return t
+}
+
+// SetTuning replaces the key frequencies played by the voices. Build the
+// table off the audio thread, it must not change once set.
+func SetTuning(t *KeyTable) {
+	tuning.Store(t)
+}
+
+// KeyFreq frequency of the key in the current tuning, 0 if it does not sound
+func KeyFreq(key int) float32 {
+	if key < 0 || key > 127 {
+		return 0
+	}
+	return tuning.Load()[key]
+}
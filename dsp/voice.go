@@ -5,8 +5,8 @@ type Resettable interface {
 }
 
 type Envelope interface {
-	NoteOn()
-	NoteOff()
+	NoteOnAt(offset int)
+	NoteOffAt(offset int)
 	IsIdle() bool
 	ParamModulator
 }
@@ -16,6 +16,8 @@ type Voice struct {
 	freq   Param
 	envs   []Envelope
 	resets []Resettable
+
+	softReset bool // retriggered at an offset, reset after the block
 }
 
 func NewVoice(src Node, freq Param, extra ...any) *Voice {
@@ -41,23 +43,59 @@ func NewVoice(src Node, freq Param, extra ...any) *Voice {
 }
 
 func (v *Voice) NoteOn(key int, vel float32) {
+	v.NoteOnAt(key, vel, 0)
+}
+
+// NoteOnAt starts the note at the given sample offset of the next block,
+// keys unmapped by the tuning do not sound, see KeyFreq
+func (v *Voice) NoteOnAt(key int, vel float32, offset int) {
+	freq := KeyFreq(key)
+	if freq <= 0 {
+		return
+	}
+
 	// v.gain.SetBase(vel) todo handle vel, probably with a param modulator
-	v.freq.SetBase(MidiKeys[key])
-	soft := !v.envs[0].IsIdle()
-	v.Node.Reset(soft)
+	SetBaseAt(v.freq, freq, offset)
 
-	for _, reset := range v.resets {
-		reset.Reset(soft)
+	// Nodes reset on block boundaries only. An idle voice is silent until
+	// offset and resets right away, a playing one still renders its former
+	// note before offset and resets once the block is done.
+	if soft := !v.envs[0].IsIdle(); soft && offset > 0 {
+		v.softReset = true
+	} else {
+		v.reset(soft)
 	}
 
 	for _, env := range v.envs {
-		env.NoteOn()
+		env.NoteOnAt(offset)
+	}
+}
+
+func (v *Voice) Process(b *Block) {
+	v.Node.Process(b)
+
+	if v.softReset {
+		v.reset(true)
+	}
+}
+
+func (v *Voice) reset(soft bool) {
+	v.softReset = false
+	v.Node.Reset(soft)
+
+	for _, reset := range v.resets {
+		reset.Reset(soft)
 	}
 }
 
 func (v *Voice) NoteOff() {
+	v.NoteOffAt(0)
+}
+
+// NoteOffAt releases the note at the given sample offset of the next block
+func (v *Voice) NoteOffAt(offset int) {
 	for _, env := range v.envs {
-		env.NoteOff()
+		env.NoteOffAt(offset)
 	}
 }
 
@@ -0,0 +1,48 @@
+package dsp
+
+import "testing"
+
+// stateful outputs the number of blocks since its last reset
+type stateful struct{ blocks float32 }
+
+func (s *stateful) Process(b *Block) {
+	for i := range b.L {
+		b.L[i], b.R[i] = s.blocks, s.blocks
+	}
+	s.blocks++
+}
+
+func (s *stateful) Reset(bool) { s.blocks = 0 }
+
+func TestVoice_RetriggerAtOffset(t *testing.T) {
+	const sr = 44100.0
+	src := &stateful{}
+	env := NewADSR(sr, NewConstParam(.01), NewConstParam(.05), NewConstParam(.8), NewConstParam(.1))
+	v := NewVoice(src, NewParam(440), env)
+
+	var b Block
+	v.NoteOn(69, 1)
+	for range 4 {
+		b.Cycle++
+		env.Resolve(b.Cycle)
+		v.Process(&b)
+	}
+
+	// The former note plays until the offset
+	const offset = 100
+	v.NoteOnAt(69, 1, offset)
+	b.Cycle++
+	env.Resolve(b.Cycle)
+	v.Process(&b)
+	for i := range offset {
+		if b.L[i] != 4 {
+			t.Fatalf("expected the voice state kept before the offset, got %f at %d", b.L[i], i)
+		}
+	}
+
+	b.Cycle++
+	v.Process(&b)
+	if b.L[0] != 0 {
+		t.Fatalf("expected the voice reset after the block, got %f", b.L[0])
+	}
+}
@@ -0,0 +1,107 @@
+// Package fft radix-2 fast Fourier transform on float32 blocks, no allocation
+// once created. Meant for analysis (spectrum, pitch), not for convolution.
+package fft
+
+import (
+	"math"
+	"math/bits"
+)
+
+type FFT struct {
+	n        int
+	cos, sin []float32 // twiddles, n/2
+	rev      []int     // bit reversed indexes
+
+	// Scratch for Spectrum
+	re, im []float32
+}
+
+// New expects a power of two size n, rounded up otherwise
+func New(n int) *FFT {
+	size := 2
+	for size < n {
+		size <<= 1
+	}
+
+	f := &FFT{
+		n:   size,
+		cos: make([]float32, size/2),
+		sin: make([]float32, size/2),
+		rev: make([]int, size),
+		re:  make([]float32, size),
+		im:  make([]float32, size),
+	}
+
+	for i := range f.cos {
+		a := -2 * math.Pi * float64(i) / float64(size)
+		f.cos[i] = float32(math.Cos(a))
+		f.sin[i] = float32(math.Sin(a))
+	}
+
+	shift := bits.UintSize - bits.Len(uint(size-1))
+	for i := range f.rev {
+		f.rev[i] = int(bits.Reverse(uint(i)) >> shift)
+	}
+
+	return f
+}
+
+// Size number of points of the transform
+func (f *FFT) Size() int {
+	return f.n
+}
+
+// Transform forward in place, re and im must have Size elements
+func (f *FFT) Transform(re, im []float32) {
+	re, im = re[:f.n], im[:f.n]
+
+	for i, j := range f.rev {
+		if i < j {
+			re[i], re[j] = re[j], re[i]
+			im[i], im[j] = im[j], im[i]
+		}
+	}
+
+	for size := 2; size <= f.n; size <<= 1 {
+		half := size / 2
+		step := f.n / size
+		for start := 0; start < f.n; start += size {
+			for k := 0; k < half; k++ {
+				wr, wi := f.cos[k*step], f.sin[k*step]
+				a, b := start+k, start+k+half
+
+				tr := re[b]*wr - im[b]*wi
+				ti := re[b]*wi + im[b]*wr
+				re[b], im[b] = re[a]-tr, im[a]-ti
+				re[a], im[a] = re[a]+tr, im[a]+ti
+			}
+		}
+	}
+}
+
+// Spectrum magnitudes of the real signal x weighted by window (see
+// NewWindow, nil for none), into out, Size/2+1 bins from DC to Nyquist.
+// Magnitudes are normalized, a full scale sine at a bin frequency is 1.
+func (f *FFT) Spectrum(x, window, out []float32) {
+	gain := float32(f.n)
+	if window != nil {
+		gain = 0
+		for i := range f.n {
+			f.re[i] = x[i] * window[i]
+			gain += window[i]
+		}
+	} else {
+		copy(f.re, x[:f.n])
+	}
+	clear(f.im)
+
+	f.Transform(f.re, f.im)
+
+	scale := 2 / gain
+	for k := range f.n/2 + 1 {
+		m := float32(math.Sqrt(float64(f.re[k]*f.re[k] + f.im[k]*f.im[k])))
+		out[k] = m * scale
+	}
+	out[0] /= 2
+	out[f.n/2] /= 2
+}
@@ -0,0 +1,99 @@
+package fft
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func dft(re, im []float64) ([]float64, []float64) {
+	n := len(re)
+	outR, outI := make([]float64, n), make([]float64, n)
+	for k := range n {
+		for t := range n {
+			a := -2 * math.Pi * float64(k*t) / float64(n)
+			outR[k] += re[t]*math.Cos(a) - im[t]*math.Sin(a)
+			outI[k] += re[t]*math.Sin(a) + im[t]*math.Cos(a)
+		}
+	}
+	return outR, outI
+}
+
+func TestFFT_MatchesDFT(t *testing.T) {
+	for _, n := range []int{2, 8, 64, 512} {
+		f := New(n)
+		rng := rand.New(rand.NewSource(int64(n)))
+
+		re, im := make([]float32, n), make([]float32, n)
+		re64, im64 := make([]float64, n), make([]float64, n)
+		for i := range n {
+			re[i], im[i] = rng.Float32()*2-1, rng.Float32()*2-1
+			re64[i], im64[i] = float64(re[i]), float64(im[i])
+		}
+
+		f.Transform(re, im)
+		expR, expI := dft(re64, im64)
+
+		for k := range n {
+			if math.Abs(float64(re[k])-expR[k]) > 1e-3*float64(n) ||
+				math.Abs(float64(im[k])-expI[k]) > 1e-3*float64(n) {
+				t.Fatalf("n=%d bin %d: expected %f%+fi, got %f%+fi", n, k, expR[k], expI[k], re[k], im[k])
+			}
+		}
+	}
+}
+
+func TestFFT_SpectrumSine(t *testing.T) {
+	const n, bin = 1024, 40
+	f := New(n)
+
+	x := make([]float32, n)
+	for i := range x {
+		x[i] = float32(0.5 * math.Sin(2*math.Pi*bin*float64(i)/n))
+	}
+
+	for _, w := range []Window{WindowRect, WindowHann, WindowHamming, WindowBlackman} {
+		out := make([]float32, n/2+1)
+		f.Spectrum(x, NewWindow(w, n), out)
+
+		if math.Abs(float64(out[bin])-.5) > 1e-2 {
+			t.Errorf("window %d: expected .5 at bin %d, got %f", w, bin, out[bin])
+		}
+		if out[bin+10] > 1e-3 {
+			t.Errorf("window %d: expected no leakage at bin %d, got %f", w, bin+10, out[bin+10])
+		}
+	}
+}
+
+func TestFFT_SizeRoundedUp(t *testing.T) {
+	if s := New(1000).Size(); s != 1024 {
+		t.Fatalf("expected 1024, got %d", s)
+	}
+}
+
+func TestFFT_SpectrumNoAlloc(t *testing.T) {
+	f := New(4096)
+	x := make([]float32, 4096)
+	w := NewWindow(WindowHann, 4096)
+	out := make([]float32, 2049)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		f.Spectrum(x, w, out)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs, got %.1f", allocs)
+	}
+}
+
+func BenchmarkFFT_Spectrum4096(b *testing.B) {
+	f := New(4096)
+	x := make([]float32, 4096)
+	w := NewWindow(WindowHann, 4096)
+	out := make([]float32, 2049)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Spectrum(x, w, out)
+	}
+}
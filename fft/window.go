@@ -0,0 +1,31 @@
+package fft
+
+import "math"
+
+type Window int
+
+const (
+	WindowRect Window = iota
+	WindowHann
+	WindowHamming
+	WindowBlackman
+)
+
+// NewWindow coefficients of w over n samples
+func NewWindow(w Window, n int) []float32 {
+	coefs := make([]float32, n)
+	for i := range coefs {
+		x := 2 * math.Pi * float64(i) / float64(n-1)
+		switch w {
+		case WindowHann:
+			coefs[i] = float32(0.5 - 0.5*math.Cos(x))
+		case WindowHamming:
+			coefs[i] = float32(0.54 - 0.46*math.Cos(x))
+		case WindowBlackman:
+			coefs[i] = float32(0.42 - 0.5*math.Cos(x) + 0.08*math.Cos(2*x))
+		default:
+			coefs[i] = 1
+		}
+	}
+	return coefs
+}
@@ -0,0 +1,45 @@
+package harmony
+
+// Scale pitch classes of a scale, bit i = note i semitones above the root
+type Scale struct {
+	Name  string
+	Notes uint16
+}
+
+// Scales selectable with preset.HarmonyScale, in order
+var Scales = []Scale{
+	{"Major", notes(0, 2, 4, 5, 7, 9, 11)},
+	{"Minor", notes(0, 2, 3, 5, 7, 8, 10)},
+	{"Harmonic minor", notes(0, 2, 3, 5, 7, 8, 11)},
+	{"Dorian", notes(0, 2, 3, 5, 7, 9, 10)},
+	{"Mixolydian", notes(0, 2, 4, 5, 7, 9, 10)},
+	{"Major pentatonic", notes(0, 2, 4, 7, 9)},
+	{"Minor pentatonic", notes(0, 3, 5, 7, 10)},
+	{"Blues", notes(0, 3, 5, 6, 7, 10)},
+}
+
+func notes(intervals ...int) uint16 {
+	var n uint16
+	for _, i := range intervals {
+		n |= 1 << i
+	}
+	return n
+}
+
+// Snap returns the note of the scale nearest to key, the lower one on ties.
+// root: pitch class of the scale root, 0 = C. Keys stay in 0..127.
+func (s Scale) Snap(key, root int) int {
+	for d := 0; d < 12; d++ {
+		if k := key - d; k >= 0 && s.has(k-root) {
+			return k
+		}
+		if k := key + d; k <= 127 && s.has(k-root) {
+			return k
+		}
+	}
+	return key
+}
+
+func (s Scale) has(interval int) bool {
+	return s.Notes&(1<<((interval%12+12)%12)) != 0
+}
@@ -0,0 +1,37 @@
+package harmony
+
+import (
+	"synth/preset"
+	"testing"
+)
+
+func TestScale_Snap(t *testing.T) {
+	major := Scales[0]
+	for _, test := range []struct {
+		key, root, exp int
+	}{
+		{60, 0, 60},   // C in C major
+		{61, 0, 60},   // C# between C and D, the lower one
+		{66, 0, 65},   // F# between F and G
+		{61, 2, 61},   // C# in D major
+		{60, 2, 59},   // C between B and C# in D major
+		{0, 2, 1},     // C in D major, no lower note
+		{127, 0, 127}, // G
+	} {
+		if k := major.Snap(test.key, test.root); k != test.exp {
+			t.Errorf("key %d root %d: expected %d, got %d", test.key, test.root, test.exp, k)
+		}
+	}
+
+	blues := Scales[len(Scales)-1]
+	if k := blues.Snap(64, 0); k != 63 { // E between Eb and F
+		t.Errorf("expected Eb, got %d", k)
+	}
+}
+
+func TestScales_Param(t *testing.T) {
+	m := preset.GetParamMeta(preset.HarmonyScale)
+	if n := int(m.Max) + 1; n != len(Scales) {
+		t.Fatalf("expected the scale parameter to cover %d scales, got %d", len(Scales), n)
+	}
+}
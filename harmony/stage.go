@@ -0,0 +1,245 @@
+package harmony
+
+import (
+	"synth/midi"
+	"synth/msg"
+	"synth/preset"
+)
+
+const (
+	MaxChordNotes = 8  // notes played by a key, the lowest ones of the shape
+	maxStrummed   = 64 // pending strummed notes, the next ones play right away
+)
+
+// Preset the current preset: reads the harmony parameters and applies the
+// learned chord shapes, see preset.Manager
+type Preset interface {
+	msg.Handler
+	Param(id uint8) float32
+}
+
+// Stage transforms the played notes before the midi.Player, following the
+// preset.Harmony* parameters of the current preset:
+//   - scale lock snaps the notes to the scale
+//   - chord memory plays the chord shape from each key, learned from the keys
+//     held in preset.ChordLearn mode
+//   - strum spreads the note ons of a chord over time
+//
+// Other messages go through untouched.
+type Stage struct {
+	next      msg.Handler
+	preset    Preset
+	messenger *msg.Messenger
+	sr        float64
+
+	// notes played by each held key and held keys playing each note, per
+	// channel. Note offs release what the note on played, whatever the
+	// parameters became since.
+	played  [16][128]chord
+	playing [16][128]uint8
+
+	strums []strummed // note ons waiting for their frame
+
+	learned [128]bool // keys pressed since the learning started
+	down    [128]bool // learned keys still held
+	held    int
+}
+
+type chord struct {
+	keys [MaxChordNotes]uint8
+	n    int
+}
+
+type strummed struct {
+	m    msg.Message
+	from uint8 // played key
+}
+
+// NewStage next: the player, messenger: echoes the learned parameters and
+// provides the clock
+func NewStage(sr float64, next msg.Handler, p Preset, messenger *msg.Messenger) *Stage {
+	return &Stage{
+		next:      next,
+		preset:    p,
+		messenger: messenger,
+		sr:        sr,
+		strums:    make([]strummed, 0, maxStrummed),
+	}
+}
+
+func (s *Stage) HandleMessage(m msg.Message) {
+	switch m.Kind {
+	case midi.NoteOnKind:
+		s.noteOn(m)
+	case midi.NoteOffKind:
+		s.noteOff(m)
+	default:
+		s.next.HandleMessage(m)
+	}
+}
+
+// Process plays the strummed notes due in the current block, call it once
+// per block after the messenger
+func (s *Stage) Process() {
+	clock := s.messenger.Clock()
+	n := 0
+	for _, st := range s.strums {
+		if clock.Due(st.m.Frame) {
+			s.play(st.m)
+			continue
+		}
+		s.strums[n] = st
+		n++
+	}
+	s.strums = s.strums[:n]
+}
+
+func (s *Stage) noteOn(m msg.Message) {
+	ch, key := m.Chan&15, m.Key&127
+	s.release(ch, key, m) // retriggered without note off
+
+	mode := int(s.preset.Param(preset.HarmonyChord))
+	shape := uint32(1)
+	switch mode {
+	case preset.ChordOn:
+		shape = uint32(s.preset.Param(preset.HarmonyChordShape))
+	case preset.ChordLearn:
+		if !s.down[key] { // retriggered without note off
+			s.held++
+		}
+		s.learned[key], s.down[key] = true, true
+	}
+
+	// Shape from the locked key, each note locked again: the chord follows the scale
+	c := &s.played[ch][key]
+	root := s.lock(int(key))
+	for i := 0; i < 24 && c.n < MaxChordNotes && root+i <= 127; i++ {
+		if shape&(1<<i) != 0 {
+			if k := uint8(s.lock(root + i)); !c.has(k) {
+				c.keys[c.n] = k
+				c.n++
+			}
+		}
+	}
+
+	step := uint64(float64(s.preset.Param(preset.HarmonyStrum)) * s.sr)
+	down := s.preset.Param(preset.HarmonyStrumDir) != 0
+	start := max(m.Frame, s.messenger.Clock().Start())
+	for i := 0; i < c.n; i++ {
+		note := m
+		note.Key = c.keys[i]
+		if down {
+			note.Key = c.keys[c.n-1-i]
+		}
+
+		if i == 0 || step == 0 || len(s.strums) == cap(s.strums) {
+			s.play(note)
+			continue
+		}
+		note.Frame = start + uint64(i)*step
+		s.strums = append(s.strums, strummed{note, key})
+	}
+}
+
+func (s *Stage) noteOff(m msg.Message) {
+	ch, key := m.Chan&15, m.Key&127
+	s.release(ch, key, m)
+
+	if s.down[key] {
+		s.down[key] = false
+		if s.held--; s.held == 0 {
+			s.learn()
+		}
+	}
+}
+
+func (s *Stage) play(m msg.Message) {
+	s.playing[m.Chan&15][m.Key]++
+	s.next.HandleMessage(m)
+}
+
+// release sends the note offs of the notes played by key, m: note message to
+// copy the channel and frame from
+func (s *Stage) release(ch, key uint8, m msg.Message) {
+	c := &s.played[ch][key]
+	for _, k := range c.keys[:c.n] {
+		if s.unstrum(ch, key, k) {
+			continue
+		}
+
+		if n := &s.playing[ch][k]; *n > 0 {
+			if *n--; *n == 0 {
+				off := m
+				off.Kind = midi.NoteOffKind
+				off.Key = k
+				s.next.HandleMessage(off)
+			}
+		}
+	}
+	c.n = 0
+}
+
+// unstrum drops the pending note on of key played by from, reports whether found
+func (s *Stage) unstrum(ch, from, key uint8) bool {
+	for i, st := range s.strums {
+		if st.from == from && st.m.Key == key && st.m.Chan&15 == ch {
+			s.strums = append(s.strums[:i], s.strums[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Stage) lock(key int) int {
+	if s.preset.Param(preset.HarmonyScaleLock) == 0 {
+		return key
+	}
+
+	scale := int(s.preset.Param(preset.HarmonyScale))
+	if scale < 0 || scale >= len(Scales) {
+		return key
+	}
+	return Scales[scale].Snap(key, int(s.preset.Param(preset.HarmonyRoot)))
+}
+
+// learn stores the keys pressed while learning as the chord shape, from the
+// lowest one, once all are released
+func (s *Stage) learn() {
+	var shape uint32
+	low, n := -1, 0
+	for k, l := range s.learned {
+		if !l {
+			continue
+		}
+		s.learned[k] = false
+		if low < 0 {
+			low = k
+		}
+		if k-low < 24 && n < MaxChordNotes {
+			shape |= 1 << (k - low)
+			n++
+		}
+	}
+
+	if shape == 0 || s.preset.Param(preset.HarmonyChord) != preset.ChordLearn {
+		return
+	}
+	s.set(preset.HarmonyChordShape, float32(shape))
+	s.set(preset.HarmonyChord, preset.ChordOn)
+}
+
+// set applies a parameter to the preset and echoes it, for the UI to follow
+func (s *Stage) set(id uint8, val float32) {
+	m := msg.Message{Kind: preset.UpdateParameterKind, Key: id, ValF: val}
+	s.preset.HandleMessage(m)
+	s.messenger.SendMessage(m)
+}
+
+func (c *chord) has(key uint8) bool {
+	for _, k := range c.keys[:c.n] {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
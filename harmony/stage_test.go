@@ -0,0 +1,217 @@
+package harmony
+
+import (
+	"slices"
+	"synth/midi"
+	"synth/msg"
+	"synth/preset"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+type fakePreset map[uint8]float32
+
+func (p fakePreset) Param(id uint8) float32 { return p[id] }
+
+func (p fakePreset) HandleMessage(m msg.Message) {
+	if m.Kind == preset.UpdateParameterKind {
+		p[m.Key] = m.ValF
+	}
+}
+
+type recorder struct {
+	msgs []msg.Message
+}
+
+func (r *recorder) HandleMessage(m msg.Message) {
+	r.msgs = append(r.msgs, m)
+}
+
+// notes keys of the recorded messages of kind, in order
+func (r *recorder) notes(kind msg.Kind) []uint8 {
+	var keys []uint8
+	for _, m := range r.msgs {
+		if m.Kind == kind {
+			keys = append(keys, m.Key)
+		}
+	}
+	return keys
+}
+
+const sr = 1000
+
+func newStage(p fakePreset) (*Stage, *recorder, *msg.Messenger) {
+	rec := &recorder{}
+	m := msg.NewMessenger(nil, msg.NewQueue(16), 0)
+	return NewStage(sr, rec, p, m), rec, m
+}
+
+func noteOn(s *Stage, key uint8) {
+	s.HandleMessage(msg.Message{Kind: midi.NoteOnKind, Key: key, Val8: 100})
+}
+
+func noteOff(s *Stage, key uint8) {
+	s.HandleMessage(msg.Message{Kind: midi.NoteOffKind, Key: key})
+}
+
+func TestStage_Passthrough(t *testing.T) {
+	s, rec, _ := newStage(fakePreset{})
+
+	noteOn(s, 61)
+	s.HandleMessage(msg.Message{Kind: midi.PitchBendKind, Val16: 100})
+	noteOff(s, 61)
+
+	if len(rec.msgs) != 3 || rec.msgs[0].Key != 61 || rec.msgs[0].Val8 != 100 || rec.msgs[2].Key != 61 {
+		t.Fatalf("expected the messages untouched, got %v", rec.msgs)
+	}
+}
+
+func TestStage_ScaleLock(t *testing.T) {
+	s, rec, _ := newStage(fakePreset{
+		preset.HarmonyScaleLock: 1,
+		preset.HarmonyRoot:      2, // D major
+	})
+
+	noteOn(s, 60) // C, locked to B
+	noteOn(s, 59) // B, shares the locked note
+	noteOff(s, 60)
+	if exp, got := []uint8{59, 59}, rec.notes(midi.NoteOnKind); !slices.Equal(got, exp) {
+		t.Fatalf("expected note ons %v, got %v", exp, got)
+	}
+	if got := rec.notes(midi.NoteOffKind); len(got) != 0 {
+		t.Fatalf("expected B held by the other key, got note offs %v", got)
+	}
+
+	noteOff(s, 59)
+	if exp, got := []uint8{59}, rec.notes(midi.NoteOffKind); !slices.Equal(got, exp) {
+		t.Fatalf("expected note offs %v, got %v", exp, got)
+	}
+}
+
+func TestStage_ChordMemory(t *testing.T) {
+	p := fakePreset{preset.HarmonyChord: preset.ChordLearn}
+	s, rec, _ := newStage(p)
+
+	// Learn a minor seventh, played as is
+	noteOn(s, 57)
+	noteOn(s, 60)
+	noteOn(s, 64)
+	noteOff(s, 57)
+	noteOn(s, 67)
+	noteOff(s, 60)
+	noteOff(s, 64)
+	if p[preset.HarmonyChord] != preset.ChordLearn {
+		t.Fatal("expected learning until all keys are released")
+	}
+	noteOff(s, 67)
+
+	if p[preset.HarmonyChord] != preset.ChordOn {
+		t.Fatalf("expected chord memory on after learning, got %g", p[preset.HarmonyChord])
+	}
+	if exp := float32(1 | 1<<3 | 1<<7 | 1<<10); p[preset.HarmonyChordShape] != exp {
+		t.Fatalf("expected shape %g, got %g", exp, p[preset.HarmonyChordShape])
+	}
+
+	// One key plays the shape, then the shape changes while held
+	rec.msgs = nil
+	noteOn(s, 62)
+	p[preset.HarmonyChordShape] = 1
+	noteOff(s, 62)
+	if exp, got := []uint8{62, 65, 69, 72}, rec.notes(midi.NoteOnKind); !slices.Equal(got, exp) {
+		t.Fatalf("expected note ons %v, got %v", exp, got)
+	}
+	if exp, got := []uint8{62, 65, 69, 72}, rec.notes(midi.NoteOffKind); !slices.Equal(got, exp) {
+		t.Fatalf("expected note offs %v, got %v", exp, got)
+	}
+}
+
+func TestStage_ChordFollowsScale(t *testing.T) {
+	s, rec, _ := newStage(fakePreset{
+		preset.HarmonyScaleLock:  1,
+		preset.HarmonyChord:      preset.ChordOn,
+		preset.HarmonyChordShape: 1 | 1<<4 | 1<<7, // major triad
+	})
+
+	noteOn(s, 62) // D minor in C major
+	if exp, got := []uint8{62, 65, 69}, rec.notes(midi.NoteOnKind); !slices.Equal(got, exp) {
+		t.Fatalf("expected note ons %v, got %v", exp, got)
+	}
+}
+
+func TestStage_Strum(t *testing.T) {
+	const blockSize = 100
+	p := fakePreset{
+		preset.HarmonyChord:      preset.ChordOn,
+		preset.HarmonyChordShape: 1 | 1<<4 | 1<<7,
+		preset.HarmonyStrum:      .1, // a block between notes
+		preset.HarmonyStrumDir:   1,
+	}
+	s, rec, m := newStage(p)
+	clock := msg.NewClock(sr, blockSize, 0)
+	m.SetClock(clock, 16)
+	clock.Tick()
+
+	noteOn(s, 60)
+	for i, exp := range [][]uint8{{67}, {67, 64}, {67, 64, 60}} {
+		s.Process()
+		if got := rec.notes(midi.NoteOnKind); !slices.Equal(got, exp) {
+			t.Fatalf("block %d: expected note ons %v, got %v", i, exp, got)
+		}
+		if last := rec.msgs[len(rec.msgs)-1]; clock.Offset(last.Frame) != 0 || last.Frame != uint64(i*blockSize) {
+			t.Fatalf("block %d: expected the note at frame %d, got %d", i, i*blockSize, last.Frame)
+		}
+		clock.Tick()
+	}
+
+	// Released before the strum ends, the pending notes never play
+	rec.msgs = nil
+	noteOn(s, 48)
+	noteOff(s, 48)
+	clock.Tick()
+	s.Process()
+	clock.Tick()
+	s.Process()
+	if exp, got := []uint8{55}, rec.notes(midi.NoteOnKind); !slices.Equal(got, exp) {
+		t.Fatalf("expected note ons %v, got %v", exp, got)
+	}
+	if exp, got := []uint8{55}, rec.notes(midi.NoteOffKind); !slices.Equal(got, exp) {
+		t.Fatalf("expected note offs %v, got %v", exp, got)
+	}
+}
+
+func TestStage_ChordMemoryManager(t *testing.T) {
+	manager := preset.NewManager(sr, zerolog.Nop(), msg.NewMessenger(nil, msg.NewQueue(1024), 0), "/dev/null")
+	s := NewStage(sr, &recorder{}, manager, msg.NewMessenger(nil, msg.NewQueue(16), 0))
+
+	// Switched to learning like from the tree, edits reach the stage
+	manager.HandleMessage(msg.Message{Kind: preset.UpdateParameterKind, Key: preset.HarmonyChord, ValF: preset.ChordLearn})
+	if got := manager.Param(preset.HarmonyChord); got != preset.ChordLearn {
+		t.Fatalf("expected the edited chord mode, got %g", got)
+	}
+
+	noteOn(s, 60)
+	noteOn(s, 67)
+	noteOff(s, 60)
+	noteOff(s, 67)
+	if got := manager.Param(preset.HarmonyChord); got != preset.ChordOn {
+		t.Fatalf("expected chord memory on after learning, got %g", got)
+	}
+	if got := manager.Param(preset.HarmonyChordShape); got != 1|1<<7 {
+		t.Fatalf("expected a fifth, got %g", got)
+	}
+}
+
+func TestStage_ChordLearnRetrigger(t *testing.T) {
+	p := fakePreset{preset.HarmonyChord: preset.ChordLearn}
+	s, _, _ := newStage(p)
+
+	noteOn(s, 60)
+	noteOn(s, 64)
+	noteOn(s, 60) // retriggered without note off
+	noteOff(s, 60)
+	noteOff(s, 64)
+	if p[preset.HarmonyChord] != preset.ChordOn || p[preset.HarmonyChordShape] != 1|1<<4 {
+		t.Fatalf("expected a major third learned, got mode %g shape %g", p[preset.HarmonyChord], p[preset.HarmonyChordShape])
+	}
+}
@@ -0,0 +1,131 @@
+package meter
+
+import (
+	"synth/dsp"
+	"synth/msg"
+)
+
+// CpuLoadKind msg.key = entry index * CpuKeysSpacing + CpuParam*, msg.valF = load,
+// 1 = the whole block budget. Index 0 is the total, see dsp.Profiler.Names.
+const CpuLoadKind msg.Kind = 40
+
+const (
+	CpuKeysSpacing = 2
+	CpuParamAvg    = 0
+	CpuParamPeak   = 1
+)
+
+// SafetyEventKind msg.key = Safety*, see dsp.Safety
+const SafetyEventKind msg.Kind = 41
+
+const (
+	SafetyReset = 0 // msg.valF = resets since the start
+	SafetyLimit = 1 // msg.valF = limiter gain reduction in dB, <= 0
+)
+
+// CompressorReductionKind msg.valF = gain reduction of the compressor and
+// ducking in dB, <= 0, see dsp.Compressor
+const CompressorReductionKind msg.Kind = 42
+
+const (
+	cpuPublish   = 0.1  // seconds between two publications
+	limitPublish = 0.1  // seconds between two limiter publications
+	compPublish  = 0.05 // seconds between two publications
+)
+
+// Publisher sends the readings of the master DSP nodes as messages, the
+// nodes themselves know nothing of messaging. It processes its source then
+// publishes, on the audio thread, once per block: put it last before the stream.
+type Publisher struct {
+	dsp.Node
+	messenger *msg.Messenger
+	sr        float64
+
+	profiler *dsp.Profiler
+	entries  int
+	cpu      every
+
+	safety *dsp.Safety
+	resets uint64
+	limit  every
+
+	compressor *dsp.Compressor
+	comp       every
+}
+
+// every counts blocks, fires once per n
+type every struct {
+	n, i int
+}
+
+func newEvery(sr, period float64) every {
+	return every{n: max(1, int(period*sr/dsp.BlockSize))}
+}
+
+func (e *every) tick() bool {
+	if e.n == 0 {
+		return false
+	}
+	if e.i++; e.i < e.n {
+		return false
+	}
+	e.i = 0
+	return true
+}
+
+func NewPublisher(sr float64, src dsp.Node, m *msg.Messenger) *Publisher {
+	return &Publisher{
+		Node:      src,
+		messenger: m,
+		sr:        sr,
+	}
+}
+
+// WatchProfiler publishes the loads as CpuLoadKind messages every 100ms.
+// Must be called before processing starts.
+func (p *Publisher) WatchProfiler(pr *dsp.Profiler) {
+	p.profiler = pr
+	p.entries = len(pr.Names())
+	p.cpu = newEvery(p.sr, cpuPublish)
+}
+
+// WatchSafety publishes SafetyEventKind messages, resets as they happen and
+// the limiter reduction every 100ms. Must be called before processing starts.
+func (p *Publisher) WatchSafety(s *dsp.Safety) {
+	p.safety = s
+	p.limit = newEvery(p.sr, limitPublish)
+}
+
+// WatchCompressor publishes the gain reduction as CompressorReductionKind
+// messages every 50ms. Must be called before processing starts.
+func (p *Publisher) WatchCompressor(c *dsp.Compressor) {
+	p.compressor = c
+	p.comp = newEvery(p.sr, compPublish)
+}
+
+func (p *Publisher) Process(b *dsp.Block) {
+	p.Node.Process(b)
+
+	if p.cpu.tick() {
+		for i := range p.entries {
+			load := p.profiler.Load(i)
+			key := uint8(i * CpuKeysSpacing)
+			p.messenger.SendMessage(msg.Message{Kind: CpuLoadKind, Key: key + CpuParamAvg, ValF: load.Avg})
+			p.messenger.SendMessage(msg.Message{Kind: CpuLoadKind, Key: key + CpuParamPeak, ValF: load.Peak})
+		}
+	}
+
+	if p.safety != nil {
+		if resets := p.safety.Resets(); resets != p.resets {
+			p.resets = resets
+			p.messenger.SendMessage(msg.Message{Kind: SafetyEventKind, Key: SafetyReset, ValF: float32(resets)})
+		}
+	}
+	if p.limit.tick() {
+		p.messenger.SendMessage(msg.Message{Kind: SafetyEventKind, Key: SafetyLimit, ValF: dsp.ToDb(p.safety.Reduction(), -60)})
+	}
+
+	if p.comp.tick() {
+		p.messenger.SendMessage(msg.Message{Kind: CompressorReductionKind, ValF: p.compressor.Reduction()})
+	}
+}
@@ -0,0 +1,124 @@
+package meter
+
+import (
+	"math"
+	"slices"
+	"synth/dsp"
+	"synth/msg"
+	"testing"
+)
+
+type silence struct{}
+
+func (silence) Process(b *dsp.Block) {}
+func (silence) Reset(soft bool)      {}
+
+func drain(q *msg.Queue) []msg.Message {
+	var msgs []msg.Message
+	q.Drain(64, func(m msg.Message) {
+		msgs = append(msgs, m)
+	})
+	return msgs
+}
+
+func TestPublisher_Cpu(t *testing.T) {
+	out := msg.NewQueue(64)
+	pr := dsp.NewProfiler(44100, silence{})
+	pr.Wrap("node", silence{})
+	p := NewPublisher(44100, pr, msg.NewMessenger(nil, out, 0))
+	p.WatchProfiler(pr)
+
+	var b dsp.Block
+	for range p.cpu.n - 1 {
+		p.Process(&b)
+	}
+	if msgs := drain(out); len(msgs) != 0 {
+		t.Fatalf("expected nothing before 100ms, got %v", msgs)
+	}
+
+	p.Process(&b)
+	var keys []uint8
+	for _, m := range drain(out) {
+		if m.Kind != CpuLoadKind {
+			t.Errorf("unexpected kind %d", m.Kind)
+		}
+		keys = append(keys, m.Key)
+	}
+
+	expected := []uint8{
+		0*CpuKeysSpacing + CpuParamAvg, 0*CpuKeysSpacing + CpuParamPeak,
+		1*CpuKeysSpacing + CpuParamAvg, 1*CpuKeysSpacing + CpuParamPeak,
+	}
+	if !slices.Equal(keys, expected) {
+		t.Fatalf("expected keys %v, got %v", expected, keys)
+	}
+}
+
+func TestPublisher_ProcessNoAlloc(t *testing.T) {
+	pr := dsp.NewProfiler(44100, silence{})
+	pr.Wrap("node", silence{})
+	p := NewPublisher(44100, pr, msg.NewMessenger(nil, msg.NewQueue(1024), 0))
+	p.WatchProfiler(pr)
+
+	var b dsp.Block
+	allocs := testing.AllocsPerRun(100, func() {
+		p.Process(&b)
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs, got %.1f", allocs)
+	}
+}
+
+// nan outputs a non finite sample once
+type nan struct{ done bool }
+
+func (n *nan) Process(b *dsp.Block) {
+	if !n.done {
+		b.L[0] = float32(math.NaN())
+		n.done = true
+	}
+}
+func (n *nan) Reset(soft bool) {}
+
+func TestPublisher_Safety(t *testing.T) {
+	out := msg.NewQueue(64)
+	safety := dsp.NewSafety(44100, &nan{}, dsp.NewParam(0))
+	p := NewPublisher(44100, safety, msg.NewMessenger(nil, out, 0))
+	p.WatchSafety(safety)
+
+	var b dsp.Block
+	p.Process(&b)
+	msgs := drain(out)
+	if len(msgs) != 1 || msgs[0] != (msg.Message{Kind: SafetyEventKind, Key: SafetyReset, ValF: 1}) {
+		t.Fatalf("expected 1 reset, got %v", msgs)
+	}
+
+	for range p.limit.n - 1 {
+		p.Process(&b)
+	}
+	msgs = drain(out)
+	if len(msgs) != 1 || msgs[0].Kind != SafetyEventKind || msgs[0].Key != SafetyLimit || msgs[0].ValF != 0 {
+		t.Fatalf("expected the limiter reduction once, got %v", msgs)
+	}
+}
+
+func TestPublisher_Compressor(t *testing.T) {
+	out := msg.NewQueue(64)
+	comp := dsp.NewCompressor(dsp.CompressorOpts{
+		SampleRate: 44100,
+		Src:        silence{},
+		Enabled:    dsp.NewParam(0),
+	})
+	p := NewPublisher(44100, comp, msg.NewMessenger(nil, out, 0))
+	p.WatchCompressor(comp)
+
+	var b dsp.Block
+	for range 2 * p.comp.n {
+		p.Process(&b)
+	}
+	msgs := drain(out)
+	if len(msgs) != 2 || msgs[0] != (msg.Message{Kind: CompressorReductionKind}) {
+		t.Fatalf("expected the reduction every 50ms, got %v", msgs)
+	}
+}
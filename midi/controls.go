@@ -0,0 +1,103 @@
+package midi
+
+import "synth/msg"
+
+// ControlMapping maps a control change to a parameter update
+type ControlMapping struct {
+	Key   uint8                 // parameter key
+	Scale func(float32) float32 // maps the 0..1 control value to the parameter value
+}
+
+// ControlMapper turns mapped control changes and NRPNs into parameter updates,
+// 14 bits control changes refine the value of their MSB control.
+// Updates are applied to the handler and echoed through the messenger,
+// so that other components (UI) stay in sync.
+type ControlMapper struct {
+	kind     msg.Kind
+	mappings [128]*ControlMapping
+	nrpn     map[uint16]*ControlMapping
+	handler  msg.Handler
+	echo     *msg.Messenger
+}
+
+func NewControlMapper(kind msg.Kind, handler msg.Handler, echo *msg.Messenger) *ControlMapper {
+	return &ControlMapper{
+		kind:    kind,
+		nrpn:    make(map[uint16]*ControlMapping),
+		handler: handler,
+		echo:    echo,
+	}
+}
+
+// Map binds a control change number to a parameter, replacing any previous binding
+func (c *ControlMapper) Map(cc uint8, m ControlMapping) {
+	if cc >= uint8(len(c.mappings)) {
+		return
+	}
+	c.mappings[cc] = &m
+}
+
+// MapNRPN binds an NRPN number to a parameter, replacing any previous binding
+func (c *ControlMapper) MapNRPN(nrpn uint16, m ControlMapping) {
+	c.nrpn[nrpn] = &m
+}
+
+func (c *ControlMapper) HandleMessage(m msg.Message) {
+	var mapping *ControlMapping
+	val := m.ValF
+	switch m.Kind {
+	case ControlChangeKind, ControlChange14Kind:
+		if m.Key < uint8(len(c.mappings)) {
+			mapping = c.mappings[m.Key]
+		}
+		if m.Kind == ControlChangeKind {
+			val = float32(m.Val8) / 127
+		}
+	case NrpnKind:
+		mapping = c.nrpn[uint16(m.Val16)]
+	}
+	if mapping == nil {
+		return
+	}
+
+	update := msg.Message{
+		Kind:  c.kind,
+		Key:   mapping.Key,
+		ValF:  mapping.Scale(val),
+		Frame: m.Frame,
+	}
+
+	c.handler.HandleMessage(update)
+	if c.echo != nil {
+		c.echo.SendMessage(update)
+	}
+}
+
+// ControlTrigger sends a message when a control change goes from released
+// (< 64) to pressed (>= 64), for momentary buttons
+type ControlTrigger struct {
+	cc      uint8
+	message msg.Message
+	handler msg.Handler
+	down    bool
+}
+
+func NewControlTrigger(cc uint8, m msg.Message, handler msg.Handler) *ControlTrigger {
+	return &ControlTrigger{
+		cc:      cc,
+		message: m,
+		handler: handler,
+	}
+}
+
+func (c *ControlTrigger) HandleMessage(m msg.Message) {
+	if m.Kind != ControlChangeKind || m.Key != c.cc {
+		return
+	}
+
+	down := m.Val8 >= 64
+	if down && !c.down {
+		c.handler.HandleMessage(c.message)
+	}
+	c.down = down
+}
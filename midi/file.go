@@ -0,0 +1,281 @@
+package midi
+
+import (
+	"cmp"
+	"errors"
+	"path/filepath"
+	"slices"
+	"synth/msg"
+	"synth/settings"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+var ErrTimeCode = errors.New("SMPTE time code files are not supported")
+
+// fileLookahead seconds of events sent ahead of the clock, covers the
+// scheduling period jitter
+const fileLookahead = .05
+
+// FilePlayer plays the Standard MIDI Files (.mid) of a folder: notes, control
+// changes and pitch bends are stamped against the clock and sent ahead of
+// time, the audio messenger holds them until their block. Program changes
+// are ignored, files audition the current presets.
+//
+// Commands are FilePlayKind messages, loop and tempo override are settings.
+// Register it on its own messenger, then call Schedule periodically from the
+// same goroutine.
+type FilePlayer struct {
+	files     []string
+	sr        float64
+	logger    zerolog.Logger
+	messenger *msg.Messenger
+	now       func() uint64 // frame at which a message sent now applies, 0 if unknown
+
+	loop  bool
+	tempo float64 // bpm, 0 = tempo of the file
+
+	current int // file index, -1 none or not from the folder
+	song    *song
+	next    int   // next event
+	origin  int64 // frame of the song start, 0 = waiting for the clock
+	held    [16][128]bool
+}
+
+type song struct {
+	events     []fileEvent
+	resolution float64 // ticks per quarter note
+	end        fileEvent
+}
+
+type fileEvent struct {
+	tick int64
+	us   int64 // at the tempo of the file
+	m    msg.Message
+}
+
+// NewFilePlayer plays the .mid files of dir, in name order
+func NewFilePlayer(sr float64, logger zerolog.Logger, messenger *msg.Messenger, clock *msg.Clock, dir string) *FilePlayer {
+	files, err := filepath.Glob(filepath.Join(dir, "*.mid"))
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to glob midi files")
+	}
+	slices.Sort(files)
+
+	return &FilePlayer{
+		files:     files,
+		sr:        sr,
+		logger:    logger,
+		messenger: messenger,
+		now:       clock.Stamp,
+		current:   -1,
+	}
+}
+
+// Names file names by index, see FilePlayKind
+func (p *FilePlayer) Names() []string {
+	names := make([]string, len(p.files))
+	for i, f := range p.files {
+		names[i] = filepath.Base(f)
+	}
+	return names
+}
+
+func (p *FilePlayer) HandleMessage(m msg.Message) {
+	switch m.Kind {
+	case FilePlayKind:
+		i := int(m.Key)
+		if m.ValF == 0 {
+			if i == p.current {
+				p.Stop()
+			}
+			return
+		}
+		if i < len(p.files) && i != p.current {
+			if err := p.Play(p.files[i]); err == nil {
+				p.current = i
+			}
+		}
+	case settings.SettingUpdateKind:
+		switch m.Key {
+		case settings.FileLoop:
+			p.loop = m.ValF != 0
+		case settings.FileTempo:
+			p.setTempo(float64(m.ValF))
+		}
+	}
+}
+
+// Play stops the current file and plays the file at path, from the next Schedule
+func (p *FilePlayer) Play(path string) error {
+	s, err := readSong(path)
+	if err != nil {
+		p.logger.Error().Err(err).Str("file", path).Msg("failed to read midi file")
+		return err
+	}
+
+	p.Stop()
+	p.song = s
+	p.next = 0
+	p.origin = 0
+	p.logger.Info().Str("file", path).Int("events", len(s.events)).Msg("playing")
+	return nil
+}
+
+// Stop releases the held notes and publishes the stop of the current file
+func (p *FilePlayer) Stop() {
+	if p.song == nil {
+		return
+	}
+
+	p.release(p.now())
+	p.song = nil
+	if p.current >= 0 {
+		p.messenger.SendMessage(msg.Message{Kind: FilePlayKind, Key: uint8(p.current)})
+		p.current = -1
+	}
+}
+
+// Schedule sends the events due before the lookahead, loops or stops at the
+// end of the file
+func (p *FilePlayer) Schedule() {
+	if p.song == nil {
+		return
+	}
+
+	now := int64(p.now())
+	if now == 0 {
+		return // clock not started yet
+	}
+	if p.origin == 0 {
+		p.origin = now
+	}
+
+	horizon := now + int64(fileLookahead*p.sr)
+	for {
+		if p.next == len(p.song.events) {
+			end := p.origin + p.frames(p.song.end)
+			if end >= horizon {
+				return
+			}
+			if !p.loop || end == p.origin {
+				p.Stop()
+				return
+			}
+			p.release(uint64(end))
+			p.origin, p.next = end, 0
+		}
+
+		e := &p.song.events[p.next]
+		at := p.origin + p.frames(*e)
+		if at >= horizon {
+			return
+		}
+
+		m := e.m
+		m.Frame = uint64(max(at, 1))
+		switch m.Kind {
+		case NoteOnKind:
+			p.held[m.Chan][m.Key] = true
+		case NoteOffKind:
+			p.held[m.Chan][m.Key] = false
+		}
+		p.messenger.SendMessage(m)
+		p.next++
+	}
+}
+
+// frames position of the event from the song start, at the tempo override if any
+func (p *FilePlayer) frames(e fileEvent) int64 {
+	if p.tempo > 0 {
+		return int64(float64(e.tick) / p.song.resolution * 60 / p.tempo * p.sr)
+	}
+	return int64(float64(e.us) / 1e6 * p.sr)
+}
+
+// setTempo keeps the last sent event in place, the following ones move
+func (p *FilePlayer) setTempo(bpm float64) {
+	if p.song != nil && p.origin != 0 && p.next > 0 {
+		last := p.song.events[p.next-1]
+		at := p.origin + p.frames(last)
+		p.tempo = bpm
+		p.origin = at - p.frames(last)
+		return
+	}
+	p.tempo = bpm
+}
+
+// release sends note offs for the held notes at frame
+func (p *FilePlayer) release(frame uint64) {
+	for ch := range p.held {
+		for key, held := range p.held[ch] {
+			if held {
+				p.messenger.SendMessage(msg.Message{Kind: NoteOffKind, Chan: uint8(ch), Key: uint8(key), Frame: frame})
+				p.held[ch][key] = false
+			}
+		}
+	}
+}
+
+// readSong merges the tracks of the file in time order, note offs first
+func readSong(path string) (*song, error) {
+	f, err := smf.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mt, ok := f.TimeFormat.(smf.MetricTicks)
+	if !ok {
+		return nil, ErrTimeCode
+	}
+
+	s := &song{resolution: float64(mt.Resolution())}
+	for _, track := range f.Tracks {
+		var tick int64
+		for _, ev := range track {
+			tick += int64(ev.Delta)
+			if m, ok := fileMessage(ev.Message); ok {
+				s.events = append(s.events, fileEvent{tick: tick, m: m})
+			}
+		}
+		s.end.tick = max(s.end.tick, tick)
+	}
+
+	slices.SortStableFunc(s.events, func(a, b fileEvent) int {
+		if c := cmp.Compare(a.tick, b.tick); c != 0 {
+			return c
+		}
+		return cmp.Compare(offFirst(a), offFirst(b))
+	})
+
+	for i := range s.events {
+		s.events[i].us = f.TimeAt(s.events[i].tick)
+	}
+	s.end.us = f.TimeAt(s.end.tick)
+
+	return s, nil
+}
+
+func offFirst(e fileEvent) int {
+	if e.m.Kind == NoteOffKind {
+		return 0
+	}
+	return 1
+}
+
+func fileMessage(m smf.Message) (msg.Message, bool) {
+	var ch, key, val8 uint8
+	var val16 int16
+	switch {
+	case m.GetNoteStart(&ch, &key, &val8):
+		return msg.Message{Kind: NoteOnKind, Chan: ch, Key: key, Val8: val8}, true
+	case m.GetNoteEnd(&ch, &key):
+		return msg.Message{Kind: NoteOffKind, Chan: ch, Key: key}, true
+	case m.GetControlChange(&ch, &key, &val8) && key != BankSelectMsb && key != BankSelectLsb:
+		return msg.Message{Kind: ControlChangeKind, Chan: ch, Key: key, Val8: val8}, true
+	case m.GetPitchBend(&ch, &val16, nil):
+		return msg.Message{Kind: PitchBendKind, Chan: ch, Val16: val16}, true
+	}
+	return msg.Message{}, false
+}
@@ -0,0 +1,124 @@
+package midi
+
+import (
+	"path/filepath"
+	"slices"
+	"synth/msg"
+	"synth/settings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+// writeSong two quarter notes at 120 bpm, 480 ticks per quarter, a bank
+// select and a program change to skip
+func writeSong(t *testing.T, dir string) {
+	var track smf.Track
+	track.Add(0, smf.MetaTempo(120))
+	track.Add(0, midi.ControlChange(0, BankSelectMsb, 1))
+	track.Add(0, midi.ProgramChange(0, 3))
+	track.Add(0, midi.NoteOn(0, 60, 100))
+	track.Add(480, midi.NoteOff(0, 60))
+	track.Add(0, midi.NoteOn(1, 64, 90))
+	track.Add(480, midi.NoteOff(1, 64))
+	track.Close(0)
+
+	s := smf.New()
+	s.TimeFormat = smf.MetricTicks(480)
+	if err := s.Add(track); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteFile(filepath.Join(dir, "phrase.mid")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type fileFixture struct {
+	player *FilePlayer
+	out    *msg.Queue
+	now    uint64
+}
+
+func newFileFixture(t *testing.T) *fileFixture {
+	dir := t.TempDir()
+	writeSong(t, dir)
+
+	f := &fileFixture{out: msg.NewQueue(64)}
+	f.player = NewFilePlayer(1000, zerolog.Nop(), msg.NewMessenger(nil, f.out, 0), nil, dir)
+	f.player.now = func() uint64 { return f.now }
+	return f
+}
+
+// advance schedules at now, returns the messages sent
+func (f *fileFixture) advance(now uint64) []msg.Message {
+	f.now = now
+	f.player.Schedule()
+
+	var msgs []msg.Message
+	f.out.Drain(0, func(m msg.Message) { msgs = append(msgs, m) })
+	return msgs
+}
+
+func TestFilePlayer_Schedule(t *testing.T) {
+	f := newFileFixture(t)
+	if names := f.player.Names(); len(names) != 1 || names[0] != "phrase.mid" {
+		t.Fatalf("expected phrase.mid, got %v", names)
+	}
+
+	f.player.HandleMessage(msg.Message{Kind: FilePlayKind, Key: 0, ValF: 1})
+	if msgs := f.advance(0); len(msgs) != 0 {
+		t.Fatalf("expected to wait for the clock, got %v", msgs)
+	}
+
+	// Quarter notes of 500 frames, the lookahead is 50 frames
+	msgs := f.advance(100)
+	if len(msgs) != 1 || msgs[0].Kind != NoteOnKind || msgs[0].Key != 60 || msgs[0].Frame != 100 {
+		t.Fatalf("expected the first note on at 100, got %v", msgs)
+	}
+	if msgs := f.advance(540); len(msgs) != 0 {
+		t.Fatalf("expected nothing before the lookahead, got %v", msgs)
+	}
+
+	msgs = f.advance(560)
+	if len(msgs) != 2 || msgs[0].Kind != NoteOffKind || msgs[1].Kind != NoteOnKind || msgs[1].Chan != 1 || msgs[1].Frame != 600 {
+		t.Fatalf("expected the note off then the note on at 600, got %v", msgs)
+	}
+
+	// End of the file, the UI selector is told
+	msgs = f.advance(1100)
+	if len(msgs) != 2 || msgs[0].Kind != NoteOffKind || msgs[0].Frame != 1100 || msgs[1].Kind != FilePlayKind || msgs[1].ValF != 0 {
+		t.Fatalf("expected the last note off and the stop, got %v", msgs)
+	}
+	if msgs := f.advance(2000); len(msgs) != 0 {
+		t.Fatalf("expected nothing after the end, got %v", msgs)
+	}
+}
+
+func TestFilePlayer_LoopAndTempo(t *testing.T) {
+	f := newFileFixture(t)
+	f.player.HandleMessage(msg.Message{Kind: settings.SettingUpdateKind, Key: settings.FileLoop, ValF: 1})
+	f.player.HandleMessage(msg.Message{Kind: settings.SettingUpdateKind, Key: settings.FileTempo, ValF: 60})
+	f.player.HandleMessage(msg.Message{Kind: FilePlayKind, Key: 0, ValF: 1})
+
+	// Quarter notes of 1000 frames at 60 bpm, the song loops after 2000
+	var frames []uint64
+	for now := uint64(1); now < 3900; now += 100 {
+		for _, m := range f.advance(now) {
+			if m.Kind == NoteOnKind {
+				frames = append(frames, m.Frame)
+			}
+		}
+	}
+	if exp := []uint64{1, 1001, 2001, 3001}; !slices.Equal(frames, exp) {
+		t.Fatalf("expected note ons at %v, got %v", exp, frames)
+	}
+
+	// Stopped while a note is held
+	f.player.HandleMessage(msg.Message{Kind: FilePlayKind, Key: 0, ValF: 0})
+	msgs := f.advance(4200)
+	if len(msgs) != 2 || msgs[0].Kind != NoteOffKind || msgs[0].Key != 64 || msgs[1].Kind != FilePlayKind {
+		t.Fatalf("expected the held note released and the stop, got %v", msgs)
+	}
+}
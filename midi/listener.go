@@ -1,8 +1,11 @@
 package midi
 
 import (
+	"hash/fnv"
+	"slices"
 	"sync"
 	"synth/msg"
+	"synth/settings"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -10,28 +13,65 @@ import (
 	"gitlab.com/gomidi/midi/v2/drivers"
 )
 
+// sysExBufferSize fits a preset dump, see preset.PresetDumpSysEx
+const sysExBufferSize = 16 * 1024
+
 type device struct {
 	name  string
+	slot  int // settings slot, -1 none
 	stop  func()
 	found bool
+	// Callbacks may still run while the port stops, stopped drops them
+	mu      sync.Mutex     // parser, notes and stopped
+	parser  ControlParser  // parameter selections are stateful
+	notes   [16][128]int16 // sounding key + 1 per input key, 0 released
+	stopped bool
+}
+
+// deviceSlot settings of a device, see settings.DeviceKey
+type deviceSlot struct {
+	id        float32 // see deviceID, 0 free
+	enabled   bool
+	channel   uint8 // 0 omni, 1..16
+	transpose int
 }
 
+// Listener listens to the MIDI input ports, polled for hotplug. Each port
+// takes a settings slot (settings.DeviceKey) matched by name, which enables
+// it and sets its channel filter and transpose. Ports are opened once the
+// device settings are known.
 type Listener struct {
 	logger  zerolog.Logger
 	out     *msg.Queue
+	clock   *msg.Clock
+	ports   func() []drivers.In
 	devices []*device
 	msgs    chan msg.Message
+	sysex   func([]byte)
 	done    chan struct{}
 	once    sync.Once
+
+	mu      sync.Mutex // slots, known, names and present
+	slots   [settings.MaxDevices]deviceSlot
+	known   [settings.MaxDevices]bool // slot id received
+	names   [settings.MaxDevices]string
+	present [settings.MaxDevices]bool
 }
 
-func NewListener(log zerolog.Logger, out *msg.Queue) *Listener {
-	return &Listener{
+// NewListener clock: timestamps incoming messages, can be nil
+func NewListener(log zerolog.Logger, out *msg.Queue, clock *msg.Clock) *Listener {
+	l := &Listener{
 		logger: log,
 		out:    out,
+		clock:  clock,
+		ports:  func() []drivers.In { return midi.GetInPorts() },
 		msgs:   make(chan msg.Message, 1024),
 		done:   make(chan struct{}),
 	}
+	for i := range l.slots {
+		l.slots[i].enabled = true
+	}
+	return l
 }
 
 func (l *Listener) ListenAll() {
@@ -74,23 +114,100 @@ func (l *Listener) Close() {
 	})
 }
 
+// HandleSysEx sets the handler of the received SysEx bodies (without F0 and
+// F7), called from the listening goroutines. Set it before ListenAll.
+func (l *Listener) HandleSysEx(h func(body []byte)) {
+	l.sysex = h
+}
+
+// HandleMessage applies the device settings, applied to the ports at the next
+// scan. Safe for concurrent use.
+func (l *Listener) HandleMessage(m msg.Message) {
+	if m.Kind != settings.SettingUpdateKind || m.Key < settings.DeviceKeys {
+		return
+	}
+	i := int(m.Key-settings.DeviceKeys) / settings.DeviceKeysSpacing
+	if i >= settings.MaxDevices {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot := &l.slots[i]
+	switch int(m.Key-settings.DeviceKeys) % settings.DeviceKeysSpacing {
+	case settings.DeviceID:
+		if slot.id != m.ValF {
+			l.names[i], l.present[i] = "", false
+		}
+		slot.id, l.known[i] = m.ValF, true
+	case settings.DeviceEnabled:
+		slot.enabled = m.ValF != 0
+	case settings.DeviceChannel:
+		slot.channel = uint8(min(max(m.ValF, 0), 16))
+	case settings.DeviceTranspose:
+		slot.transpose = int(m.ValF)
+	}
+}
+
+// Device name of the port of a settings slot, empty when not seen since the
+// start, and whether it is connected. Safe for concurrent use.
+func (l *Listener) Device(slot int) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if slot < 0 || slot >= settings.MaxDevices {
+		return "", false
+	}
+	return l.names[slot], l.present[slot]
+}
+
 func (l *Listener) scanDevices() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Slots are matched by name, claiming one needs the stored ones
+	for _, known := range l.known {
+		if !known {
+			return
+		}
+	}
+
 	// Mark all devices as not found
 	for _, dev := range l.devices {
 		dev.found = false
 	}
 
-	// Check for new devices
-	for _, in := range midi.GetInPorts() {
+	// Bind the ports to their slot, new ports claim one
+	ports := l.ports()
+	slots := make([]int, len(ports))
+	l.present = [settings.MaxDevices]bool{}
+	for i, in := range ports {
+		slots[i] = l.findSlot(in.String())
+		if slots[i] >= 0 {
+			l.names[slots[i]], l.present[slots[i]] = in.String(), true
+		}
+	}
+	for i, in := range ports {
+		if slots[i] < 0 {
+			slots[i] = l.claimSlot(in.String())
+		}
+	}
+
+	// Check for new devices, disabled ones are not listened to
+	for i, in := range ports {
+		if slots[i] >= 0 && !l.slots[slots[i]].enabled {
+			continue
+		}
 		dev := l.findDevice(in)
 		if dev == nil {
-			l.listenDevice(in)
+			l.listenDevice(in, slots[i])
 			continue
 		}
 		dev.found = true
 	}
 
-	// Remove devices that are no longer connected
+	// Remove devices that are no longer connected or enabled
 	var activeDevices []*device
 	for _, dev := range l.devices {
 		if !dev.found {
@@ -111,14 +228,68 @@ func (l *Listener) findDevice(in drivers.In) *device {
 	return nil
 }
 
-func (l *Listener) listenDevice(in drivers.In) {
+func (l *Listener) findSlot(name string) int {
+	id := deviceID(name)
+	for i := range l.slots {
+		if l.slots[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// claimSlot takes a free slot for a new port, else the slot of a disconnected
+// device, and stores it in the settings. -1 when all the slots are connected.
+func (l *Listener) claimSlot(name string) int {
+	slot := -1
+	for i := range l.slots {
+		if l.slots[i].id == 0 {
+			slot = i
+			break
+		}
+	}
+	for i := 0; slot < 0 && i < len(l.slots); i++ {
+		if !l.present[i] {
+			slot = i
+		}
+	}
+	if slot < 0 {
+		l.logger.Warn().Str("device", name).Msg("no device slot left, using defaults")
+		return -1
+	}
+
+	l.slots[slot] = deviceSlot{id: deviceID(name), enabled: true}
+	l.names[slot], l.present[slot] = name, true
+	l.send(settingMessage(settings.DeviceKey(slot, settings.DeviceID), l.slots[slot].id))
+	l.send(settingMessage(settings.DeviceKey(slot, settings.DeviceEnabled), 1))
+	l.send(settingMessage(settings.DeviceKey(slot, settings.DeviceChannel), 0))
+	l.send(settingMessage(settings.DeviceKey(slot, settings.DeviceTranspose), 0))
+	return slot
+}
+
+// deviceSettings settings of a device, the defaults without slot
+func (l *Listener) deviceSettings(dev *device) deviceSlot {
+	if dev.slot < 0 {
+		return deviceSlot{enabled: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.slots[dev.slot]
+}
+
+func (l *Listener) listenDevice(in drivers.In, slot int) {
+	dev := &device{name: in.String(), slot: slot, found: true}
 	stop, err := midi.ListenTo(
 		in,
-		l.handleMessage,
+		func(m midi.Message, _ int32) {
+			l.handleMessage(dev, m)
+		},
 		midi.HandleError(func(err error) {
 			l.logger.Error().Err(err).Msg("listen error")
 		}),
 		midi.UseSysEx(),
+		midi.SysExBufferSize(sysExBufferSize),
 	)
 
 	if err != nil {
@@ -126,47 +297,107 @@ func (l *Listener) listenDevice(in drivers.In) {
 		return
 	}
 
-	l.logger.Info().Str("device", in.String()).Msg("listening")
+	l.logger.Info().Str("device", in.String()).Int("slot", slot).Msg("listening")
 
-	l.devices = append(l.devices, &device{
-		name: in.String(),
-		stop: func() {
-			stop()
-			l.logger.Info().Str("device", in.String()).Msg("stopped listening")
-		},
-		found: true,
-	})
+	dev.stop = func() {
+		stop()
+		dev.mu.Lock()
+		dev.stopped = true
+		l.releaseNotes(dev)
+		dev.mu.Unlock()
+		l.logger.Info().Str("device", in.String()).Msg("stopped listening")
+	}
+	l.devices = append(l.devices, dev)
 }
 
-func (l *Listener) handleMessage(message midi.Message, _ int32) {
+// releaseNotes sends a note off for the sounding notes of a device, dev.mu held
+func (l *Listener) releaseNotes(dev *device) {
+	for ch := range dev.notes {
+		for key, sounding := range dev.notes[ch] {
+			if sounding != 0 {
+				l.send(msg.Message{Kind: NoteOffKind, Key: uint8(sounding - 1), Chan: uint8(ch)})
+				dev.notes[ch][key] = 0
+			}
+		}
+	}
+}
+
+// deviceID 24 bits hash of a port name, exact as a setting value, never 0
+func deviceID(name string) float32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return float32(max(h.Sum32()&0xffffff, 1))
+}
+
+func (l *Listener) handleMessage(dev *device, message midi.Message) {
 	var ch, key, val8 uint8
 	var val16 int16
+	var body []byte
+
+	// Settings first, scanDevices holds l.mu while stopping the device
+	filter := l.deviceSettings(dev)
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+	if dev.stopped {
+		return
+	}
+
+	if message.GetChannel(&ch) && filter.channel != 0 && ch != filter.channel-1 {
+		return
+	}
+
 	switch {
 	case message.GetNoteStart(&ch, &key, &val8):
+		sounding := int(key) + filter.transpose
+		if sounding < 0 || sounding > 127 {
+			return
+		}
+		dev.notes[ch][key] = int16(sounding + 1)
 		l.send(msg.Message{
 			Kind: NoteOnKind,
-			Key:  key,
+			Key:  uint8(sounding),
 			Val8: val8,
 			Chan: ch,
 		})
 		l.logger.Debug().Uint8("channel", ch).Uint8("key", key).Uint8("val8", val8).Msg("Note ON")
 
 	case message.GetNoteEnd(&ch, &key):
+		// The transpose of the note on, it may have changed since
+		sounding := int(dev.notes[ch][key]) - 1
+		if sounding < 0 {
+			sounding = int(key) + filter.transpose
+		}
+		dev.notes[ch][key] = 0
+		if sounding < 0 || sounding > 127 {
+			return
+		}
 		l.send(msg.Message{
 			Kind: NoteOffKind,
-			Key:  key,
+			Key:  uint8(sounding),
 			Val8: val8,
 			Chan: ch,
 		})
 		l.logger.Debug().Uint8("channel", ch).Uint8("key", key).Uint8("val8", val8).Msg("Note OFF")
-	case message.GetControlChange(&ch, &key, &val8):
+	case message.GetControlChange(&ch, &key, &val8) && (key == BankSelectMsb || key == BankSelectLsb):
 		l.send(msg.Message{
-			Kind: ControlChangeKind,
+			Kind: BankSelectKind,
 			Key:  key,
 			Val8: val8,
 			Chan: ch,
 		})
+		l.logger.Debug().Uint8("channel", ch).Uint8("controller", key).Uint8("value", val8).Msg("Bank Select")
+	case message.GetControlChange(&ch, &key, &val8):
+		if m, ok := dev.parser.Parse(ch, key, val8); ok {
+			l.send(m)
+		}
 		l.logger.Debug().Uint8("channel", ch).Uint8("controller", key).Uint8("value", val8).Msg("Control Change")
+	case message.GetProgramChange(&ch, &key):
+		l.send(msg.Message{
+			Kind: ProgramChangeKind,
+			Key:  key,
+			Chan: ch,
+		})
+		l.logger.Debug().Uint8("channel", ch).Uint8("program", key).Msg("Program Change")
 	case message.GetPitchBend(&ch, &val16, nil):
 		l.send(msg.Message{
 			Kind:  PitchBendKind,
@@ -174,12 +405,19 @@ func (l *Listener) handleMessage(message midi.Message, _ int32) {
 			Chan:  ch,
 		})
 		l.logger.Debug().Uint8("channel", ch).Int16("value", val16).Msg("Pitch Bend")
+	case message.GetSysEx(&body):
+		if l.sysex != nil {
+			l.sysex(slices.Clone(body))
+		}
+		l.logger.Debug().Int("bytes", len(body)).Msg("SysEx")
 	default:
 		l.logger.Debug().Str("msg", message.String()).Msg("unknown message")
 	}
 }
 
 func (l *Listener) send(m msg.Message) {
+	m.Frame = l.clock.Stamp()
+
 	select {
 	case <-l.done:
 		// closing, drop message
@@ -2,9 +2,12 @@ package midi
 
 import (
 	"synth/msg"
+	"synth/settings"
 	"testing"
 
 	"github.com/rs/zerolog"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
 	"go.uber.org/goleak"
 )
 
@@ -18,8 +21,125 @@ func TestListener_StopsGoroutines(t *testing.T) {
 	out := msg.NewQueue(1024)
 	logger := zerolog.Nop()
 
-	l := NewListener(logger, out)
+	l := NewListener(logger, out, nil)
 
 	go l.ListenAll()
 	l.Close()
 }
+
+// fakeIn in port whose messages are sent by the test, not safe for concurrent use
+type fakeIn struct {
+	name   string
+	open   bool
+	listen func([]byte, int32)
+}
+
+func (f *fakeIn) Open() error             { f.open = true; return nil }
+func (f *fakeIn) Close() error            { f.open = false; return nil }
+func (f *fakeIn) IsOpen() bool            { return f.open }
+func (f *fakeIn) Number() int             { return 0 }
+func (f *fakeIn) String() string          { return f.name }
+func (f *fakeIn) Underlying() interface{} { return nil }
+
+func (f *fakeIn) Listen(onMsg func([]byte, int32), _ drivers.ListenConfig) (func(), error) {
+	f.listen = onMsg
+	return func() { f.listen = nil }, nil
+}
+
+func (f *fakeIn) send(t *testing.T, m midi.Message) {
+	t.Helper()
+	if f.listen == nil {
+		t.Fatalf("%s is not listened to", f.name)
+	}
+	f.listen(m, 0)
+}
+
+// drain messages sent by the listener
+func drain(l *Listener) []msg.Message {
+	var msgs []msg.Message
+	for {
+		select {
+		case m := <-l.msgs:
+			msgs = append(msgs, m)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestListener_Devices(t *testing.T) {
+	keys, pads := &fakeIn{name: "Keys"}, &fakeIn{name: "Pads"}
+	ports := []drivers.In{keys}
+
+	l := NewListener(zerolog.Nop(), msg.NewQueue(64), nil)
+	l.ports = func() []drivers.In { return ports }
+
+	// Ports wait for the stored slots
+	l.scanDevices()
+	if keys.listen != nil {
+		t.Fatal("expected no port opened before the device settings")
+	}
+
+	// Defaults as published by the settings, pads remembered in slot 0, on
+	// channel 10 and an octave up
+	for i := 0; i < settings.MaxDevices; i++ {
+		l.HandleMessage(setting(settings.DeviceKey(i, settings.DeviceID), 0))
+		l.HandleMessage(setting(settings.DeviceKey(i, settings.DeviceEnabled), 1))
+	}
+	l.HandleMessage(setting(settings.DeviceKey(0, settings.DeviceID), deviceID("Pads")))
+	l.HandleMessage(setting(settings.DeviceKey(0, settings.DeviceChannel), 10))
+	l.HandleMessage(setting(settings.DeviceKey(0, settings.DeviceTranspose), 12))
+
+	// Hotplug, the new port claims the free slot 1
+	ports = []drivers.In{keys, pads}
+	l.scanDevices()
+	msgs := drain(l)
+	if len(msgs) != 4 || msgs[0].Key != settings.DeviceKey(1, settings.DeviceID) || msgs[0].ValF != deviceID("Keys") {
+		t.Fatalf("expected keys stored in slot 1, got %v", msgs)
+	}
+	for i, exp := range []string{"Pads", "Keys"} {
+		if name, connected := l.Device(i); name != exp || !connected {
+			t.Fatalf("slot %d: expected %s connected, got %q %v", i, exp, name, connected)
+		}
+	}
+
+	// Channel filter and transpose
+	pads.send(t, midi.NoteOn(0, 60, 100))
+	pads.send(t, midi.NoteOn(9, 60, 100))
+	keys.send(t, midi.NoteOn(0, 60, 100))
+	msgs = drain(l)
+	if len(msgs) != 2 || msgs[0].Key != 72 || msgs[0].Chan != 9 || msgs[1].Key != 60 {
+		t.Fatalf("expected pads on channel 10 transposed and keys, got %v", msgs)
+	}
+
+	// Note offs follow the transpose of their note on
+	l.HandleMessage(setting(settings.DeviceKey(0, settings.DeviceTranspose), 0))
+	pads.send(t, midi.NoteOff(9, 60))
+	if msgs := drain(l); len(msgs) != 1 || msgs[0].Kind != NoteOffKind || msgs[0].Key != 72 {
+		t.Fatalf("expected note off 72, got %v", msgs)
+	}
+
+	// Unplugged while a note is held, a callback running late is dropped
+	pads.send(t, midi.NoteOn(9, 62, 100))
+	drain(l)
+	late := pads.listen
+	ports = []drivers.In{keys}
+	l.scanDevices()
+	late(midi.NoteOn(9, 64, 100), 0)
+	if msgs := drain(l); len(msgs) != 1 || msgs[0].Kind != NoteOffKind || msgs[0].Key != 62 {
+		t.Fatalf("expected the held note released only, got %v", msgs)
+	}
+	if name, connected := l.Device(0); name != "Pads" || connected {
+		t.Fatalf("expected pads disconnected, got %q %v", name, connected)
+	}
+
+	// Disabled
+	l.HandleMessage(setting(settings.DeviceKey(1, settings.DeviceEnabled), 0))
+	l.scanDevices()
+	if keys.listen != nil {
+		t.Fatal("expected keys stopped")
+	}
+	if _, connected := l.Device(1); !connected {
+		t.Fatal("expected keys still connected")
+	}
+}
@@ -6,3 +6,26 @@ const NoteOnKind msg.Kind = 1
 const NoteOffKind msg.Kind = 2
 const PitchBendKind msg.Kind = 3
 const ControlChangeKind msg.Kind = 4
+
+// ProgramChangeKind msg.key = program
+const ProgramChangeKind msg.Kind = 5
+
+// BankSelectKind msg.key = BankSelectMsb or BankSelectLsb, msg.val8 = value.
+// The bank applies to the next program change.
+const BankSelectKind msg.Kind = 6
+
+const (
+	BankSelectMsb = 0  // CC 0
+	BankSelectLsb = 32 // CC 32
+)
+
+// FilePlayKind msg.key = FilePlayer file index, msg.valF = 1 play, 0 stop.
+// The player publishes the stop of a file back.
+const FilePlayKind msg.Kind = 7
+
+// ControlChange14Kind msg.key = MSB control change (0-31), msg.val16 = 14 bits
+// value, msg.valF = value in 0..1. Sent on the LSB (CC 32-63), see ControlParser.
+const ControlChange14Kind msg.Kind = 8
+
+// NrpnKind msg.val16 = NRPN number (14 bits), msg.valF = value in 0..1, see ControlParser
+const NrpnKind msg.Kind = 9
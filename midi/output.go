@@ -0,0 +1,210 @@
+package midi
+
+import (
+	"synth/msg"
+	"synth/settings"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// OutputMapping maps a parameter to a control change, or to an NRPN when CC
+// is 0 (bank select can not be a parameter)
+type OutputMapping struct {
+	CC        uint8
+	NRPN      uint16                // 14 bits parameter number
+	Normalize func(float32) float32 // maps the parameter value to 0..1
+}
+
+// Output sends the parameter updates coming back from the engine (edits,
+// preset loads) to a MIDI out port, for controllers with motorized faders or
+// LED rings to follow. It optionally forwards the input messages (MIDI thru).
+// Port, channel and mode are settings.
+//
+// Register it on its own messenger, sends may block on the port.
+type Output struct {
+	kind   msg.Kind
+	ports  []drivers.Out
+	logger zerolog.Logger
+
+	port drivers.Out
+	send func(midi.Message) error
+
+	mappings [256]*OutputMapping
+	values   [256]float32 // last parameter values, sent again on port change
+	known    [256]bool
+	sent     [256]int32 // last value sent per parameter, -1 none
+
+	channel uint8 // 0 based
+	mode    int   // see settings.MidiOutParams*
+	thru    bool
+}
+
+// NewOutput kind: parameter update kind, ports: available out ports, see Names
+func NewOutput(kind msg.Kind, ports []drivers.Out, logger zerolog.Logger) *Output {
+	o := &Output{
+		kind:   kind,
+		ports:  ports,
+		logger: logger,
+		mode:   settings.MidiOutParamsNRPN,
+	}
+	o.resetSent()
+	return o
+}
+
+// Names port names, the settings.MidiOutPort setting is the index + 1
+func (o *Output) Names() []string {
+	names := make([]string, len(o.ports))
+	for i, p := range o.ports {
+		names[i] = p.String()
+	}
+	return names
+}
+
+// Map binds a parameter key to a control change or NRPN, replacing any previous binding
+func (o *Output) Map(key uint8, m OutputMapping) {
+	o.mappings[key] = &m
+}
+
+func (o *Output) HandleMessage(m msg.Message) {
+	switch m.Kind {
+	case o.kind:
+		o.values[m.Key], o.known[m.Key] = m.ValF, true
+		o.sendParam(m.Key)
+	case NoteOnKind, NoteOffKind, PitchBendKind, ControlChangeKind, ProgramChangeKind, BankSelectKind, ControlChange14Kind:
+		if o.thru {
+			if t := thruMessage(m); t != nil {
+				o.write(t)
+			}
+		}
+	case NrpnKind:
+		if o.thru {
+			o.writeNRPN(m.Chan, uint16(m.Val16), uint16(m.ValF*0x3fff+.5))
+		}
+	case settings.SettingUpdateKind:
+		switch m.Key {
+		case settings.MidiOutPort:
+			o.open(int(m.ValF) - 1)
+		case settings.MidiOutChannel:
+			o.channel = uint8(min(max(int(m.ValF), 1), 16) - 1)
+			o.resync()
+		case settings.MidiOutParams:
+			o.mode = int(m.ValF)
+			o.resync()
+		case settings.MidiThru:
+			o.thru = m.ValF != 0
+		}
+	}
+}
+
+// WriteSysEx sends a SysEx body (without F0 and F7), dropped without port.
+// Call it from the goroutine of the messenger.
+func (o *Output) WriteSysEx(body []byte) {
+	o.write(midi.SysEx(body))
+}
+
+// Close closes the port
+func (o *Output) Close() {
+	o.open(-1)
+}
+
+// open closes the current port and opens port i, none if out of range
+func (o *Output) open(i int) {
+	if o.port != nil {
+		if err := o.port.Close(); err != nil {
+			o.logger.Error().Err(err).Str("port", o.port.String()).Msg("failed to close port")
+		}
+		o.port, o.send = nil, nil
+	}
+
+	if i < 0 || i >= len(o.ports) {
+		return
+	}
+
+	send, err := midi.SendTo(o.ports[i])
+	if err != nil {
+		o.logger.Error().Err(err).Str("port", o.ports[i].String()).Msg("failed to open port")
+		return
+	}
+	o.port, o.send = o.ports[i], send
+	o.logger.Info().Str("port", o.port.String()).Msg("sending")
+	o.resync()
+}
+
+// resync sends all the known parameters, the controller may show anything
+func (o *Output) resync() {
+	o.resetSent()
+	for key := range o.known {
+		if o.known[key] {
+			o.sendParam(uint8(key))
+		}
+	}
+}
+
+func (o *Output) resetSent() {
+	for i := range o.sent {
+		o.sent[i] = -1
+	}
+}
+
+func (o *Output) sendParam(key uint8) {
+	mapping := o.mappings[key]
+	if o.send == nil || mapping == nil || o.mode == settings.MidiOutParamsOff {
+		return
+	}
+
+	n := min(max(mapping.Normalize(o.values[key]), 0), 1)
+	if mapping.CC != 0 {
+		v := int32(n*127 + .5)
+		if v != o.sent[key] {
+			o.sent[key] = v
+			o.write(midi.ControlChange(o.channel, mapping.CC, uint8(v)))
+		}
+		return
+	}
+
+	if o.mode != settings.MidiOutParamsNRPN {
+		return
+	}
+	v := int32(n*0x3fff + .5)
+	if v != o.sent[key] {
+		o.sent[key] = v
+		o.writeNRPN(o.channel, mapping.NRPN, uint16(v))
+	}
+}
+
+func (o *Output) writeNRPN(ch uint8, nrpn, val uint16) {
+	o.write(midi.ControlChange(ch, ccNrpnMsb, uint8(nrpn>>7&0x7f)))
+	o.write(midi.ControlChange(ch, ccNrpnLsb, uint8(nrpn&0x7f)))
+	o.write(midi.ControlChange(ch, ccDataEntryMsb, uint8(val>>7&0x7f)))
+	o.write(midi.ControlChange(ch, ccDataEntryLsb, uint8(val&0x7f)))
+}
+
+func (o *Output) write(m midi.Message) {
+	if o.send == nil {
+		return
+	}
+	if err := o.send(m); err != nil {
+		o.logger.Error().Err(err).Str("port", o.port.String()).Msg("failed to send")
+	}
+}
+
+// thruMessage MIDI message of an input message, nil if not forwarded
+func thruMessage(m msg.Message) midi.Message {
+	switch m.Kind {
+	case NoteOnKind:
+		return midi.NoteOn(m.Chan, m.Key, m.Val8)
+	case NoteOffKind:
+		return midi.NoteOff(m.Chan, m.Key)
+	case PitchBendKind:
+		return midi.Pitchbend(m.Chan, m.Val16)
+	case ControlChangeKind, BankSelectKind:
+		return midi.ControlChange(m.Chan, m.Key, m.Val8)
+	case ControlChange14Kind: // the MSB went through as a control change
+		return midi.ControlChange(m.Chan, m.Key+32, uint8(m.Val16&0x7f))
+	case ProgramChangeKind:
+		return midi.ProgramChange(m.Chan, m.Key)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package midi
+
+import (
+	"synth/msg"
+	"synth/settings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers/testdrv"
+)
+
+const updateKind msg.Kind = 20
+
+// newOutputFixture output on a test driver port looped back to its input,
+// returns the received messages
+func newOutputFixture(t *testing.T) (*Output, *[]midi.Message) {
+	drv := testdrv.New("test")
+	ins, _ := drv.Ins()
+	outs, _ := drv.Outs()
+
+	var received []midi.Message
+	stop, err := midi.ListenTo(ins[0], func(m midi.Message, _ int32) {
+		received = append(received, m)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+
+	o := NewOutput(updateKind, outs, zerolog.Nop())
+	o.Map(1, OutputMapping{CC: 74, Normalize: func(v float32) float32 { return v / 100 }})
+	o.Map(2, OutputMapping{NRPN: 130, Normalize: func(v float32) float32 { return v }})
+	t.Cleanup(o.Close)
+
+	return o, &received
+}
+
+func setting(key uint8, val float32) msg.Message {
+	return msg.Message{Kind: settings.SettingUpdateKind, Key: key, ValF: val}
+}
+
+func TestOutput_Params(t *testing.T) {
+	o, received := newOutputFixture(t)
+	if names := o.Names(); len(names) != 1 || names[0] != "test-out" {
+		t.Fatalf("expected the test port, got %v", names)
+	}
+
+	// Known before the port opens, sent on open
+	o.HandleMessage(msg.Message{Kind: updateKind, Key: 1, ValF: 50})
+	o.HandleMessage(setting(settings.MidiOutChannel, 3))
+	o.HandleMessage(setting(settings.MidiOutPort, 1))
+
+	o.HandleMessage(msg.Message{Kind: updateKind, Key: 1, ValF: 50}) // unchanged
+	o.HandleMessage(msg.Message{Kind: updateKind, Key: 2, ValF: 1})
+	o.HandleMessage(msg.Message{Kind: updateKind, Key: 3, ValF: 1}) // unmapped
+
+	exp := []midi.Message{
+		midi.ControlChange(2, 74, 64),
+		midi.ControlChange(2, 99, 1),
+		midi.ControlChange(2, 98, 2),
+		midi.ControlChange(2, 6, 127),
+		midi.ControlChange(2, 38, 127),
+	}
+	if len(*received) != len(exp) {
+		t.Fatalf("expected %v, got %v", exp, *received)
+	}
+	for i, m := range exp {
+		if string((*received)[i]) != string(m) {
+			t.Fatalf("message %d: expected %v, got %v", i, m, (*received)[i])
+		}
+	}
+
+	// CC only, then off
+	*received = nil
+	o.HandleMessage(setting(settings.MidiOutParams, settings.MidiOutParamsCC))
+	o.HandleMessage(msg.Message{Kind: updateKind, Key: 2, ValF: 0})
+	if len(*received) != 1 || string((*received)[0]) != string(midi.ControlChange(2, 74, 64)) {
+		t.Fatalf("expected the CC sent again only, got %v", *received)
+	}
+
+	*received = nil
+	o.HandleMessage(setting(settings.MidiOutParams, settings.MidiOutParamsOff))
+	o.HandleMessage(msg.Message{Kind: updateKind, Key: 1, ValF: 100})
+	if len(*received) != 0 {
+		t.Fatalf("expected nothing sent, got %v", *received)
+	}
+}
+
+func TestOutput_Thru(t *testing.T) {
+	o, received := newOutputFixture(t)
+	o.HandleMessage(setting(settings.MidiOutPort, 1))
+
+	note := msg.Message{Kind: NoteOnKind, Chan: 4, Key: 60, Val8: 99}
+	o.HandleMessage(note)
+	if len(*received) != 0 {
+		t.Fatalf("expected no thru by default, got %v", *received)
+	}
+
+	o.HandleMessage(setting(settings.MidiThru, 1))
+	o.HandleMessage(note)
+	o.HandleMessage(msg.Message{Kind: PitchBendKind, Chan: 4, Val16: -200})
+	o.HandleMessage(msg.Message{Kind: ProgramChangeKind, Chan: 4, Key: 7})
+	o.HandleMessage(msg.Message{Kind: ControlChange14Kind, Chan: 4, Key: 7, Val16: 100<<7 | 5})
+	o.HandleMessage(msg.Message{Kind: NrpnKind, Chan: 4, Val16: 130, ValF: 1})
+	exp := []midi.Message{
+		midi.NoteOn(4, 60, 99),
+		midi.Pitchbend(4, -200),
+		midi.ProgramChange(4, 7),
+		midi.ControlChange(4, 39, 5),
+		midi.ControlChange(4, 99, 1),
+		midi.ControlChange(4, 98, 2),
+		midi.ControlChange(4, 6, 127),
+		midi.ControlChange(4, 38, 127),
+	}
+	if len(*received) != len(exp) {
+		t.Fatalf("expected %v, got %v", exp, *received)
+	}
+	for i, m := range exp {
+		if string((*received)[i]) != string(m) {
+			t.Fatalf("message %d: expected %v, got %v", i, m, (*received)[i])
+		}
+	}
+}
@@ -5,20 +5,34 @@ import (
 	"synth/settings"
 )
 
+// Instrument ch is the 0 based MIDI channel of the message
 type Instrument interface {
-	NoteOn(int, float32)
-	NoteOff(int)
-	SetPitchBend(st float32)
+	NoteOnAt(ch uint8, key int, vel float32, offset int)
+	NoteOffAt(ch uint8, key int, offset int)
+	SetPitchBend(ch uint8, st float32)
 }
 
+// ProgramInstrument loads programs, banks are MSB*128 + LSB
+type ProgramInstrument interface {
+	LoadProgram(bank, program int)
+}
+
+// Player plays note messages on an instrument, at their sample offset
+// in the current block if the clock is set (see msg.Clock). Program changes
+// on the settings.ProgramChannel go to instruments implementing ProgramInstrument.
 type Player struct {
 	inst        Instrument
+	clock       *msg.Clock
 	pitchBendSt float32
+
+	programCh int // 0 omni, 1..16
+	bank      [2]uint8
 }
 
-func NewPlayer(inst Instrument) *Player {
+func NewPlayer(inst Instrument, clock *msg.Clock) *Player {
 	return &Player{
-		inst: inst,
+		inst:  inst,
+		clock: clock,
 	}
 }
 
@@ -26,18 +40,37 @@ func (p *Player) HandleMessage(m msg.Message) {
 	switch m.Kind {
 	case NoteOnKind:
 		// Todo handle vel properly with LUT (precalculated curve)
-		p.inst.NoteOn(int(m.Key), float32(m.Val8)/127)
+		p.inst.NoteOnAt(m.Chan, int(m.Key), float32(m.Val8)/127, p.clock.Offset(m.Frame))
 	case NoteOffKind:
-		p.inst.NoteOff(int(m.Key))
+		p.inst.NoteOffAt(m.Chan, int(m.Key), p.clock.Offset(m.Frame))
 	case PitchBendKind:
 		rel := float32(0)
 		if m.Val16 >= 128 || m.Val16 <= -128 {
 			rel = float32(m.Val16) / 8192.0 * p.pitchBendSt
 		}
-		p.inst.SetPitchBend(rel)
+		p.inst.SetPitchBend(m.Chan, rel)
+	case BankSelectKind:
+		if p.listens(m.Chan) {
+			if m.Key == BankSelectMsb {
+				p.bank[0] = m.Val8
+			} else {
+				p.bank[1] = m.Val8
+			}
+		}
+	case ProgramChangeKind:
+		if pi, ok := p.inst.(ProgramInstrument); ok && p.listens(m.Chan) {
+			pi.LoadProgram(int(p.bank[0])<<7|int(p.bank[1]), int(m.Key))
+		}
 	case settings.SettingUpdateKind:
-		if m.Key == settings.PitchBendRange {
+		switch m.Key {
+		case settings.PitchBendRange:
 			p.pitchBendSt = m.ValF
+		case settings.ProgramChannel:
+			p.programCh = int(m.ValF)
 		}
 	}
 }
+
+func (p *Player) listens(ch uint8) bool {
+	return p.programCh == 0 || int(ch)+1 == p.programCh
+}
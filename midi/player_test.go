@@ -0,0 +1,38 @@
+package midi
+
+import (
+	"synth/msg"
+	"synth/settings"
+	"testing"
+)
+
+type programs struct {
+	loaded [][2]int
+}
+
+func (p *programs) NoteOnAt(uint8, int, float32, int) {}
+func (p *programs) NoteOffAt(uint8, int, int)         {}
+func (p *programs) SetPitchBend(uint8, float32)       {}
+func (p *programs) LoadProgram(bank, program int) {
+	p.loaded = append(p.loaded, [2]int{bank, program})
+}
+
+func TestPlayer_ProgramChange(t *testing.T) {
+	inst := &programs{}
+	p := NewPlayer(inst, msg.NewClock(44100, 256, 0))
+	p.HandleMessage(msg.Message{Kind: settings.SettingUpdateKind, Key: settings.ProgramChannel, ValF: 2})
+
+	for _, m := range []msg.Message{
+		{Kind: BankSelectKind, Key: BankSelectMsb, Val8: 1, Chan: 1},
+		{Kind: BankSelectKind, Key: BankSelectLsb, Val8: 3, Chan: 1},
+		{Kind: BankSelectKind, Key: BankSelectLsb, Val8: 9, Chan: 0}, // other channel
+		{Kind: ProgramChangeKind, Key: 5, Chan: 0},                   // other channel
+		{Kind: ProgramChangeKind, Key: 7, Chan: 1},
+	} {
+		p.HandleMessage(m)
+	}
+
+	if len(inst.loaded) != 1 || inst.loaded[0] != [2]int{131, 7} {
+		t.Fatalf("expected bank 131 program 7 only, got %v", inst.loaded)
+	}
+}
@@ -0,0 +1,124 @@
+package midi
+
+import (
+	"synth/msg"
+	"synth/settings"
+)
+
+// Control changes of the registered and non-registered parameters
+const (
+	ccDataEntryMsb = 6
+	ccDataEntryLsb = 38
+	ccDataInc      = 96
+	ccDataDec      = 97
+	ccNrpnLsb      = 98
+	ccNrpnMsb      = 99
+	ccRpnLsb       = 100
+	ccRpnMsb       = 101
+)
+
+// Registered parameters applied to the settings
+const (
+	RpnPitchBendRange = 0
+	RpnFineTuning     = 1
+	RpnCoarseTuning   = 2
+	rpnNull           = 0x3fff
+)
+
+const (
+	paramNone = iota
+	paramRpn
+	paramNrpn
+)
+
+// ControlParser turns control changes into high resolution messages, with a
+// state per channel:
+//   - CC 0-31 followed by CC 32-63 (LSB) give a ControlChange14Kind
+//   - NRPN (CC 99/98 then data entry CC 6/38, increment/decrement CC 96/97)
+//     give a NrpnKind
+//   - RPN 0 (pitch bend range), 1 (fine tuning) and 2 (coarse tuning) give
+//     settings.SettingUpdateKind messages
+//
+// Parameter selection and data entry control changes are consumed, the
+// others go through as ControlChangeKind. Not safe for concurrent use, use
+// one per input port.
+type ControlParser struct {
+	channels [16]parserChannel
+}
+
+type parserChannel struct {
+	msb    [32]uint8 // last MSB of CC 0-31
+	msbSet [32]bool
+
+	kind      int    // selected parameter, see param*
+	rpn, nrpn uint16 // parameter numbers, 14 bits
+	data      uint16 // data entry value, 14 bits
+}
+
+// Parse returns the message of a control change, false if consumed without output
+func (p *ControlParser) Parse(ch, cc, val uint8) (msg.Message, bool) {
+	c := &p.channels[ch&15]
+
+	switch {
+	case cc == ccRpnMsb || cc == ccRpnLsb:
+		c.rpn = set7(c.rpn, cc == ccRpnMsb, val)
+		c.kind = paramRpn
+		if c.rpn == rpnNull {
+			c.kind = paramNone
+		}
+		return msg.Message{}, false
+	case cc == ccNrpnMsb || cc == ccNrpnLsb:
+		c.nrpn = set7(c.nrpn, cc == ccNrpnMsb, val)
+		c.kind = paramNrpn
+		return msg.Message{}, false
+	case cc == ccDataEntryMsb || cc == ccDataEntryLsb:
+		c.data = set7(c.data, cc == ccDataEntryMsb, val)
+		if cc == ccDataEntryMsb {
+			c.data &^= 0x7f // a new MSB resets the LSB
+		}
+		return c.entry(ch)
+	case cc == ccDataInc:
+		c.data = min(c.data+1, 0x3fff)
+		return c.entry(ch)
+	case cc == ccDataDec:
+		c.data -= min(c.data, 1)
+		return c.entry(ch)
+	case cc < 32:
+		c.msb[cc], c.msbSet[cc] = val, true
+	case cc > 32 && cc < 64 && c.msbSet[cc-32]:
+		v := uint16(c.msb[cc-32])<<7 | uint16(val)
+		return msg.Message{Kind: ControlChange14Kind, Chan: ch, Key: cc - 32, Val16: int16(v), ValF: float32(v) / 0x3fff}, true
+	}
+
+	return msg.Message{Kind: ControlChangeKind, Chan: ch, Key: cc, Val8: val}, true
+}
+
+// set7 sets the MSB or the LSB of a 14 bits value
+func set7(v uint16, msb bool, val uint8) uint16 {
+	if msb {
+		return v&0x7f | uint16(val&0x7f)<<7
+	}
+	return v&^0x7f | uint16(val&0x7f)
+}
+
+// entry applies the data entry value to the selected parameter
+func (c *parserChannel) entry(ch uint8) (msg.Message, bool) {
+	switch c.kind {
+	case paramNrpn:
+		return msg.Message{Kind: NrpnKind, Chan: ch, Val16: int16(c.nrpn), ValF: float32(c.data) / 0x3fff}, true
+	case paramRpn:
+		switch c.rpn {
+		case RpnPitchBendRange: // semitones, cents
+			return settingMessage(settings.PitchBendRange, float32(c.data>>7)+float32(c.data&0x7f)/100), true
+		case RpnFineTuning: // -100..+100 cents, centered at 0x2000
+			return settingMessage(settings.MasterFineTune, (float32(c.data)-0x2000)/0x2000*100), true
+		case RpnCoarseTuning: // semitones, centered at MSB 64
+			return settingMessage(settings.MasterTranspose, float32(int(c.data>>7)-64)), true
+		}
+	}
+	return msg.Message{}, false
+}
+
+func settingMessage(key uint8, val float32) msg.Message {
+	return msg.Message{Kind: settings.SettingUpdateKind, Key: key, ValF: val}
+}
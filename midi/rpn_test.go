@@ -0,0 +1,143 @@
+package midi
+
+import (
+	"synth/msg"
+	"synth/settings"
+	"testing"
+)
+
+// parse feeds control changes on a channel, returns the messages
+func parse(p *ControlParser, ch uint8, ccs ...uint8) []msg.Message {
+	var msgs []msg.Message
+	for i := 0; i+1 < len(ccs); i += 2 {
+		if m, ok := p.Parse(ch, ccs[i], ccs[i+1]); ok {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+func TestControlParser_RPN(t *testing.T) {
+	tests := []struct {
+		name string
+		ccs  []uint8
+		key  uint8
+		val  float32
+	}{
+		{"pitch bend range", []uint8{101, 0, 100, 0, 6, 12, 38, 50}, settings.PitchBendRange, 12.5},
+		{"fine tuning", []uint8{101, 0, 100, 1, 6, 0x60, 38, 0}, settings.MasterFineTune, 50},
+		{"coarse tuning", []uint8{101, 0, 100, 2, 6, 61}, settings.MasterTranspose, -3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := parse(&ControlParser{}, 0, tt.ccs...)
+			if len(msgs) == 0 {
+				t.Fatal("expected a setting update")
+			}
+			m := msgs[len(msgs)-1]
+			if m.Kind != settings.SettingUpdateKind || m.Key != tt.key || m.ValF != tt.val {
+				t.Fatalf("expected setting %d = %v, got %+v", tt.key, tt.val, m)
+			}
+		})
+	}
+}
+
+func TestControlParser_RPNNull(t *testing.T) {
+	p := &ControlParser{}
+	parse(p, 0, 101, 0, 100, 0, 6, 2)
+	if msgs := parse(p, 0, 101, 127, 100, 127, 6, 24, 96, 0); len(msgs) != 0 {
+		t.Fatalf("expected data entry ignored after the null RPN, got %v", msgs)
+	}
+}
+
+func TestControlParser_NRPN(t *testing.T) {
+	p := &ControlParser{}
+	msgs := parse(p, 3, 99, 1, 98, 2, 6, 127, 38, 127)
+	if len(msgs) != 2 {
+		t.Fatalf("expected the MSB and LSB entries, got %v", msgs)
+	}
+	m := msgs[1]
+	if m.Kind != NrpnKind || m.Chan != 3 || m.Val16 != 130 || m.ValF != 1 {
+		t.Fatalf("expected NRPN 130 at 1, got %+v", m)
+	}
+
+	// Increment stops at the maximum, decrement steps down from it
+	msgs = parse(p, 3, 96, 0, 97, 0)
+	if len(msgs) != 2 || msgs[0].ValF != 1 || msgs[1].ValF != float32(0x3ffe)/0x3fff {
+		t.Fatalf("expected increment clamped then decrement, got %v", msgs)
+	}
+
+	// A new MSB resets the LSB
+	msgs = parse(p, 3, 6, 64)
+	if len(msgs) != 1 || msgs[0].ValF != float32(0x2000)/0x3fff {
+		t.Fatalf("expected 0x2000, got %v", msgs)
+	}
+}
+
+func TestControlParser_14Bits(t *testing.T) {
+	p := &ControlParser{}
+
+	// LSB without MSB goes through
+	msgs := parse(p, 0, 39, 5)
+	if len(msgs) != 1 || msgs[0].Kind != ControlChangeKind || msgs[0].Key != 39 {
+		t.Fatalf("expected a plain CC 39, got %v", msgs)
+	}
+
+	msgs = parse(p, 0, 7, 100, 39, 5)
+	if len(msgs) != 2 || msgs[0].Kind != ControlChangeKind || msgs[0].Key != 7 || msgs[0].Val8 != 100 {
+		t.Fatalf("expected the MSB as a CC then the 14 bits value, got %v", msgs)
+	}
+	if m := msgs[1]; m.Kind != ControlChange14Kind || m.Key != 7 || m.Val16 != 100<<7|5 || m.ValF != float32(100<<7|5)/0x3fff {
+		t.Fatalf("expected CC 7 at %d, got %+v", 100<<7|5, m)
+	}
+
+	// State is per channel
+	msgs = parse(p, 1, 39, 5)
+	if len(msgs) != 1 || msgs[0].Kind != ControlChangeKind {
+		t.Fatalf("expected a plain CC on channel 1, got %v", msgs)
+	}
+}
+
+func TestControlParser_PerChannel(t *testing.T) {
+	p := &ControlParser{}
+	parse(p, 0, 99, 0, 98, 5)
+	if msgs := parse(p, 1, 6, 10); len(msgs) != 0 {
+		t.Fatalf("expected no parameter selected on channel 1, got %v", msgs)
+	}
+	if msgs := parse(p, 0, 6, 10); len(msgs) != 1 || msgs[0].Kind != NrpnKind || msgs[0].Val16 != 5 {
+		t.Fatalf("expected NRPN 5 on channel 0, got %v", msgs)
+	}
+}
+
+type updates []msg.Message
+
+func (u *updates) HandleMessage(m msg.Message) {
+	*u = append(*u, m)
+}
+
+func TestControlMapper_HighResolution(t *testing.T) {
+	var got updates
+	mapper := NewControlMapper(updateKind, &got, nil)
+	scale := func(v float32) float32 { return v * 100 }
+	mapper.Map(7, ControlMapping{Key: 1, Scale: scale})
+	mapper.MapNRPN(300, ControlMapping{Key: 2, Scale: scale})
+
+	mapper.HandleMessage(msg.Message{Kind: ControlChangeKind, Key: 7, Val8: 127})
+	mapper.HandleMessage(msg.Message{Kind: ControlChange14Kind, Key: 7, ValF: .5})
+	mapper.HandleMessage(msg.Message{Kind: NrpnKind, Val16: 300, ValF: .25})
+	mapper.HandleMessage(msg.Message{Kind: NrpnKind, Val16: 301, ValF: .25})
+
+	exp := []msg.Message{
+		{Kind: updateKind, Key: 1, ValF: 100},
+		{Kind: updateKind, Key: 1, ValF: 50},
+		{Kind: updateKind, Key: 2, ValF: 25},
+	}
+	if len(got) != len(exp) {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Fatalf("update %d: expected %+v, got %+v", i, exp[i], got[i])
+		}
+	}
+}
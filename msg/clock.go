@@ -0,0 +1,99 @@
+package msg
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock shares the audio position with message producers, so that they can
+// timestamp messages in sample frames (Message.Frame).
+//
+// Producers stamp with the wall time elapsed since the first block plus a
+// fixed latency, which must cover how far the audio thread renders ahead
+// (output buffer + a block). Events are then applied at a constant delay
+// instead of being rounded to block boundaries.
+type Clock struct {
+	sr        float64
+	blockSize uint64
+	latency   uint64
+	epoch     time.Time // monotonic reference
+
+	start uint64 // first frame of the current block, audio thread only
+
+	started atomic.Bool
+	t0      atomic.Int64 // ns since epoch at which frame 0 was rendered
+}
+
+// NewClock latency: in frames, ex: output buffer + 2 blocks, at least 2 blocks
+func NewClock(sr float64, blockSize, latency int) *Clock {
+	return &Clock{
+		sr:        sr,
+		blockSize: uint64(blockSize),
+		latency:   uint64(max(latency, 2*blockSize)),
+		epoch:     time.Now(),
+	}
+}
+
+// Tick marks the beginning of a new block, audio thread only
+func (c *Clock) Tick() {
+	now := int64(time.Since(c.epoch))
+	if !c.started.Load() {
+		c.t0.Store(now)
+		c.started.Store(true)
+		return
+	}
+	c.start += c.blockSize
+
+	// Keep the wall time estimate within what was rendered, absorbs
+	// underruns and the drift between the audio device and the wall clock
+	wall := c.framesAt(now)
+	lead := int64(c.start) - wall
+	if lead < 0 {
+		c.t0.Add(int64(c.duration(-lead)))
+	} else if maxLead := int64(c.latency - c.blockSize); lead > maxLead {
+		c.t0.Add(-int64(c.duration(lead - maxLead)))
+	}
+}
+
+// Stamp returns the frame at which a message sent now should be applied,
+// 0 (as soon as possible) if the clock is nil or not started yet
+func (c *Clock) Stamp() uint64 {
+	if c == nil || !c.started.Load() {
+		return 0
+	}
+
+	wall := c.framesAt(int64(time.Since(c.epoch)))
+	return uint64(max(0, wall)) + c.latency
+}
+
+// Due reports whether frame falls in the current block or before, audio thread only
+func (c *Clock) Due(frame uint64) bool {
+	return c == nil || frame < c.start+c.blockSize
+}
+
+// Start returns the first frame of the current block, 0 if the clock is nil.
+// Audio thread only.
+func (c *Clock) Start() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.start
+}
+
+// Offset returns the sample offset of frame in the current block,
+// 0 if the clock is nil, frame is 0 or late. Audio thread only.
+func (c *Clock) Offset(frame uint64) int {
+	if c == nil || frame <= c.start {
+		return 0
+	}
+
+	return int(min(frame-c.start, c.blockSize-1))
+}
+
+func (c *Clock) framesAt(ns int64) int64 {
+	return int64(float64(ns-c.t0.Load()) / float64(time.Second) * c.sr)
+}
+
+func (c *Clock) duration(frames int64) time.Duration {
+	return time.Duration(float64(frames) / c.sr * float64(time.Second))
+}
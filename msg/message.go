@@ -9,4 +9,7 @@ type Message struct {
 	Val8  uint8 // velocity, control value, ...
 	Val16 int16 // pitch bend, ...
 	ValF  float32
+
+	// Frame sample frame the message applies at, 0 = as soon as possible, see Clock
+	Frame uint64
 }
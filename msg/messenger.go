@@ -10,6 +10,9 @@ type Messenger struct {
 	in, out   *Queue
 	handlers  []Handler
 	drainRate int
+
+	clock   *Clock
+	pending []Message // timestamped messages waiting for their block
 }
 
 // NewMessenger creates a new Messenger
@@ -22,15 +25,55 @@ func NewMessenger(in, out *Queue, drainRate int) *Messenger {
 	}
 }
 
+// SetClock enables timestamped messages: a message is held until the block
+// containing its Frame, up to capacity messages. Process must then be called
+// once per block, after Clock.Tick.
+func (m *Messenger) SetClock(c *Clock, capacity int) {
+	m.clock = c
+	m.pending = make([]Message, 0, capacity)
+}
+
+// Clock returns the clock set with SetClock, nil if none
+func (m *Messenger) Clock() *Clock {
+	return m.clock
+}
+
 // Process drains incoming messages and dispatches them to registered handlers.
 func (m *Messenger) Process() {
+	if m.clock == nil {
+		m.in.Drain(m.drainRate, func(msg Message) {
+			m.dispatch(msg)
+		})
+		return
+	}
+
+	// Held messages first, they were received before
+	n := 0
+	for _, msg := range m.pending {
+		if m.clock.Due(msg.Frame) {
+			m.dispatch(msg)
+			continue
+		}
+		m.pending[n] = msg
+		n++
+	}
+	m.pending = m.pending[:n]
+
 	m.in.Drain(m.drainRate, func(msg Message) {
-		for _, h := range m.handlers {
-			h.HandleMessage(msg)
+		if m.clock.Due(msg.Frame) || len(m.pending) == cap(m.pending) {
+			m.dispatch(msg)
+			return
 		}
+		m.pending = append(m.pending, msg)
 	})
 }
 
+func (m *Messenger) dispatch(msg Message) {
+	for _, h := range m.handlers {
+		h.HandleMessage(msg)
+	}
+}
+
 // RegisterHandler registers a Handler function for a specific message kind.
 func (m *Messenger) RegisterHandler(h Handler) {
 	m.handlers = append(m.handlers, h)
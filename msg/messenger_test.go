@@ -0,0 +1,75 @@
+package msg
+
+import "testing"
+
+type recorder struct {
+	clock *Clock
+	got   []Message
+	at    []int
+}
+
+func (r *recorder) HandleMessage(m Message) {
+	r.got = append(r.got, m)
+	r.at = append(r.at, r.clock.Offset(m.Frame))
+}
+
+func TestMessenger_Timestamped(t *testing.T) {
+	const blockSize = 256
+
+	in := NewQueue(16)
+	clock := NewClock(44100, blockSize, 4*blockSize)
+	m := NewMessenger(in, NewQueue(2), 0)
+	m.SetClock(clock, 8)
+
+	rec := &recorder{clock: clock}
+	m.RegisterHandler(rec)
+
+	in.TryWrite(Message{Key: 1, Frame: 2*blockSize + 10}) // block 2
+	in.TryWrite(Message{Key: 2})                          // as soon as possible
+	in.TryWrite(Message{Key: 3, Frame: blockSize + 200})  // block 1
+
+	expected := [][]struct {
+		key    uint8
+		offset int
+	}{
+		{{2, 0}},
+		{{3, 200}},
+		{{1, 10}},
+		{},
+	}
+
+	for b, exp := range expected {
+		rec.got, rec.at = rec.got[:0], rec.at[:0]
+		clock.Tick()
+		m.Process()
+
+		if len(rec.got) != len(exp) {
+			t.Fatalf("block %d: expected %d messages, got %d", b, len(exp), len(rec.got))
+		}
+		for i, e := range exp {
+			if rec.got[i].Key != e.key || rec.at[i] != e.offset {
+				t.Errorf("block %d: expected key %d at %d, got key %d at %d", b, e.key, e.offset, rec.got[i].Key, rec.at[i])
+			}
+		}
+	}
+}
+
+func TestClock_Stamp(t *testing.T) {
+	const latency = 1024
+
+	var nilClock *Clock
+	if f := nilClock.Stamp(); f != 0 {
+		t.Errorf("expected nil clock to stamp 0, got %d", f)
+	}
+
+	clock := NewClock(44100, 256, latency)
+	if f := clock.Stamp(); f != 0 {
+		t.Errorf("expected stopped clock to stamp 0, got %d", f)
+	}
+
+	// Stamps land after the rendered blocks, so they can be scheduled
+	clock.Tick()
+	if f := clock.Stamp(); f < latency || clock.Due(f) {
+		t.Errorf("expected a stamp in a future block, got %d", f)
+	}
+}
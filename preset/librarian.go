@@ -0,0 +1,219 @@
+package preset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"synth/msg"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
+)
+
+// SysExWriter sends SysEx bodies, see midi.Output
+type SysExWriter interface {
+	WriteSysEx(body []byte)
+}
+
+// Librarian exchanges presets as SysEx, see ParseSysEx. Dump requests
+// are sent to the Manager as DumpPresetKind messages, it copies the presets to
+// snapshots on the audio thread, marshalled and sent as preset dumps by
+// Process. Received preset dumps are imported in the import directory, a bank
+// listed from the next start.
+//
+// Receive may be called from any goroutine, snapshot and dump from the audio
+// thread, Process from the goroutine of the SysEx writer.
+type Librarian struct {
+	dir       string
+	messenger *msg.Messenger
+	logger    zerolog.Logger
+	received  chan []byte
+	free      chan *presetSnapshot
+	dumps     chan *presetSnapshot
+}
+
+// presetSnapshot values of a preset, copied without allocation
+type presetSnapshot struct {
+	name     string
+	params   [256]float32
+	known    [256]bool
+	modSlots [ModSlots]ModSlot
+	macros   [Macros][MacroTargets]MacroTarget
+}
+
+// snapshots presets being dumped at once
+const snapshots = 4
+
+// NewLibrarian dir: import directory, messenger: sends the dump requests
+func NewLibrarian(dir string, messenger *msg.Messenger, logger zerolog.Logger) *Librarian {
+	l := &Librarian{
+		dir:       dir,
+		messenger: messenger,
+		logger:    logger,
+		received:  make(chan []byte, 16),
+		free:      make(chan *presetSnapshot, snapshots),
+		dumps:     make(chan *presetSnapshot, snapshots),
+	}
+	for range snapshots {
+		l.free <- &presetSnapshot{}
+	}
+	return l
+}
+
+// Receive queues a SysEx body received from a MIDI input, see midi.Listener.HandleSysEx
+func (l *Librarian) Receive(body []byte) {
+	select {
+	case l.received <- body:
+	default:
+		l.logger.Warn().Msg("sysex queue full, message dropped")
+	}
+}
+
+// RequestDump asks the Manager for the current preset, bank < 0, or the
+// presets of a bank
+func (l *Librarian) RequestDump(bank int) {
+	if bank < 0 {
+		l.messenger.SendMessage(msg.Message{Kind: DumpPresetKind})
+		return
+	}
+	l.messenger.SendMessage(msg.Message{Kind: DumpPresetKind, Key: uint8(bank), ValF: 1})
+}
+
+// Process handles the received SysEx and sends the dumped presets to out
+func (l *Librarian) Process(out SysExWriter) {
+	for {
+		select {
+		case body := <-l.received:
+			l.handle(body)
+		case s := <-l.dumps:
+			raw, err := proto.Marshal(s.preset().ToProto())
+			l.free <- s
+			if err != nil {
+				l.logger.Error().Err(err).Msg("failed to marshal preset")
+				continue
+			}
+			out.WriteSysEx(PresetDumpSysEx(raw))
+		default:
+			return
+		}
+	}
+}
+
+// Import sanitizes a marshalled preset and writes it to the import directory,
+// named after the preset, without replacing a file. Returns the file written.
+func (l *Librarian) Import(raw []byte) (string, error) {
+	prt := &ProtoPreset{}
+	if err := proto.Unmarshal(raw, prt); err != nil {
+		return "", err
+	}
+	if err := ValidateProto(prt); err != nil {
+		l.logger.Warn().Err(err).Str("preset", prt.Name).Msg("invalid preset values, sanitized")
+	}
+
+	preset := NewPresetFromProto(prt)
+	out, err := proto.Marshal(preset.ToProto())
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return "", err
+	}
+	file, err := l.freeFile(preset.Name)
+	if err != nil {
+		return "", err
+	}
+	return file, os.WriteFile(file, out, 0644)
+}
+
+// snapshot a free snapshot for the Manager, nil while all are being dumped
+func (l *Librarian) snapshot() *presetSnapshot {
+	select {
+	case s := <-l.free:
+		return s
+	default:
+		return nil
+	}
+}
+
+// dump queues a snapshot filled by the Manager, never blocks: at most
+// snapshots are taken
+func (l *Librarian) dump(s *presetSnapshot) {
+	l.dumps <- s
+}
+
+// preset of the snapshot values
+func (s *presetSnapshot) preset() *Preset {
+	p := NewPreset()
+	p.Name = s.name
+	for id, v := range s.params {
+		if param, ok := p.Params[uint8(id)]; ok && s.known[id] {
+			param.SetBase(v)
+		}
+	}
+	for i := range s.modSlots {
+		slot := s.modSlots[i]
+		p.ModSlots[i] = &slot
+	}
+	for i := range s.macros {
+		for j := range s.macros[i] {
+			t := s.macros[i][j]
+			p.Macros[i].Targets[j] = &t
+		}
+	}
+	return p
+}
+
+func (l *Librarian) handle(body []byte) {
+	cmd, data, err := ParseSysEx(body)
+	if errors.Is(err, ErrSysExForeign) {
+		return
+	}
+	if err != nil {
+		l.logger.Warn().Err(err).Msg("failed to parse sysex")
+		return
+	}
+
+	switch cmd {
+	case SysExDumpRequest:
+		if data[0] == SysExCurrentPreset {
+			l.RequestDump(-1)
+		} else {
+			l.RequestDump(int(data[0]))
+		}
+	case SysExPresetDump:
+		file, err := l.Import(data)
+		if err != nil {
+			l.logger.Error().Err(err).Msg("failed to import preset")
+			return
+		}
+		l.logger.Info().Str("file", file).Msg("preset imported")
+	}
+}
+
+// freeFile path of a preset file named after name in the import directory,
+// numbered when taken
+func (l *Librarian) freeFile(name string) (string, error) {
+	base := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if base == "" {
+		base = "sysex"
+	}
+
+	for i := 1; i < 1000; i++ {
+		file := filepath.Join(l.dir, base+".preset")
+		if i > 1 {
+			file = filepath.Join(l.dir, fmt.Sprintf("%s %d.preset", base, i))
+		}
+		if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("no free file name for %q", name)
+}
@@ -0,0 +1,95 @@
+package preset
+
+import (
+	"os"
+	"path/filepath"
+	"synth/dsp"
+	"synth/msg"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+type sysExRecorder [][]byte
+
+func (r *sysExRecorder) WriteSysEx(body []byte) {
+	*r = append(*r, body)
+}
+
+func TestLibrarian_DumpAndImport(t *testing.T) {
+	m := newPartsManager(t, 3)
+	requests := msg.NewQueue(8)
+	lib := NewLibrarian(t.TempDir(), msg.NewMessenger(nil, requests, 0), zerolog.Nop())
+	m.SetLibrarian(lib)
+
+	// Requests of the current preset and of bank 0 reach the Manager
+	lib.Receive(DumpRequestSysEx(SysExCurrentPreset))
+	lib.Receive(DumpRequestSysEx(0))
+	lib.Receive([]byte{0x43, 0x10}) // another device
+	var out sysExRecorder
+	lib.Process(&out)
+	requests.Drain(0, m.HandleMessage)
+
+	// One preset per block, marshalled by Process, the current preset is
+	// part of the bank and dumped once
+	var block dsp.Block
+	for i := 1; i <= 4; i++ {
+		m.Process(&block)
+		lib.Process(&out)
+		if exp := min(i, 3); len(out) != exp {
+			t.Fatalf("block %d: expected %d preset dumps, got %d", i, exp, len(out))
+		}
+	}
+	if size := len(out[0]) + 2; size > 16*1024 {
+		t.Fatalf("dump of %d bytes over the listener sysex buffer", size)
+	}
+
+	// Dumps received back are imported, without replacing a file
+	for _, body := range out[:2] {
+		lib.Receive(body)
+	}
+	lib.Process(&out)
+
+	for _, f := range []string{"0.preset", "0 2.preset"} {
+		raw, err := os.ReadFile(filepath.Join(lib.dir, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := lib.Import(raw); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+}
+
+func TestLibrarian_ImportName(t *testing.T) {
+	lib := NewLibrarian(t.TempDir(), nil, zerolog.Nop())
+	file, err := lib.freeFile(" a/b: ")
+	if err != nil || filepath.Base(file) != "a_b_.preset" {
+		t.Fatalf("expected a sanitized name, got %q %v", file, err)
+	}
+}
+
+func TestLibrarian_DumpNoAlloc(t *testing.T) {
+	m := newPartsManager(t, 3)
+	lib := NewLibrarian(t.TempDir(), nil, zerolog.Nop())
+	m.SetLibrarian(lib)
+	m.HandleMessage(msg.Message{Kind: UpdateParameterKind, Key: LPFCutoff, ValF: 1234})
+	m.HandleMessage(msg.Message{Kind: DumpPresetKind, Key: 0, ValF: 1})
+
+	var block dsp.Block
+	allocs := testing.AllocsPerRun(2, func() {
+		m.Process(&block)
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations, got %v", allocs)
+	}
+	if len(lib.dumps) != 3 {
+		t.Fatalf("expected 3 snapshots, got %d", len(lib.dumps))
+	}
+
+	// Unsaved edits are dumped
+	p := (<-lib.dumps).preset()
+	if p.Name != "0" || p.Params[LPFCutoff].GetBase() != 1234 {
+		t.Fatalf("expected the edited preset 0 first, got %q with cutoff %g", p.Name, p.Params[LPFCutoff].GetBase())
+	}
+}
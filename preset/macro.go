@@ -0,0 +1,74 @@
+package preset
+
+import (
+	"math"
+	"synth/dsp"
+)
+
+type Macro struct {
+	Targets [MacroTargets]*MacroTarget
+}
+
+// MacroTarget offsets its destination following the macro value: Min at
+// macro 0, Max at macro 1, along Curve. Min and Max are relative to the
+// destination range, so they keep their meaning whatever the destination.
+type MacroTarget struct {
+	Destination uint8
+	Min         float32
+	Max         float32
+	Curve       uint8
+	ModInput    dsp.ParamModInput
+
+	span float32 // destination range
+}
+
+func newMacro() *Macro {
+	m := &Macro{}
+	for i := range m.Targets {
+		m.Targets[i] = &MacroTarget{
+			Destination: ParamNone,
+			Min:         0,
+			Max:         1,
+			Curve:       ModShapeLinear,
+		}
+	}
+	return m
+}
+
+// newMacroTarget creates a target fed by the given macro value
+func newMacroTarget(macro dsp.ParamModulator) *MacroTarget {
+	t := &MacroTarget{
+		Destination: ParamNone,
+		Max:         1,
+		Curve:       ModShapeLinear,
+	}
+	t.ModInput = dsp.NewModInput(macro, dsp.NewConstParam(1), t.offset)
+
+	return t
+}
+
+func (t *MacroTarget) setDestination(dst uint8) {
+	t.Destination = dst
+	t.span = 0
+	if meta := GetParamMeta(dst); meta != nil {
+		t.span = meta.Max - meta.Min
+	}
+}
+
+func (t *MacroTarget) offset(x float32) float32 {
+	return (t.Min + (t.Max-t.Min)*applyModShape(t.Curve, x)) * t.span
+}
+
+// applyModShape bends x, clamped to 0..1, following the given ModShape*
+func applyModShape(shape uint8, x float32) float32 {
+	x = max(0, min(1, x))
+
+	switch shape {
+	case ModShapeExponential:
+		return x * x
+	case ModShapeLogarithmic:
+		return float32(math.Sqrt(float64(x)))
+	default:
+		return x
+	}
+}
@@ -1,6 +1,7 @@
 package preset
 
 import (
+	"cmp"
 	"os"
 	"path"
 	"path/filepath"
@@ -15,20 +16,43 @@ import (
 )
 
 type presetVoice struct {
-	preset *Preset
-	voice  *Polysynth
-	file   string
+	preset    *Preset
+	voice     *Polysynth
+	file      string
+	bank      int
+	gain, pan dsp.Param // set by the parts, see Manager.applyMix
+	level     float32   // part volume, balance compensated
+	fade      float32   // preset switch crossfade, 0..1
+	fadeDir   int       // -1 fading out, +1 fading in
 }
 
-// todo because our goal is to be able to play multiple presets at once,
-// we should have a way to unload presets without affecting others.
+// presetBank presets of a subdirectory, by name
+type presetBank struct {
+	name   string
+	voices []int
+}
+
+// Manager plays the presets of the parts, several presets at once split or
+// layered by MIDI channel, key and velocity. Parameter updates go to the
+// edited (current) preset, played by the first part.
+//
+// todo we should have a way to unload presets without affecting others.
 type Manager struct {
 	*dsp.Mixer
 	messenger *msg.Messenger
 	current   int
 	voices    []*presetVoice
+	banks     []presetBank
+	parts     [settings.MaxParts]part
 	logger    zerolog.Logger
 	settings  map[uint8]dsp.Param
+	librarian *Librarian // nil without SysEx dumps
+	dumps     []int      // presets to snapshot for the librarian, see dumpNext
+
+	// Preset switch, see Switch*
+	switchMode int
+	switchTime float32 // seconds, crossfade
+	sr         float64
 }
 
 func NewManager(sr float64, logger zerolog.Logger, messenger *msg.Messenger, path string) *Manager {
@@ -40,7 +64,15 @@ func NewManager(sr float64, logger zerolog.Logger, messenger *msg.Messenger, pat
 		messenger: messenger,
 		logger:    logger,
 		settings:  sets,
+
+		switchMode: SwitchRingOut,
+		switchTime: .5,
+		sr:         sr,
+	}
+	for i := range m.parts {
+		m.parts[i] = newPart(i)
 	}
+	m.parts[0].enabled = true
 
 	m.buildFromPath(sr, path)
 	m.loadPreset(0) // force publish
@@ -48,30 +80,120 @@ func NewManager(sr float64, logger zerolog.Logger, messenger *msg.Messenger, pat
 	return m
 }
 
+// Profile measures the nodes of every preset, see Polysynth.Profile
+func (m *Manager) Profile(pr *dsp.Profiler) {
+	for _, v := range m.voices {
+		v.voice.Profile(pr)
+	}
+}
+
+// SetRenderer renders the voices of every preset in parallel, nil to go back to serial
+func (m *Manager) SetRenderer(r *dsp.ParallelRenderer) {
+	for _, v := range m.voices {
+		v.voice.SetRenderer(r)
+	}
+}
+
+// SetLibrarian sends the presets dumped on DumpPresetKind to the librarian
+func (m *Manager) SetLibrarian(l *Librarian) {
+	m.librarian = l
+	m.dumps = make([]int, 0, len(m.voices))
+}
+
+// Process snapshots the next preset to dump, advances the preset switch
+// crossfades then mixes the presets
+func (m *Manager) Process(b *dsp.Block) {
+	m.dumpNext()
+	m.advanceFades()
+	m.Mixer.Process(b)
+}
+
+// NoteOn plays the key on the first MIDI channel
 func (m *Manager) NoteOn(key int, vel float32) {
-	m.voices[m.current].voice.NoteOn(key, vel)
+	m.NoteOnAt(0, key, vel, 0)
 }
 
 func (m *Manager) NoteOff(key int) {
-	m.voices[m.current].voice.NoteOff(key)
+	m.NoteOffAt(0, key, 0)
+}
+
+// NoteOnAt starts the note on every part accepting it, ch is the 0 based MIDI channel
+func (m *Manager) NoteOnAt(ch uint8, key int, vel float32, offset int) {
+	if key < 0 || key > 127 {
+		return
+	}
+
+	ch &= 15
+	for i := range m.parts {
+		p := &m.parts[i]
+		if !p.accepts(ch, key, vel) {
+			continue
+		}
+
+		k := min(max(key+p.transpose, 0), 127)
+		v := m.partVoice(i)
+		p.held[ch][key] = heldNote{key: uint8(k + 1), voice: int16(v)}
+		m.voices[v].voice.NoteOnAt(k, vel, offset)
+	}
+}
+
+// NoteOffAt releases the note on the parts it was started on
+func (m *Manager) NoteOffAt(ch uint8, key int, offset int) {
+	if key < 0 || key > 127 {
+		return
+	}
+
+	ch &= 15
+	for i := range m.parts {
+		p := &m.parts[i]
+		if h := p.held[ch][key]; h.key != 0 {
+			p.held[ch][key] = heldNote{}
+			m.voices[h.voice].voice.NoteOffAt(int(h.key)-1, offset)
+		}
+	}
 }
 
-func (m *Manager) SetPitchBend(st float32) {
-	m.voices[m.current].voice.SetPitchBend(st)
+// SetPitchBend bends the parts listening to the channel
+func (m *Manager) SetPitchBend(ch uint8, st float32) {
+	ch &= 15
+	for i := range m.parts {
+		p := &m.parts[i]
+		if p.enabled && (p.channel == 0 || int(ch)+1 == p.channel) {
+			m.voices[m.partVoice(i)].voice.SetPitchBend(st)
+		}
+	}
 }
 
+// GetPresets names of the presets by index, prefixed by their bank directory
 func (m *Manager) GetPresets() []string {
 	names := make([]string, len(m.voices))
 	for i, v := range m.voices {
 		names[i] = v.preset.Name
+		if v.bank > 0 {
+			names[i] = m.banks[v.bank].name + "/" + v.preset.Name
+		}
 	}
 	return names
 }
 
+// Param base value of a parameter of the current preset, 0 if unknown
+func (m *Manager) Param(id uint8) float32 {
+	return m.voices[m.current].voice.Param(id)
+}
+
+// LoadProgram loads the preset of the bank at the program index, ignored out of range
+func (m *Manager) LoadProgram(bank, program int) {
+	if bank < 0 || bank >= len(m.banks) || program < 0 || program >= len(m.banks[bank].voices) {
+		return
+	}
+	m.loadPreset(m.banks[bank].voices[program])
+}
+
 func (m *Manager) HandleMessage(msg msg.Message) {
 	switch msg.Kind {
 	case UpdateParameterKind:
-		m.voices[m.current].voice.SetParam(msg.Key, msg.ValF)
+		offset := m.messenger.Clock().Offset(msg.Frame)
+		m.voices[m.current].voice.SetParamAt(msg.Key, msg.ValF, offset)
 	case LoadSavePresetKind:
 		p := int(msg.Key)
 		if msg.ValF == 0 {
@@ -85,9 +207,39 @@ func (m *Manager) HandleMessage(msg msg.Message) {
 			msg.Key%ModKeysSpacing,
 			msg.ValF,
 		)
+	case MacroUpdateKind:
+		m.voices[m.current].voice.UpdateMacro(
+			int(msg.Key/MacroKeysSpacing),
+			int(msg.Key%MacroKeysSpacing/MacroTargetSpacing),
+			msg.Key%MacroTargetSpacing,
+			msg.ValF,
+		)
+	case DumpPresetKind:
+		if msg.ValF == 0 {
+			m.queueDump(m.current)
+		} else if int(msg.Key) < len(m.banks) {
+			for _, p := range m.banks[msg.Key].voices {
+				m.queueDump(p)
+			}
+		}
+	case PresetStepKind:
+		if msg.ValF > 0 {
+			m.step(1)
+		} else if msg.ValF < 0 {
+			m.step(-1)
+		}
 	case settings.SettingUpdateKind:
-		if param, ok := m.settings[msg.Key]; ok {
-			param.SetBase(msg.ValF)
+		switch {
+		case msg.Key >= settings.PartKeys:
+			m.setPart(msg.Key, msg.ValF)
+		case msg.Key == settings.PresetSwitch:
+			m.switchMode = int(msg.ValF)
+		case msg.Key == settings.PresetSwitchTime:
+			m.switchTime = msg.ValF
+		default:
+			if param, ok := m.settings[msg.Key]; ok {
+				param.SetBase(msg.ValF)
+			}
 		}
 	}
 }
@@ -97,12 +249,15 @@ func (m *Manager) loadPreset(p int) {
 		return
 	}
 
-	if m.current != p {
-		m.voices[m.current].voice.AllNotesOff()
-	}
-
 	m.voices[p].voice.LoadPreset(m.voices[p].preset) // reload preset
+	from := m.current
 	m.current = p
+	if m.parts[0].enabled {
+		m.switchVoice(from, p)
+	}
+	m.applyMix()
+
+	m.messenger.SendMessage(msg.Message{Kind: PresetLoadedKind, ValF: float32(p)})
 
 	// publish all parameters
 	for key, param := range m.voices[p].preset.Params {
@@ -136,6 +291,17 @@ func (m *Manager) loadPreset(p int) {
 			ValF: float32(slot.Shape),
 		})
 	}
+
+	// publish macro targets
+	for i, macro := range m.voices[p].preset.Macros {
+		for j, t := range macro.Targets {
+			key := uint8(MacroKeysSpacing*i + MacroTargetSpacing*j)
+			m.messenger.SendMessage(msg.Message{Kind: MacroUpdateKind, Key: key + MacroParamDst, ValF: float32(t.Destination)})
+			m.messenger.SendMessage(msg.Message{Kind: MacroUpdateKind, Key: key + MacroParamMin, ValF: t.Min})
+			m.messenger.SendMessage(msg.Message{Kind: MacroUpdateKind, Key: key + MacroParamMax, ValF: t.Max})
+			m.messenger.SendMessage(msg.Message{Kind: MacroUpdateKind, Key: key + MacroParamCrv, ValF: float32(t.Curve)})
+		}
+	}
 }
 
 func (m *Manager) savePreset(p int) {
@@ -169,55 +335,109 @@ func (m *Manager) savePreset(p int) {
 	logger.Info().Msg("preset saved")
 }
 
-func (m *Manager) buildFromPath(sr float64, pth string) {
-	files, err := filepath.Glob(filepath.Join(pth, "*.preset"))
-	if err != nil {
-		m.logger.Error().Err(err).Msg("failed to glob preset files")
+// queueDump queues a preset to snapshot, ignored when already queued
+func (m *Manager) queueDump(p int) {
+	if m.librarian == nil || slices.Contains(m.dumps, p) || len(m.dumps) == cap(m.dumps) {
 		return
 	}
+	m.dumps = append(m.dumps, p)
+}
 
-	for _, f := range files {
-		raw, err := os.ReadFile(f)
-		if err != nil {
-			m.logger.Error().Err(err).Str("file", f).Msg("failed to read preset file")
-			continue
+// dumpNext copies the next queued preset, with its unsaved edits, to a
+// snapshot of the librarian which marshals it. One preset per block at most,
+// retried while the librarian has no free snapshot.
+func (m *Manager) dumpNext() {
+	if len(m.dumps) == 0 {
+		return
+	}
+	s := m.librarian.snapshot()
+	if s == nil {
+		return
+	}
+
+	p := m.dumps[0]
+	m.dumps = append(m.dumps[:0], m.dumps[1:]...)
+	s.name = m.voices[p].preset.Name
+	m.voices[p].voice.snapshot(s)
+	m.librarian.dump(s)
+}
+
+// buildFromPath loads the presets of pth as bank 0 and the presets of its
+// subdirectories as the next banks, in name order
+func (m *Manager) buildFromPath(sr float64, pth string) {
+	m.banks = []presetBank{{}}
+	dirs := []string{pth}
+	if entries, err := os.ReadDir(pth); err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				dirs = append(dirs, filepath.Join(pth, e.Name()))
+				m.banks = append(m.banks, presetBank{name: e.Name()})
+			}
 		}
+	}
 
-		prt := &ProtoPreset{}
-		err = proto.Unmarshal(raw, prt)
+	for bank, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.preset"))
 		if err != nil {
-			m.logger.Error().Err(err).Str("file", f).Msg("failed to unmarshal preset file")
+			m.logger.Error().Err(err).Msg("failed to glob preset files")
 			continue
 		}
 
-		preset := NewPresetFromProto(prt)
-		m.addVoice(preset, sr, f)
+		for _, f := range files {
+			raw, err := os.ReadFile(f)
+			if err != nil {
+				m.logger.Error().Err(err).Str("file", f).Msg("failed to read preset file")
+				continue
+			}
+
+			prt := &ProtoPreset{}
+			err = proto.Unmarshal(raw, prt)
+			if err != nil {
+				m.logger.Error().Err(err).Str("file", f).Msg("failed to unmarshal preset file")
+				continue
+			}
+
+			if err := ValidateProto(prt); err != nil {
+				m.logger.Warn().Err(err).Str("file", f).Msg("invalid preset values, sanitized")
+			}
+
+			preset := NewPresetFromProto(prt)
+			m.addVoice(preset, sr, f, bank)
+
+			m.logger.Info().
+				Str("preset", preset.Name).
+				Str("file", f).
+				Int("bank", bank).
+				Msg("preset loaded")
+		}
+	}
 
-		m.logger.Info().
-			Str("preset", preset.Name).
-			Str("file", f).
-			Msg("preset loaded")
+	if len(m.voices) == 0 {
+		m.logger.Warn().Msg("no presets loaded, creating a default one")
+		m.addVoice(NewPreset(), sr, path.Join(pth, "default.preset"), 0)
 	}
 
 	slices.SortFunc(m.voices, func(a, b *presetVoice) int {
-		return strings.Compare(a.preset.Name, b.preset.Name)
+		return cmp.Or(cmp.Compare(a.bank, b.bank), strings.Compare(a.preset.Name, b.preset.Name))
 	})
 
-	if len(m.voices) > 0 {
-		return
+	for i, v := range m.voices {
+		m.banks[v.bank].voices = append(m.banks[v.bank].voices, i)
 	}
-
-	m.logger.Warn().Msg("no presets loaded, creating a default one")
-	m.addVoice(NewPreset(), sr, path.Join(pth, "default.preset"))
 }
 
-func (m *Manager) addVoice(preset *Preset, sr float64, file string) {
+func (m *Manager) addVoice(preset *Preset, sr float64, file string, bank int) {
 	voice := &presetVoice{
 		preset: preset,
 		voice:  NewPolysynth(sr),
 		file:   file,
+		bank:   bank,
+		gain:   dsp.NewSmoothedParam(sr, balanceGain(1, 0), dsp.NewConstParam(0.01)),
+		pan:    dsp.NewSmoothedParam(sr, 0, dsp.NewConstParam(0.01)),
+		level:  balanceGain(1, 0),
+		fade:   1,
 	}
 	voice.voice.LoadPreset(preset)
-	m.Mixer.Add(dsp.NewInput(voice.voice, nil, nil))
+	m.Mixer.Add(dsp.NewInput(voice.voice, voice.gain, voice.pan))
 	m.voices = append(m.voices, voice)
 }
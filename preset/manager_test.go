@@ -1,11 +1,18 @@
 package preset
 
 import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"synth/dsp"
 	"synth/msg"
+	"synth/settings"
 	"testing"
 
 	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestManager_ProcessNoAlloc(t *testing.T) {
@@ -33,3 +40,225 @@ func TestManager_ProcessNoAlloc(t *testing.T) {
 		t.Errorf("expected 0 allocations, got %v", allocs)
 	}
 }
+
+// writePresets n default presets named "0".."n-1" in dir
+func writePresets(t *testing.T, dir string, n int) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range n {
+		p := NewPreset()
+		p.Name = strconv.Itoa(i)
+		raw, err := proto.Marshal(p.ToProto())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, p.Name+".preset"), raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newPartsManager manager of n default presets, named "0".."n-1"
+func newPartsManager(t *testing.T, n int) *Manager {
+	dir := t.TempDir()
+	writePresets(t, dir, n)
+
+	messenger := msg.NewMessenger(msg.NewQueue(1), msg.NewQueue(1024), 0)
+	return NewManager(44100, zerolog.Nop(), messenger, dir)
+}
+
+func setPart(m *Manager, part, param int, val float32) {
+	m.HandleMessage(msg.Message{Kind: settings.SettingUpdateKind, Key: settings.PartKey(part, param), ValF: val})
+}
+
+func playing(m *Manager) []bool {
+	res := make([]bool, len(m.voices))
+	for i, v := range m.voices {
+		res[i] = !v.voice.IsSilent()
+	}
+	return res
+}
+
+func TestManager_PartsSplit(t *testing.T) {
+	m := newPartsManager(t, 2)
+	setPart(m, 0, settings.PartKeyHigh, 59)
+	setPart(m, 1, settings.PartEnabled, 1)
+	setPart(m, 1, settings.PartPreset, 1)
+	setPart(m, 1, settings.PartKeyLow, 60)
+
+	m.NoteOnAt(0, 48, 1, 0)
+	if p := playing(m); !p[0] || p[1] {
+		t.Fatalf("expected the low key on the first preset only, got %v", p)
+	}
+
+	m.NoteOnAt(0, 72, 1, 0)
+	if held := m.parts[1].held[0][72].key; held != 73 {
+		t.Fatalf("expected the high key held by the second part, got %d", held)
+	}
+	if held := m.parts[0].held[0][72].key; held != 0 {
+		t.Fatalf("expected the high key out of the first part, got %d", held)
+	}
+}
+
+func TestManager_PartsLayerChannelTranspose(t *testing.T) {
+	m := newPartsManager(t, 2)
+	setPart(m, 1, settings.PartEnabled, 1)
+	setPart(m, 1, settings.PartPreset, 1)
+	setPart(m, 1, settings.PartChannel, 2)
+	setPart(m, 1, settings.PartTranspose, -12)
+
+	// Channel 1 only reaches the omni part
+	m.NoteOnAt(0, 60, 1, 0)
+	if m.parts[0].held[0][60].key != 61 || m.parts[1].held[0][60].key != 0 {
+		t.Fatal("expected channel 1 on the first part only")
+	}
+
+	// Channel 2 is layered, transposed on the second part
+	m.NoteOnAt(1, 60, 1, 0)
+	if m.parts[0].held[1][60].key != 61 || m.parts[1].held[1][60].key != 49 {
+		t.Fatalf("expected channel 2 layered, got %d and %d", m.parts[0].held[1][60].key, m.parts[1].held[1][60].key)
+	}
+
+	// Note offs follow their note ons, whatever the filters became
+	setPart(m, 1, settings.PartChannel, 3)
+	m.NoteOffAt(1, 60, 0)
+	if m.parts[1].held[1][60].key != 0 {
+		t.Fatal("expected the note off to reach the second part")
+	}
+}
+
+func TestManager_PartsVelocityRange(t *testing.T) {
+	m := newPartsManager(t, 1)
+	setPart(m, 0, settings.PartVelLow, 64)
+
+	m.NoteOnAt(0, 60, .2, 0)
+	m.NoteOnAt(0, 61, .8, 0)
+
+	if m.parts[0].held[0][60].key != 0 || m.parts[0].held[0][61].key == 0 {
+		t.Fatal("expected only the loud note to play")
+	}
+
+	// UI keys velocity is out of the MIDI range
+	m.NoteOnAt(0, 62, 2, 0)
+	if m.parts[0].held[0][62].key == 0 {
+		t.Fatal("expected the UI note to play")
+	}
+}
+
+func TestManager_PartsBalance(t *testing.T) {
+	for _, test := range []struct {
+		pan, expL, expR float32
+	}{
+		{0, 1, 1},
+		{-1, 1, 0},
+		{.5, .5, 1},
+	} {
+		g, p := balanceGain(1, test.pan), balancePan(test.pan)
+		l, r := g*(1-.5*(p+1)), g*.5*(p+1)
+		if math.Abs(float64(l-test.expL)) > 1e-6 || math.Abs(float64(r-test.expR)) > 1e-6 {
+			t.Errorf("pan %.1f: expected %.2f %.2f, got %.2f %.2f", test.pan, test.expL, test.expR, l, r)
+		}
+	}
+}
+
+func loadPreset(m *Manager, p int) {
+	m.HandleMessage(msg.Message{Kind: LoadSavePresetKind, Key: uint8(p), ValF: 0})
+}
+
+func TestManager_SwitchModes(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		mode     float32
+		expHeld  bool // held right after the switch
+		expAfter bool // held once the crossfade is over
+	}{
+		{"cut", SwitchCut, false, false},
+		{"ring out", SwitchRingOut, true, true},
+		{"crossfade", SwitchCrossfade, true, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m := newPartsManager(t, 2)
+			m.HandleMessage(msg.Message{Kind: settings.SettingUpdateKind, Key: settings.PresetSwitch, ValF: test.mode})
+			m.HandleMessage(msg.Message{Kind: settings.SettingUpdateKind, Key: settings.PresetSwitchTime, ValF: .1})
+
+			m.NoteOn(60, 1)
+			loadPreset(m, 1)
+			if held := m.parts[0].held[0][60].key != 0; held != test.expHeld {
+				t.Fatalf("expected held %v after the switch, got %v", test.expHeld, held)
+			}
+
+			// New notes go to the new preset
+			m.NoteOn(64, 1)
+			if v := m.parts[0].held[0][64].voice; v != 1 {
+				t.Fatalf("expected the new note on the new preset, got %d", v)
+			}
+
+			var b dsp.Block
+			for range 20 { // 116ms
+				b.Cycle++
+				m.Process(&b)
+			}
+			if held := m.parts[0].held[0][60].key != 0; held != test.expAfter {
+				t.Fatalf("expected held %v after the crossfade, got %v", test.expAfter, held)
+			}
+			if m.voices[1].fade != 1 {
+				t.Fatalf("expected the new preset at full level, got %f", m.voices[1].fade)
+			}
+
+			// Note offs reach the former preset
+			m.NoteOff(60)
+			if m.parts[0].held[0][60].key != 0 {
+				t.Fatal("expected the note released")
+			}
+		})
+	}
+}
+
+func TestManager_PresetStep(t *testing.T) {
+	m := newPartsManager(t, 3)
+
+	for _, test := range []struct {
+		dir float32
+		exp int
+	}{
+		{-1, 2},
+		{1, 0},
+		{1, 1},
+	} {
+		m.HandleMessage(msg.Message{Kind: PresetStepKind, ValF: test.dir})
+		if m.current != test.exp {
+			t.Fatalf("expected preset %d, got %d", test.exp, m.current)
+		}
+	}
+}
+
+func TestManager_Banks(t *testing.T) {
+	dir := t.TempDir()
+	writePresets(t, dir, 2)
+	writePresets(t, filepath.Join(dir, "b"), 1)
+	writePresets(t, filepath.Join(dir, "a"), 3)
+
+	m := NewManager(44100, zerolog.Nop(), msg.NewMessenger(msg.NewQueue(1), msg.NewQueue(1024), 0), dir)
+
+	exp := []string{"0", "1", "a/0", "a/1", "a/2", "b/0"}
+	if names := m.GetPresets(); !slices.Equal(names, exp) {
+		t.Fatalf("expected %v, got %v", exp, names)
+	}
+
+	for _, test := range []struct {
+		bank, program, exp int
+	}{
+		{1, 2, 4},
+		{2, 0, 5},
+		{0, 1, 1},
+		{2, 1, 1}, // out of the bank, ignored
+		{3, 0, 1}, // no such bank, ignored
+	} {
+		m.LoadProgram(test.bank, test.program)
+		if m.current != test.exp {
+			t.Fatalf("bank %d program %d: expected preset %d, got %d", test.bank, test.program, test.exp, m.current)
+		}
+	}
+}
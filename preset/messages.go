@@ -5,6 +5,16 @@ import "synth/msg"
 // LoadSavePresetKind msg.key = preset slot, msg.val8 = 0 load, 1 save
 const LoadSavePresetKind msg.Kind = 21
 
+// PresetStepKind msg.valF = +1 next preset, -1 previous preset, see Switch*
+const PresetStepKind msg.Kind = 24
+
+// PresetLoadedKind msg.valF = index of the preset loaded, published by the Manager
+const PresetLoadedKind msg.Kind = 25
+
+// DumpPresetKind msg.key = bank, msg.valF = 0 the current preset, 1 every
+// preset of the bank, sent by the Librarian, dumped by the Manager
+const DumpPresetKind msg.Kind = 26
+
 // UpdateParameterKind msg.key = parameter ID, msg.valF = parameter value
 const UpdateParameterKind msg.Kind = 20
 
@@ -17,18 +27,7 @@ const (
 	UnisonCurveGamma   = 4
 	UnisonVoices       = 5
 
-	// Pitch Modulation
-	PitchLfoOnOff    = 6
-	PitchLfoAmount   = 7
-	PitchLfoShape    = 8
-	PitchLfoFreq     = 9
-	PitchLfoPhase    = 10
-	PitchAdsrOnOff   = 11
-	PitchAdsrAmount  = 12
-	PitchAdsrAttack  = 13
-	PitchAdsrDecay   = 14
-	PitchAdsrSustain = 15
-	PitchAdsrRelease = 16
+	// 6-16: reserved, former pitch LFO/ADSR parameters, see mod matrix
 
 	// Feedback Delay parameters
 	FBOnOff      = 17 // 0 = off, 1 = on
@@ -37,11 +36,7 @@ const (
 	FBMix        = 20
 	FBTone       = 21
 
-	// Amp Envelope parameters
-	AmpEnvAttack  = 22
-	AmpEnvDecay   = 23
-	AmpEnvSustain = 24
-	AmpEnvRelease = 25
+	// 22-25: reserved, former amp envelope parameters, see ADSR 1
 
 	// Oscillator parameters
 	Osc0Shape  = 26
@@ -67,18 +62,7 @@ const (
 	LPFCutoff    = 42
 	LPFResonance = 43
 
-	LpfLfoOnOff  = 44
-	LpfLfoAmount = 45
-	LpfLfoShape  = 46
-	LpfLfoFreq   = 47
-	LpfLfoPhase  = 48
-
-	LpfAdsrOnOff   = 49
-	LpfAdsrAmount  = 50
-	LpfAdsrAttack  = 51
-	LpfAdsrDecay   = 52
-	LpfAdsrSustain = 53
-	LpfAdsrRelease = 54
+	// 44-54: reserved, former LPF LFO/ADSR parameters, see mod matrix
 
 	// Voices
 	VoicesStealMode  = 55
@@ -125,10 +109,35 @@ const (
 	Adsr2Sustain = 82
 	Adsr2Release = 83
 
+	// Macros
+	Macro0 = 86
+	Macro1 = 87
+	Macro2 = 88
+	Macro3 = 89
+	Macro4 = 90
+	Macro5 = 91
+	Macro6 = 92
+	Macro7 = 93
+
+	// Harmony, applied to the played notes before the voices, see package harmony
+	HarmonyScaleLock  = 94  // 0 = off, 1 = on
+	HarmonyRoot       = 95  // 0 = C .. 11 = B
+	HarmonyScale      = 96  // see harmony.Scales
+	HarmonyChord      = 97  // see Chord*
+	HarmonyChordShape = 98  // bit i = note i semitones above the played key
+	HarmonyStrum      = 99  // seconds between two chord notes
+	HarmonyStrumDir   = 100 // 0 = up, 1 = down
+
 	// No parameter
 	ParamNone = 255
 )
 
+const (
+	ChordOff   = 0
+	ChordOn    = 1 // a played key plays the HarmonyChordShape
+	ChordLearn = 2 // the next held keys become the shape, then ChordOn
+)
+
 // ModulationUpdateKind msg.key = slot, msg.channel = source, msg.val8 = destination, msg.valF = amount, msg.val16 = shape
 const ModulationUpdateKind msg.Kind = 22
 
@@ -157,3 +166,18 @@ const (
 	ModShapeExponential = 1
 	ModShapeLogarithmic = 2
 )
+
+// MacroUpdateKind msg.key = macro*MacroKeysSpacing + target*MacroTargetSpacing + param, msg.valF = value
+const MacroUpdateKind msg.Kind = 23
+
+const (
+	Macros             = 8  // number of macros, values are the Macro0..Macro7 parameters
+	MacroTargets       = 8  // number of targets per macro
+	MacroKeysSpacing   = 32 // x/MacroKeysSpacing = macro
+	MacroTargetSpacing = 4  // x%MacroKeysSpacing/MacroTargetSpacing = target, x%MacroTargetSpacing = param
+
+	MacroParamDst = 0
+	MacroParamMin = 1 // offset at macro 0, relative to the destination range (-1..1)
+	MacroParamMax = 2 // offset at macro 1, relative to the destination range (-1..1)
+	MacroParamCrv = 3 // see ModShape*
+)
@@ -0,0 +1,376 @@
+package preset
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"synth/dsp"
+)
+
+// Scope tells where a parameter lives in the synth graph, and therefore
+// which modulators can reach it.
+type Scope uint8
+
+const (
+	// ScopeVoice parameters are copied in each voice, modulation is applied
+	// per voice using the voice modulators (LFOs, ADSRs).
+	ScopeVoice Scope = iota
+	// ScopeGlobal parameters live after the voices mix (effects), modulation
+	// is applied once using the global modulators (LFOs only).
+	ScopeGlobal
+	// ScopeNote parameters act on the played notes before the voices
+	// (harmony), they are not modulation destinations.
+	ScopeNote
+)
+
+// Unit tells how a parameter value should be displayed
+type Unit uint8
+
+const (
+	UnitNone     Unit = iota
+	UnitSemiTone      // st
+	UnitOctave        // semitones, displayed as octaves
+	UnitSecond        // seconds, displayed as milliseconds
+	UnitHertz
+	UnitLowHertz // Hz, with decimals
+	UnitCycle    // 0..1
+	UnitCent
+	UnitVoice
+)
+
+// NoCC parameter not bound to any MIDI control change (CC 0 is bank select)
+const NoCC = 0
+
+// ParamMeta describes a preset parameter, single source of truth for
+// defaults, ranges, display, modulation and MIDI mapping.
+type ParamMeta struct {
+	ID    uint8
+	Group string
+	Name  string
+	Scope Scope
+
+	Min, Max, Default, Step float32
+	Unit                    Unit
+
+	// Discrete parameters (waveforms, switches, counts) are not modulation
+	// destinations: they select a structure rather than a value.
+	Discrete bool
+
+	// Smoothed parameters must not jump when changed (gain, cutoff, pan, ...)
+	Smoothed bool
+
+	// CC default MIDI control change driving the parameter, NoCC if none
+	CC uint8
+}
+
+// Label returns the display label of the parameter, ex: "Osc 1 > Gain"
+func (m *ParamMeta) Label() string {
+	return m.Group + " > " + m.Name
+}
+
+// ModDestination reports whether the parameter can be targeted by a mod slot
+func (m *ParamMeta) ModDestination() bool {
+	return !m.Discrete && m.Scope != ScopeNote
+}
+
+// MacroDestination reports whether the parameter can be targeted by a macro,
+// macros can not drive each other to keep the graph acyclic.
+func (m *ParamMeta) MacroDestination() bool {
+	return m.ModDestination() && !isMacro(m.ID)
+}
+
+func isMacro(id uint8) bool {
+	return id >= Macro0 && id <= Macro7
+}
+
+// Clamp returns v constrained to the parameter range, rounded if discrete
+func (m *ParamMeta) Clamp(v float32) float32 {
+	if m.Discrete {
+		v = float32(math.Round(float64(v)))
+	}
+	if v < m.Min {
+		return m.Min
+	}
+	if v > m.Max {
+		return m.Max
+	}
+	return v
+}
+
+// Normalize maps v from the parameter range to 0..1
+func (m *ParamMeta) Normalize(v float32) float32 {
+	if m.Max == m.Min {
+		return 0
+	}
+	return (m.Clamp(v) - m.Min) / (m.Max - m.Min)
+}
+
+// Denormalize maps n from 0..1 to the parameter range
+func (m *ParamMeta) Denormalize(n float32) float32 {
+	return m.Clamp(m.Min + n*(m.Max-m.Min))
+}
+
+var paramsMeta = []*ParamMeta{
+	// Oscillators
+	oscShapeMeta(Osc0Shape, "Osc 1"),
+	{ID: Osc0Detune, Group: "Osc 1", Name: "Detune", Min: -100, Max: 100, Default: 0, Step: .01, Unit: UnitSemiTone, Smoothed: true},
+	{ID: Osc0Gain, Group: "Osc 1", Name: "Gain", Min: 0, Max: 1, Default: .33, Step: .01, Smoothed: true, CC: 14},
+	{ID: Osc0Phase, Group: "Osc 1", Name: "Phase", Min: 0, Max: 1, Default: 0, Step: .01, Unit: UnitCycle},
+	{ID: Osc0Pw, Group: "Osc 1", Name: "Pulse width", Min: .01, Max: .5, Default: .5, Step: .01},
+
+	oscShapeMeta(Osc1Shape, "Osc 2"),
+	{ID: Osc1Detune, Group: "Osc 2", Name: "Detune", Min: -100, Max: 100, Default: 0, Step: .01, Unit: UnitSemiTone, Smoothed: true},
+	{ID: Osc1Gain, Group: "Osc 2", Name: "Gain", Min: 0, Max: 1, Default: 0, Step: .01, Smoothed: true, CC: 15},
+	{ID: Osc1Phase, Group: "Osc 2", Name: "Phase", Min: 0, Max: 1, Default: 0, Step: .01, Unit: UnitCycle},
+	{ID: Osc1Pw, Group: "Osc 2", Name: "Pulse width", Min: .01, Max: .5, Default: .5, Step: .01},
+
+	oscShapeMeta(Osc2Shape, "Osc 3"),
+	{ID: Osc2Detune, Group: "Osc 3", Name: "Detune", Min: -100, Max: 100, Default: 0, Step: .01, Unit: UnitSemiTone, Smoothed: true},
+	{ID: Osc2Gain, Group: "Osc 3", Name: "Gain", Min: 0, Max: 1, Default: 0, Step: .01, Smoothed: true, CC: 16},
+	{ID: Osc2Phase, Group: "Osc 3", Name: "Phase", Min: 0, Max: 1, Default: 0, Step: .01, Unit: UnitCycle},
+	{ID: Osc2Pw, Group: "Osc 3", Name: "Pulse width", Min: .01, Max: .5, Default: .5, Step: .01},
+
+	// Noise oscillator
+	{ID: NoiseType, Group: "Noise", Name: "Type", Min: dsp.NoiseWhite, Max: dsp.NoiseBlue, Default: dsp.NoiseWhite, Step: 1, Discrete: true},
+	{ID: NoiseGain, Group: "Noise", Name: "Gain", Min: 0, Max: 1, Default: 0, Step: .01, Smoothed: true, CC: 17},
+
+	// Sub oscillator
+	oscShapeMeta(SubOscShape, "Sub"),
+	{ID: SubOscGain, Group: "Sub", Name: "Gain", Min: 0, Max: 1, Default: 0, Step: .01, Smoothed: true, CC: 18},
+	{ID: SubOscTranspose, Group: "Sub", Name: "Transpose", Min: -48, Max: 48, Default: 0, Step: 12, Unit: UnitOctave},
+
+	// LFOs
+	oscShapeMeta(Lfo0Shape, "LFO 1"),
+	{ID: Lfo0rate, Group: "LFO 1", Name: "Rate", Min: .01, Max: 20, Default: .33, Step: .01, Unit: UnitLowHertz, CC: 76},
+	{ID: Lfo0Phase, Group: "LFO 1", Name: "Phase", Min: 0, Max: 1, Default: 0, Step: .01, Unit: UnitCycle},
+
+	oscShapeMeta(Lfo1Shape, "LFO 2"),
+	{ID: Lfo1rate, Group: "LFO 2", Name: "Rate", Min: .01, Max: 20, Default: .33, Step: .01, Unit: UnitLowHertz},
+	{ID: Lfo1Phase, Group: "LFO 2", Name: "Phase", Min: 0, Max: 1, Default: 0, Step: .01, Unit: UnitCycle},
+
+	oscShapeMeta(Lfo2Shape, "LFO 3"),
+	{ID: Lfo2rate, Group: "LFO 3", Name: "Rate", Min: .01, Max: 20, Default: .33, Step: .01, Unit: UnitLowHertz},
+	{ID: Lfo2Phase, Group: "LFO 3", Name: "Phase", Min: 0, Max: 1, Default: 0, Step: .01, Unit: UnitCycle},
+
+	// ADSRs, the first one drives the amplitude
+	adsrTimeMeta(Adsr0Attack, "ADSR 1", "Attack", 73),
+	adsrTimeMeta(Adsr0Decay, "ADSR 1", "Decay", 75),
+	{ID: Adsr0Sustain, Group: "ADSR 1", Name: "Sustain", Min: 0, Max: 1, Default: .9, Step: .01, Smoothed: true, CC: 79},
+	adsrTimeMeta(Adsr0Release, "ADSR 1", "Release", 72),
+
+	adsrTimeMeta(Adsr1Attack, "ADSR 2", "Attack", NoCC),
+	adsrTimeMeta(Adsr1Decay, "ADSR 2", "Decay", NoCC),
+	{ID: Adsr1Sustain, Group: "ADSR 2", Name: "Sustain", Min: 0, Max: 1, Default: .9, Step: .01},
+	adsrTimeMeta(Adsr1Release, "ADSR 2", "Release", NoCC),
+
+	adsrTimeMeta(Adsr2Attack, "ADSR 3", "Attack", NoCC),
+	adsrTimeMeta(Adsr2Decay, "ADSR 3", "Decay", NoCC),
+	{ID: Adsr2Sustain, Group: "ADSR 3", Name: "Sustain", Min: 0, Max: 1, Default: .9, Step: .01},
+	adsrTimeMeta(Adsr2Release, "ADSR 3", "Release", NoCC),
+
+	// Feedback delay
+	onOffMeta(FBOnOff, "Delay", ScopeGlobal),
+	{ID: FBDelayParam, Group: "Delay", Name: "Time", Scope: ScopeGlobal, Min: 0, Max: 2, Default: .35, Step: .001, Unit: UnitSecond, CC: 12},
+	{ID: FBFeedBack, Group: "Delay", Name: "Feedback", Scope: ScopeGlobal, Min: 0, Max: .95, Default: .3, Step: .01, CC: 13},
+	{ID: FBMix, Group: "Delay", Name: "Mix", Scope: ScopeGlobal, Min: 0, Max: 1, Default: .3, Step: .01, Smoothed: true, CC: 91},
+	{ID: FBTone, Group: "Delay", Name: "Tone", Scope: ScopeGlobal, Min: 200, Max: 8000, Default: 5000, Step: 1, Unit: UnitHertz, Smoothed: true},
+
+	// Low pass filter
+	onOffMeta(LPFOnOff, "LPF", ScopeVoice),
+	{ID: LPFCutoff, Group: "LPF", Name: "Cutoff", Min: 20, Max: 20000, Default: 3000, Step: 1, Unit: UnitHertz, Smoothed: true, CC: 74},
+	{ID: LPFResonance, Group: "LPF", Name: "Resonance", Min: .01, Max: 10, Default: 1, Step: .01, Smoothed: true, CC: 71},
+
+	// Unison
+	onOffMeta(UnisonOnOff, "Unison", ScopeVoice),
+	{ID: UnisonVoices, Group: "Unison", Name: "Voices", Min: 1, Max: 16, Default: 4, Step: 1, Unit: UnitVoice, Discrete: true},
+	{ID: UnisonPanSpread, Group: "Unison", Name: "Pan spread", Min: 0, Max: 1, Default: 1, Step: .01, Smoothed: true, CC: 93},
+	{ID: UnisonPhaseSpread, Group: "Unison", Name: "Phase spread", Min: 0, Max: 1, Default: .1, Step: .01, Unit: UnitCycle},
+	{ID: UnisonDetuneSpread, Group: "Unison", Name: "Detune spread", Min: 0, Max: 100, Default: 12, Step: .1, Unit: UnitCent, Smoothed: true, CC: 94},
+	{ID: UnisonCurveGamma, Group: "Unison", Name: "Curve gamma", Min: .1, Max: 4, Default: 1.5, Step: .1},
+
+	// Voices
+	{ID: VoicesStealMode, Group: "Voices", Name: "Steal mode", Scope: ScopeGlobal, Min: dsp.PolyStealOldest, Max: dsp.PolyStealHighest, Default: dsp.PolyStealOldest, Step: 1, Discrete: true},
+	{ID: VoicesActive, Group: "Voices", Name: "Active voices", Scope: ScopeGlobal, Min: 1, Max: MaxVoices, Default: 8, Step: 1, Unit: UnitVoice, Discrete: true},
+	{ID: VoicesPitchGlide, Group: "Voices", Name: "Pitch glide", Min: 0, Max: 1, Default: 0, Step: .001, Unit: UnitSecond, CC: 5},
+	{ID: VoicesGain, Group: "Voices", Name: "Gain", Min: 0, Max: 1, Default: 1, Step: .01, Smoothed: true, CC: 7},
+	{ID: VoicesPitch, Group: "Voices", Name: "Pitch", Min: -48, Max: 48, Default: 0, Step: .01, Unit: UnitSemiTone, Smoothed: true},
+
+	// Macros, CC 20-27 are undefined in the MIDI spec and free for performance controls
+	macroMeta(Macro0, 20),
+	macroMeta(Macro1, 21),
+	macroMeta(Macro2, 22),
+	macroMeta(Macro3, 23),
+	macroMeta(Macro4, 24),
+	macroMeta(Macro5, 25),
+	macroMeta(Macro6, 26),
+	macroMeta(Macro7, 27),
+
+	// Harmony
+	{ID: HarmonyScaleLock, Group: "Harmony", Name: "Scale lock", Scope: ScopeNote, Min: 0, Max: 1, Default: 0, Step: 1, Discrete: true},
+	{ID: HarmonyRoot, Group: "Harmony", Name: "Root", Scope: ScopeNote, Min: 0, Max: 11, Default: 0, Step: 1, Discrete: true},
+	{ID: HarmonyScale, Group: "Harmony", Name: "Scale", Scope: ScopeNote, Min: 0, Max: 7, Default: 0, Step: 1, Discrete: true},
+	{ID: HarmonyChord, Group: "Harmony", Name: "Chord memory", Scope: ScopeNote, Min: ChordOff, Max: ChordLearn, Default: ChordOff, Step: 1, Discrete: true},
+	{ID: HarmonyChordShape, Group: "Harmony", Name: "Chord shape", Scope: ScopeNote, Min: 1, Max: 1<<24 - 1, Default: 0b10010001, Step: 1, Discrete: true}, // major triad
+	{ID: HarmonyStrum, Group: "Harmony", Name: "Strum", Scope: ScopeNote, Min: 0, Max: .25, Default: 0, Step: .001, Unit: UnitSecond},
+	{ID: HarmonyStrumDir, Group: "Harmony", Name: "Strum direction", Scope: ScopeNote, Min: 0, Max: 1, Default: 0, Step: 1, Discrete: true},
+}
+
+func oscShapeMeta(id uint8, group string) *ParamMeta {
+	return &ParamMeta{ID: id, Group: group, Name: "Shape", Min: 0, Max: 3, Default: 0, Step: 1, Discrete: true}
+}
+
+func adsrTimeMeta(id uint8, group, name string, cc uint8) *ParamMeta {
+	return &ParamMeta{ID: id, Group: group, Name: name, Min: 0, Max: 10, Default: 10.0 / 1000, Step: .001, Unit: UnitSecond, CC: cc}
+}
+
+func onOffMeta(id uint8, group string, scope Scope) *ParamMeta {
+	return &ParamMeta{ID: id, Group: group, Name: "Status", Scope: scope, Min: 0, Max: 1, Default: 0, Step: 1, Discrete: true}
+}
+
+func macroMeta(id uint8, cc uint8) *ParamMeta {
+	name := fmt.Sprintf("Macro %d", id-Macro0+1)
+	return &ParamMeta{ID: id, Group: "Macros", Name: name, Scope: ScopeGlobal, Min: 0, Max: 1, Default: 0, Step: .01, Smoothed: true, CC: cc}
+}
+
+var paramsMetaById = func() map[uint8]*ParamMeta {
+	m := make(map[uint8]*ParamMeta, len(paramsMeta))
+	for _, p := range paramsMeta {
+		if _, ok := m[p.ID]; ok {
+			panic(fmt.Sprintf("duplicated parameter ID %d", p.ID))
+		}
+		m[p.ID] = p
+	}
+	return m
+}()
+
+// ParamsMeta returns all the parameters metadata, in display order
+func ParamsMeta() []*ParamMeta {
+	return paramsMeta
+}
+
+// GetParamMeta returns the metadata of the given parameter, nil if unknown
+func GetParamMeta(id uint8) *ParamMeta {
+	return paramsMetaById[id]
+}
+
+// ModDestinations returns the parameters that can be targeted by a mod slot, in display order
+func ModDestinations() []*ParamMeta {
+	dst := make([]*ParamMeta, 0, len(paramsMeta))
+	for _, p := range paramsMeta {
+		if p.ModDestination() {
+			dst = append(dst, p)
+		}
+	}
+	return dst
+}
+
+// MacroDestinations returns the parameters that can be targeted by a macro, in display order
+func MacroDestinations() []*ParamMeta {
+	dst := make([]*ParamMeta, 0, len(paramsMeta))
+	for _, p := range paramsMeta {
+		if p.MacroDestination() {
+			dst = append(dst, p)
+		}
+	}
+	return dst
+}
+
+// voiceDestinations returns the IDs of the per-voice modulation destinations
+func voiceDestinations() []uint8 {
+	ids := make([]uint8, 0, len(paramsMeta))
+	for _, p := range paramsMeta {
+		if p.ModDestination() && p.Scope == ScopeVoice {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+var ErrUnknownParam = errors.New("unknown parameter")
+var ErrParamOutOfRange = errors.New("parameter out of range")
+var ErrInvalidModSlot = errors.New("invalid modulation slot")
+var ErrInvalidMacro = errors.New("invalid macro")
+
+// ValidateProto checks a preset against the parameters metadata.
+// NewPresetFromProto drops or clamps whatever is reported here.
+func ValidateProto(pb *ProtoPreset) error {
+	var errs []error
+
+	for _, e := range pb.Params {
+		meta := protoParamMeta(e.Id)
+		if meta == nil {
+			errs = append(errs, fmt.Errorf("%w: %d", ErrUnknownParam, e.Id))
+			continue
+		}
+		if meta.Clamp(e.Value) != e.Value {
+			errs = append(errs, fmt.Errorf("%w: %s = %g, expected %g..%g", ErrParamOutOfRange, meta.Label(), e.Value, meta.Min, meta.Max))
+		}
+	}
+
+	for i, slot := range pb.ModSlots {
+		if !validModSource(slot.Source) {
+			errs = append(errs, fmt.Errorf("%w: slot %d, unknown source %d", ErrInvalidModSlot, i, slot.Source))
+		}
+		if !validModDestination(slot.Destination) {
+			errs = append(errs, fmt.Errorf("%w: slot %d, invalid destination %d", ErrInvalidModSlot, i, slot.Destination))
+		}
+	}
+
+	if len(pb.Macros) > Macros {
+		errs = append(errs, fmt.Errorf("%w: %d macros, expected at most %d", ErrInvalidMacro, len(pb.Macros), Macros))
+	}
+	for i, macro := range pb.Macros {
+		if len(macro.Targets) > MacroTargets {
+			errs = append(errs, fmt.Errorf("%w: macro %d, %d targets, expected at most %d", ErrInvalidMacro, i, len(macro.Targets), MacroTargets))
+		}
+		for j, t := range macro.Targets {
+			if !validMacroDestination(t.Destination) {
+				errs = append(errs, fmt.Errorf("%w: macro %d target %d, invalid destination %d", ErrInvalidMacro, i, j, t.Destination))
+			}
+			if !validMacroRange(t.Min) || !validMacroRange(t.Max) {
+				errs = append(errs, fmt.Errorf("%w: macro %d target %d, range %g..%g, expected -1..1", ErrInvalidMacro, i, j, t.Min, t.Max))
+			}
+			if !validModShape(t.Curve) {
+				errs = append(errs, fmt.Errorf("%w: macro %d target %d, unknown curve %d", ErrInvalidMacro, i, j, t.Curve))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// protoParamMeta returns the metadata of a proto parameter ID, nil if unknown
+func protoParamMeta(id uint32) *ParamMeta {
+	if id > math.MaxUint8 {
+		return nil
+	}
+	return GetParamMeta(uint8(id))
+}
+
+func validModSource(src uint32) bool {
+	return src <= ModSrcAdsr2
+}
+
+func validModDestination(dst uint32) bool {
+	if dst == ParamNone {
+		return true
+	}
+	meta := protoParamMeta(dst)
+	return meta != nil && meta.ModDestination()
+}
+
+func validModShape(shape uint32) bool {
+	return shape <= ModShapeLogarithmic
+}
+
+func validMacroDestination(dst uint32) bool {
+	if dst == ParamNone {
+		return true
+	}
+	meta := protoParamMeta(dst)
+	return meta != nil && meta.MacroDestination()
+}
+
+func validMacroRange(v float32) bool {
+	return v >= -1 && v <= 1
+}
@@ -0,0 +1,85 @@
+package preset
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParamsMeta_DefaultsInRange(t *testing.T) {
+	for _, m := range ParamsMeta() {
+		if m.Min > m.Max {
+			t.Errorf("%s: min %g > max %g", m.Label(), m.Min, m.Max)
+		}
+		if m.Clamp(m.Default) != m.Default {
+			t.Errorf("%s: default %g out of range %g..%g", m.Label(), m.Default, m.Min, m.Max)
+		}
+		if m.Step <= 0 {
+			t.Errorf("%s: expected a positive step, got %g", m.Label(), m.Step)
+		}
+	}
+}
+
+func TestParamsMeta_UniqueCC(t *testing.T) {
+	seen := make(map[uint8]*ParamMeta)
+	for _, m := range ParamsMeta() {
+		if m.CC == NoCC {
+			continue
+		}
+		if m.CC > 127 {
+			t.Errorf("%s: invalid CC %d", m.Label(), m.CC)
+		}
+		if o, ok := seen[m.CC]; ok {
+			t.Errorf("CC %d bound to %s and %s", m.CC, o.Label(), m.Label())
+		}
+		seen[m.CC] = m
+	}
+}
+
+func TestParamMeta_Denormalize(t *testing.T) {
+	m := GetParamMeta(LPFCutoff)
+	if v := m.Denormalize(0); v != m.Min {
+		t.Errorf("expected %g, got %g", m.Min, v)
+	}
+	if v := m.Denormalize(1); v != m.Max {
+		t.Errorf("expected %g, got %g", m.Max, v)
+	}
+	if v := m.Normalize(m.Denormalize(.5)); v != .5 {
+		t.Errorf("expected .5, got %g", v)
+	}
+
+	d := GetParamMeta(UnisonVoices)
+	if v := d.Denormalize(.51); v != float32(int(v)) {
+		t.Errorf("expected a rounded value for a discrete parameter, got %g", v)
+	}
+}
+
+func TestValidateProto(t *testing.T) {
+	pb := NewPreset().ToProto()
+	if err := ValidateProto(pb); err != nil {
+		t.Fatalf("expected default preset to be valid, got %v", err)
+	}
+
+	pb.Params = append(pb.Params,
+		&ProtoParamEntry{Id: 255, Value: 1},
+		&ProtoParamEntry{Id: LPFCutoff, Value: 50000},
+	)
+	pb.ModSlots = append(pb.ModSlots, &ProtoModSlot{Source: ModSrcLfo0, Destination: UnisonVoices})
+
+	err := ValidateProto(pb)
+	for _, target := range []error{ErrUnknownParam, ErrParamOutOfRange, ErrInvalidModSlot} {
+		if !errors.Is(err, target) {
+			t.Errorf("expected %v, got %v", target, err)
+		}
+	}
+
+	p := NewPresetFromProto(pb)
+	if v := p.Params[LPFCutoff].GetBase(); v != GetParamMeta(LPFCutoff).Max {
+		t.Errorf("expected cutoff to be clamped, got %g", v)
+	}
+	if _, ok := p.Params[255]; ok {
+		t.Errorf("expected unknown parameter to be dropped")
+	}
+	if err := ValidateProto(p.ToProto()); err != nil {
+		t.Errorf("expected sanitized preset to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package preset
+
+import (
+	"math"
+	"synth/settings"
+)
+
+// part plays a preset for the notes matching its channel, key and velocity
+// ranges, see settings.Part*. Parts are layered, a note goes to every part
+// accepting it, and split with disjoint key ranges.
+type part struct {
+	enabled         bool
+	preset          int // preset voice, the first part follows the edited preset
+	channel         int // 0 omni, 1..16
+	keyLow, keyHigh int
+	velLow, velHigh int
+	transpose       int
+	volume, pan     float32
+
+	// held notes per channel and played key. Note offs go where their note
+	// on went, whatever the ranges or the preset became since.
+	held [16][128]heldNote
+}
+
+type heldNote struct {
+	key   uint8 // transposed key + 1, 0 when released
+	voice int16 // preset voice playing it
+}
+
+func newPart(preset int) part {
+	return part{
+		preset:  preset,
+		keyHigh: 127,
+		velHigh: 127,
+		volume:  1,
+	}
+}
+
+func (p *part) accepts(ch uint8, key int, vel float32) bool {
+	if !p.enabled || (p.channel != 0 && int(ch)+1 != p.channel) {
+		return false
+	}
+
+	v := min(int(vel*127+.5), 127) // UI keys play above 127
+	return key >= p.keyLow && key <= p.keyHigh && v >= p.velLow && v <= p.velHigh
+}
+
+// partVoice index of the preset voice played by part i
+func (m *Manager) partVoice(i int) int {
+	if i == 0 {
+		return m.current
+	}
+	return min(max(m.parts[i].preset, 0), len(m.voices)-1)
+}
+
+// voiceUsed an enabled part plays the preset voice
+func (m *Manager) voiceUsed(voice int) bool {
+	for i := range m.parts {
+		if m.parts[i].enabled && m.partVoice(i) == voice {
+			return true
+		}
+	}
+	return false
+}
+
+// forget drops the held notes of the voice, silenced by all notes off
+func (m *Manager) forget(voice int) {
+	for i := range m.parts {
+		for ch := range m.parts[i].held {
+			for key, h := range m.parts[i].held[ch] {
+				if h.key != 0 && int(h.voice) == voice {
+					m.parts[i].held[ch][key] = heldNote{}
+				}
+			}
+		}
+	}
+}
+
+func (m *Manager) setPart(key uint8, val float32) {
+	i := int(key-settings.PartKeys) / settings.PartKeysSpacing
+	if i >= len(m.parts) {
+		return
+	}
+
+	p := &m.parts[i]
+	n := int(math.Round(float64(val)))
+	switch int(key-settings.PartKeys) % settings.PartKeysSpacing {
+	case settings.PartEnabled:
+		from := m.partVoice(i)
+		p.enabled = n != 0
+		if p.enabled {
+			m.voices[from].fadeIn(m.switchMode == SwitchCrossfade)
+		} else {
+			m.switchVoice(from, -1)
+		}
+	case settings.PartPreset:
+		from := m.partVoice(i)
+		p.preset = n
+		m.switchVoice(from, m.partVoice(i))
+	case settings.PartChannel:
+		p.channel = n
+	case settings.PartKeyLow:
+		p.keyLow = n
+	case settings.PartKeyHigh:
+		p.keyHigh = n
+	case settings.PartVelLow:
+		p.velLow = n
+	case settings.PartVelHigh:
+		p.velHigh = n
+	case settings.PartTranspose:
+		p.transpose = n
+	case settings.PartVolume:
+		p.volume = val
+	case settings.PartPan:
+		p.pan = val
+	}
+
+	m.applyMix()
+}
+
+// applyMix sets the volume and pan of the preset voices from the parts.
+// Parts sharing a preset share its mixer input, the lowest part wins.
+func (m *Manager) applyMix() {
+	for _, v := range m.voices {
+		v.level = balanceGain(1, 0)
+		v.pan.SetBase(0)
+	}
+
+	for i := len(m.parts) - 1; i >= 0; i-- {
+		if p := &m.parts[i]; p.enabled {
+			v := m.voices[m.partVoice(i)]
+			v.level = balanceGain(p.volume, p.pan)
+			v.pan.SetBase(balancePan(p.pan))
+		}
+	}
+
+	for _, v := range m.voices {
+		v.gain.SetBase(v.level * v.fade)
+	}
+}
+
+// The mixer pans linearly, -6 dB each side at the center. The part pan is
+// a balance instead, unity at the center and on the kept side.
+func balanceGain(volume, pan float32) float32 {
+	l, r := min(1, 1-pan), min(1, 1+pan)
+	return volume * (l + r)
+}
+
+func balancePan(pan float32) float32 {
+	l, r := min(1, 1-pan), min(1, 1+pan)
+	return 2*r/(l+r) - 1
+}
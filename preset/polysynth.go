@@ -12,19 +12,40 @@ type Polysynth struct {
 	pitch     dsp.Param
 	messenger *msg.Messenger
 	modSlots  map[int]*ModSlot
+	macros    map[int]*Macro
 
 	modulators map[uint8]dsp.ParamModulator
 	parameters map[uint8]dsp.Param
 
 	voiceModulators []map[uint8]dsp.ParamModulator // per voice
 	voiceParams     []map[uint8]dsp.Param          // per voice
+
+	profile  []func(*dsp.Profiler) // see Profile
+	profiler *dsp.Profiler
+	parallel bool // voices rendered by a dsp.ParallelRenderer
 }
 
+// Profiler entries, see Polysynth.Profile
+const (
+	ProfileDelay       = "Delay"
+	ProfileVoices      = "Voices"
+	ProfileFilter      = "Filter"
+	ProfileOscillators = "Oscillators"
+)
+
 const MaxVoices = 16
 
+// paramRampTime duration in seconds of smoothed parameter changes, see ParamMeta.Smoothed
+const paramRampTime = 0.02
+
 func NewPolysynth(SampleRate float64) *Polysynth {
-	// Parameters map
+	// Parameters map, smoothed parameters ramp to avoid zipper noise
 	preset := NewPreset()
+	for _, meta := range paramsMeta {
+		if meta.Smoothed {
+			preset.Params[meta.ID] = dsp.NewRampParam(SampleRate, meta.Default, paramRampTime)
+		}
+	}
 
 	// Shape registry (uniq for all oscillators)
 	reg := dsp.NewShapeRegistry()
@@ -40,34 +61,27 @@ func NewPolysynth(SampleRate float64) *Polysynth {
 	voiceModulators := make([]map[uint8]dsp.ParamModulator, 0)
 	voiceParams := make([]map[uint8]dsp.Param, 0)
 
+	// Profiler hooks, wrap nodes in place
+	profile := make([]func(*dsp.Profiler), 0)
+
 	// Voice factory / 3 osc
 	voiceFact := func() *dsp.Voice {
+		// Voice params, every per voice destination gets its own copy
+		params := createLocalParametersMap(preset.Params, voiceDestinations()...)
+		voiceParams = append(voiceParams, params)
+
 		// Voice modulators
 		modulators := make(map[uint8]dsp.ParamModulator)
-		modulators[ModSrcLfo0] = dsp.NewRegOscillator(SampleRate, reg, preset.Params[Lfo0Shape], preset.Params[Lfo0rate], preset.Params[Lfo0Phase], nil)
-		modulators[ModSrcLfo1] = dsp.NewRegOscillator(SampleRate, reg, preset.Params[Lfo1Shape], preset.Params[Lfo1rate], preset.Params[Lfo1Phase], nil)
-		modulators[ModSrcLfo2] = dsp.NewRegOscillator(SampleRate, reg, preset.Params[Lfo2Shape], preset.Params[Lfo2rate], preset.Params[Lfo2Phase], nil)
-		modulators[ModSrcAdsr0] = dsp.NewADSR(SampleRate, preset.Params[Adsr0Attack], preset.Params[Adsr0Decay], preset.Params[Adsr0Sustain], preset.Params[Adsr0Release])
-		modulators[ModSrcAdsr1] = dsp.NewADSR(SampleRate, preset.Params[Adsr1Attack], preset.Params[Adsr1Decay], preset.Params[Adsr1Sustain], preset.Params[Adsr1Release])
-		modulators[ModSrcAdsr2] = dsp.NewADSR(SampleRate, preset.Params[Adsr2Attack], preset.Params[Adsr2Decay], preset.Params[Adsr2Sustain], preset.Params[Adsr2Release])
+		modulators[ModSrcLfo0] = dsp.NewRegOscillator(SampleRate, reg, preset.Params[Lfo0Shape], params[Lfo0rate], params[Lfo0Phase], nil)
+		modulators[ModSrcLfo1] = dsp.NewRegOscillator(SampleRate, reg, preset.Params[Lfo1Shape], params[Lfo1rate], params[Lfo1Phase], nil)
+		modulators[ModSrcLfo2] = dsp.NewRegOscillator(SampleRate, reg, preset.Params[Lfo2Shape], params[Lfo2rate], params[Lfo2Phase], nil)
+		modulators[ModSrcAdsr0] = dsp.NewADSR(SampleRate, params[Adsr0Attack], params[Adsr0Decay], params[Adsr0Sustain], params[Adsr0Release])
+		modulators[ModSrcAdsr1] = dsp.NewADSR(SampleRate, params[Adsr1Attack], params[Adsr1Decay], params[Adsr1Sustain], params[Adsr1Release])
+		modulators[ModSrcAdsr2] = dsp.NewADSR(SampleRate, params[Adsr2Attack], params[Adsr2Decay], params[Adsr2Sustain], params[Adsr2Release])
 		voiceModulators = append(voiceModulators, modulators)
 
-		// Voice params
-		params := createLocalParametersMap(preset.Params,
-			// Oscillators
-			Osc0Phase, Osc1Phase, Osc2Phase,
-			Osc0Detune, Osc1Detune, Osc2Detune,
-			Osc0Pw, Osc1Pw, Osc2Pw,
-			Osc0Gain, Osc1Gain, Osc2Gain,
-			// Voices
-			VoicesPitch,
-			// LPF
-			LPFCutoff, LPFResonance,
-		)
-		voiceParams = append(voiceParams, params)
-
 		// Base frequency param (uniq per voice)
-		freq := dsp.NewSmoothedParam(SampleRate, 440, preset.Params[VoicesPitchGlide])
+		freq := dsp.NewSmoothedParam(SampleRate, 440, params[VoicesPitchGlide])
 		pitchMod := params[VoicesPitch]
 		pitch := dsp.NewTunerParam(dsp.NewTunerParam(freq, pitchBend), pitchMod)
 
@@ -121,10 +135,10 @@ func NewPolysynth(SampleRate float64) *Polysynth {
 			SampleRate:   SampleRate,
 			NumVoices:    preset.Params[UnisonVoices],
 			Factory:      oscFact,
-			PanSpread:    preset.Params[UnisonPanSpread],
-			PhaseSpread:  preset.Params[UnisonPhaseSpread],
-			DetuneSpread: preset.Params[UnisonDetuneSpread],
-			CurveGamma:   preset.Params[UnisonCurveGamma],
+			PanSpread:    params[UnisonPanSpread],
+			PhaseSpread:  params[UnisonPhaseSpread],
+			DetuneSpread: params[UnisonDetuneSpread],
+			CurveGamma:   params[UnisonCurveGamma],
 		})
 		unisonSkip := NewNodeSkipper(
 			unison,
@@ -138,11 +152,11 @@ func NewPolysynth(SampleRate float64) *Polysynth {
 
 		// Noise oscillator
 		noiseOsc := dsp.NewNoise(preset.Params[NoiseType])
-		globalMix.Add(dsp.NewInput(noiseOsc, preset.Params[NoiseGain], nil))
+		globalMix.Add(dsp.NewInput(noiseOsc, params[NoiseGain], nil))
 
 		// Sub oscillator
-		subOsc := dsp.NewRegOscillator(SampleRate, reg, preset.Params[SubOscShape], dsp.NewTunerParam(pitch, preset.Params[SubOscTranspose]), nil, nil)
-		globalMix.Add(dsp.NewInput(subOsc, preset.Params[SubOscGain], nil))
+		subOsc := dsp.NewRegOscillator(SampleRate, reg, preset.Params[SubOscShape], dsp.NewTunerParam(pitch, params[SubOscTranspose]), nil, nil)
+		globalMix.Add(dsp.NewInput(subOsc, params[SubOscGain], nil))
 
 		// LPF
 		lpf := dsp.NewLowPassSVF(SampleRate, globalMix, params[LPFCutoff], params[LPFResonance])
@@ -151,11 +165,18 @@ func NewPolysynth(SampleRate float64) *Polysynth {
 		// Amplitude envelope
 		gain := dsp.NewParam(0)
 		*gain.ModInputs() = append(*gain.ModInputs(),
-			dsp.NewModInput(modulators[ModSrcAdsr0], preset.Params[VoicesGain], nil),
+			dsp.NewModInput(modulators[ModSrcAdsr0], params[VoicesGain], nil),
 		)
 
 		vca := dsp.NewVca(lpfSkip, gain)
 
+		profile = append(profile, func(pr *dsp.Profiler) {
+			oscs := pr.Wrap(ProfileOscillators, globalMix)
+			lpf.Src = oscs
+			lpfSkip.skipped = oscs
+			lpfSkip.normal = pr.Wrap(ProfileFilter, lpf, ProfileOscillators)
+		})
+
 		// Voice
 		voice := dsp.NewVoice(vca, freq,
 			modulators[ModSrcAdsr0], // First one drives the voice
@@ -176,6 +197,14 @@ func NewPolysynth(SampleRate float64) *Polysynth {
 	delay := dsp.NewFeedbackDelay(SampleRate, 2.0, poly, preset.Params[FBDelayParam], preset.Params[FBFeedBack], preset.Params[FBMix], preset.Params[FBTone])
 	delaySkip := NewNodeSkipper(delay, poly, preset.Params[FBOnOff])
 
+	// Delay first, entries are listed from the output
+	profile = append([]func(*dsp.Profiler){func(pr *dsp.Profiler) {
+		voices := pr.Wrap(ProfileVoices, poly, ProfileFilter, ProfileOscillators)
+		delay.Src = voices
+		delaySkip.skipped = voices
+		delaySkip.normal = pr.Wrap(ProfileDelay, delay, ProfileVoices)
+	}}, profile...)
+
 	// Global modulators
 	modulators := make(map[uint8]dsp.ParamModulator)
 	modulators[ModSrcLfo0] = dsp.NewRegOscillator(SampleRate, reg, preset.Params[Lfo0Shape], preset.Params[Lfo0rate], preset.Params[Lfo0Phase], nil)
@@ -190,25 +219,80 @@ func NewPolysynth(SampleRate float64) *Polysynth {
 			Destination:    slot.Destination,
 			Amount:         slot.Amount,
 			Shape:          slot.Shape,
-			GlobalModInput: dsp.NewModInput(modulators[slot.Source], dsp.NewParam(slot.Amount), nil),
+			GlobalModInput: dsp.NewModInput(modulatorOrSilent(modulators, slot.Source), dsp.NewParam(slot.Amount), nil),
 		}
 		for j := 0; j < MaxVoices; j++ {
 			modSlots[i].PerVoiceModInput = append(modSlots[i].PerVoiceModInput,
-				dsp.NewModInput(voiceModulators[j][slot.Source], dsp.NewParam(slot.Amount), nil),
+				dsp.NewModInput(modulatorOrSilent(voiceModulators[j], slot.Source), dsp.NewParam(slot.Amount), nil),
 			)
 		}
 	}
 
+	// Macros, targets are fed by the macro value parameters
+	macros := make(map[int]*Macro)
+	for i := 0; i < Macros; i++ {
+		macros[i] = &Macro{}
+		for j := range macros[i].Targets {
+			macros[i].Targets[j] = newMacroTarget(preset.Params[uint8(Macro0+i)])
+		}
+	}
+
 	return &Polysynth{
 		Node:            delaySkip,
 		voice:           poly,
 		pitch:           pitchBend,
 		modSlots:        modSlots,
+		macros:          macros,
 		modulators:      modulators,
 		parameters:      preset.Params,
 		voiceModulators: voiceModulators,
 		voiceParams:     voiceParams,
+		profile:         profile,
+	}
+}
+
+// IsSilent no voice is playing and effect tails are over, the manager
+// skips the preset until its next note
+func (p *Polysynth) IsSilent() bool {
+	return dsp.IsSilent(p.Node)
+}
+
+// Profile measures the delay, voices, filters and oscillators of the synth,
+// entries are shared by every synth profiled with pr. Call it once, before processing.
+func (p *Polysynth) Profile(pr *dsp.Profiler) {
+	for _, f := range p.profile {
+		f(pr)
 	}
+	p.profiler = pr
+	p.profileParallel()
+}
+
+// profileParallel the filters and oscillators of voices rendered in parallel
+// sum up the time of every worker, the voices keep them in their share
+func (p *Polysynth) profileParallel() {
+	if p.profiler != nil {
+		p.profiler.SetParallel(p.parallel, ProfileFilter, ProfileOscillators)
+	}
+}
+
+// SetRenderer renders the voices in parallel, nil to go back to serial
+func (p *Polysynth) SetRenderer(r *dsp.ParallelRenderer) {
+	p.parallel = r != nil
+	p.profileParallel()
+
+	if r == nil {
+		p.voice.SetRenderer(nil)
+		return
+	}
+
+	// Preset level parameters are followed by every voice
+	shared := make([]dsp.ParamModulator, 0, len(p.parameters)+1)
+	shared = append(shared, p.pitch)
+	for _, meta := range paramsMeta {
+		shared = append(shared, p.parameters[meta.ID])
+	}
+
+	p.voice.SetRenderer(r, shared...)
 }
 
 func (p *Polysynth) NoteOn(key int, vel float32) {
@@ -219,6 +303,14 @@ func (p *Polysynth) NoteOff(key int) {
 	p.voice.NoteOff(key)
 }
 
+func (p *Polysynth) NoteOnAt(key int, vel float32, offset int) {
+	p.voice.NoteOnAt(key, vel, offset)
+}
+
+func (p *Polysynth) NoteOffAt(key int, offset int) {
+	p.voice.NoteOffAt(key, offset)
+}
+
 func (p *Polysynth) SetPitchBend(semiTones float32) {
 	p.pitch.SetBase(semiTones)
 }
@@ -229,6 +321,21 @@ func (p *Polysynth) SetParam(key uint8, val float32) {
 	}
 }
 
+// Param base value of a parameter, with the edits since the preset loaded, 0 if unknown
+func (p *Polysynth) Param(key uint8) float32 {
+	if param, ok := p.parameters[key]; ok {
+		return param.GetBase()
+	}
+	return 0
+}
+
+// SetParamAt sets the parameter at the given sample offset of the next block
+func (p *Polysynth) SetParamAt(key uint8, val float32, offset int) {
+	if param, ok := p.parameters[key]; ok {
+		dsp.SetBaseAt(param, val, offset)
+	}
+}
+
 func (p *Polysynth) UpdateModMatrix(slot int, key uint8, val float32) {
 	switch key {
 	case ModParamSrc:
@@ -252,55 +359,56 @@ func (p *Polysynth) UpdateModSource(s int, src uint8) {
 	}
 	slot.Source = src
 
-	slot.GlobalModInput.SetSrc(p.modulators[slot.Source])
+	slot.GlobalModInput.SetSrc(modulatorOrSilent(p.modulators, slot.Source))
 	for v, mi := range slot.PerVoiceModInput {
-		mi.SetSrc(p.voiceModulators[v][slot.Source])
+		mi.SetSrc(modulatorOrSilent(p.voiceModulators[v], slot.Source))
 	}
 }
 
+// UpdateModDestination moves the slot to the given destination.
+// Per voice destinations are modulated by each voice's own modulators,
+// global destinations by the global ones, see Scope.
 func (p *Polysynth) UpdateModDestination(s int, dst uint8) {
 	slot := p.modSlots[s]
 	if slot.Destination == dst {
 		return
 	}
 
-	// Detach previous destination
-	if slot.Destination != ParamNone {
-		// Per voice
-		for v, mi := range slot.PerVoiceModInput {
-			param := p.voiceParams[v][slot.Destination]
-			if param != nil {
-				param.RemoveModInput(mi)
-			}
-		}
-		// Global
-		param := p.parameters[slot.Destination]
-		if param != nil {
-			param.RemoveModInput(slot.GlobalModInput)
-		}
+	p.detachModSlot(slot)
+	slot.Destination = dst
+	p.attachModSlot(slot)
+}
+
+func (p *Polysynth) attachModSlot(slot *ModSlot) {
+	meta := GetParamMeta(slot.Destination)
+	if meta == nil || !meta.ModDestination() {
+		return
 	}
 
-	// Attach new destination
-	if dst != ParamNone {
-		// Per voice
-		found := false
-		for v, mi := range slot.PerVoiceModInput {
-			param := p.voiceParams[v][dst]
-			if param != nil {
-				found = true
-				param.AddModInput(mi)
-			}
-		}
-		// Global only if not found per voice
-		if !found {
-			param := p.parameters[dst]
-			if param != nil {
-				param.AddModInput(slot.GlobalModInput)
-			}
-		}
+	if meta.Scope == ScopeGlobal {
+		p.parameters[meta.ID].AddModInput(slot.GlobalModInput)
+		return
 	}
 
-	slot.Destination = dst
+	for v, mi := range slot.PerVoiceModInput {
+		p.voiceParams[v][meta.ID].AddModInput(mi)
+	}
+}
+
+func (p *Polysynth) detachModSlot(slot *ModSlot) {
+	meta := GetParamMeta(slot.Destination)
+	if meta == nil || !meta.ModDestination() {
+		return
+	}
+
+	if meta.Scope == ScopeGlobal {
+		p.parameters[meta.ID].RemoveModInput(slot.GlobalModInput)
+		return
+	}
+
+	for v, mi := range slot.PerVoiceModInput {
+		p.voiceParams[v][meta.ID].RemoveModInput(mi)
+	}
 }
 
 func (p *Polysynth) UpdateModAmount(s int, amt float32) {
@@ -311,6 +419,42 @@ func (p *Polysynth) UpdateModAmount(s int, amt float32) {
 	}
 }
 
+func (p *Polysynth) UpdateMacro(macro, target int, key uint8, val float32) {
+	t := p.macros[macro].Targets[target]
+
+	switch key {
+	case MacroParamDst:
+		p.UpdateMacroDestination(t, uint8(val))
+	case MacroParamMin:
+		t.Min = val
+	case MacroParamMax:
+		t.Max = val
+	case MacroParamCrv:
+		t.Curve = uint8(val)
+	default:
+		panic("unknown param")
+	}
+}
+
+// UpdateMacroDestination moves the target to the given destination.
+// Macros are global, the offset is applied to the preset level parameter
+// which every voice copy follows.
+func (p *Polysynth) UpdateMacroDestination(t *MacroTarget, dst uint8) {
+	if t.Destination == dst {
+		return
+	}
+
+	if meta := GetParamMeta(t.Destination); meta != nil && meta.MacroDestination() {
+		p.parameters[meta.ID].RemoveModInput(t.ModInput)
+	}
+
+	t.setDestination(ParamNone)
+	if meta := GetParamMeta(dst); meta != nil && meta.MacroDestination() {
+		t.setDestination(dst)
+		p.parameters[meta.ID].AddModInput(t.ModInput)
+	}
+}
+
 func (p *Polysynth) LoadPreset(preset *Preset) {
 	for key, param := range preset.Params {
 		p.SetParam(key, param.GetBase())
@@ -321,6 +465,37 @@ func (p *Polysynth) LoadPreset(preset *Preset) {
 		p.UpdateModDestination(i, slot.Destination)
 		p.UpdateModAmount(i, slot.Amount)
 	}
+
+	for i, macro := range preset.Macros {
+		for j, t := range macro.Targets {
+			p.UpdateMacro(i, j, MacroParamDst, float32(t.Destination))
+			p.UpdateMacro(i, j, MacroParamMin, t.Min)
+			p.UpdateMacro(i, j, MacroParamMax, t.Max)
+			p.UpdateMacro(i, j, MacroParamCrv, float32(t.Curve))
+		}
+	}
+}
+
+// snapshot copies the preset values to s without allocation, see Librarian
+func (p *Polysynth) snapshot(s *presetSnapshot) {
+	s.known = [256]bool{}
+	for key, param := range p.parameters {
+		s.params[key], s.known[key] = param.GetBase(), true
+	}
+
+	for i, slot := range p.modSlots {
+		if i < ModSlots {
+			s.modSlots[i] = ModSlot{Source: slot.Source, Destination: slot.Destination, Amount: slot.Amount, Shape: slot.Shape}
+		}
+	}
+
+	for i, macro := range p.macros {
+		for j, t := range macro.Targets {
+			if i < Macros {
+				s.macros[i][j] = MacroTarget{Destination: t.Destination, Min: t.Min, Max: t.Max, Curve: t.Curve}
+			}
+		}
+	}
 }
 
 func (p *Polysynth) HydratePreset(preset *Preset) *Preset {
@@ -337,6 +512,17 @@ func (p *Polysynth) HydratePreset(preset *Preset) *Preset {
 		}
 	}
 
+	for i, macro := range p.macros {
+		for j, t := range macro.Targets {
+			preset.Macros[i].Targets[j] = &MacroTarget{
+				Destination: t.Destination,
+				Min:         t.Min,
+				Max:         t.Max,
+				Curve:       t.Curve,
+			}
+		}
+	}
+
 	return preset
 }
 
@@ -344,6 +530,17 @@ func (p *Polysynth) AllNotesOff() {
 	p.voice.AllNotesOff()
 }
 
+// silentModulator stands for sources that do not exist in a given scope,
+// ex: envelopes are per voice only, a global destination does not see them.
+var silentModulator = dsp.NewConstParam(0)
+
+func modulatorOrSilent(modulators map[uint8]dsp.ParamModulator, src uint8) dsp.ParamModulator {
+	if m, ok := modulators[src]; ok {
+		return m
+	}
+	return silentModulator
+}
+
 func createLocalParametersMap(src map[uint8]dsp.Param, keys ...uint8) map[uint8]dsp.Param {
 	local := make(map[uint8]dsp.Param)
 
@@ -1,6 +1,7 @@
 package preset
 
 import (
+	"math"
 	"synth/dsp"
 	"testing"
 )
@@ -20,3 +21,245 @@ func TestPolysynth_ProcessNoAlloc(t *testing.T) {
 		t.Errorf("expected 0 allocations, got %v", allocs)
 	}
 }
+
+func TestPolysynth_EveryModDestination(t *testing.T) {
+	synth := NewPolysynth(44100)
+	for i := 0; i < 4; i++ {
+		synth.NoteOn(60+i, 1.0)
+	}
+
+	var block dsp.Block
+	sources := []uint8{ModSrcLfo0, ModSrcAdsr0, ModSrcVelocity}
+
+	for _, dst := range ModDestinations() {
+		for _, src := range sources {
+			synth.UpdateModSource(0, src)
+			synth.UpdateModDestination(0, dst.ID)
+			synth.UpdateModAmount(0, 1)
+
+			if dst.Scope == ScopeVoice {
+				if n := len(*synth.voiceParams[0][dst.ID].ModInputs()); n != 2 {
+					t.Errorf("%s: expected 2 voice mod inputs, got %d", dst.Label(), n)
+				}
+			} else if n := len(*synth.parameters[dst.ID].ModInputs()); n != 1 {
+				t.Errorf("%s: expected 1 global mod input, got %d", dst.Label(), n)
+			}
+
+			// Self modulation (ex: LFO 1 > Rate) must not recurse
+			block.Cycle++
+			synth.Process(&block)
+
+			synth.UpdateModDestination(0, ParamNone)
+		}
+
+		// Detached, no input left behind
+		if dst.Scope == ScopeGlobal {
+			if n := len(*synth.parameters[dst.ID].ModInputs()); n != 0 {
+				t.Errorf("%s: expected 0 global mod inputs, got %d", dst.Label(), n)
+			}
+			continue
+		}
+		for v, params := range synth.voiceParams {
+			// One input, following the preset parameter
+			if n := len(*params[dst.ID].ModInputs()); n != 1 {
+				t.Errorf("%s: voice %d, expected 1 mod input, got %d", dst.Label(), v, n)
+			}
+		}
+	}
+}
+
+func TestPolysynth_Macro(t *testing.T) {
+	synth := NewPolysynth(44100)
+	cutoff := GetParamMeta(LPFCutoff)
+	mix := GetParamMeta(FBMix)
+
+	// Macro 1 drives the cutoff over the upper half of its range, and the delay mix down
+	synth.UpdateMacro(0, 0, MacroParamDst, LPFCutoff)
+	synth.UpdateMacro(0, 0, MacroParamMin, 0)
+	synth.UpdateMacro(0, 0, MacroParamMax, .5)
+	synth.UpdateMacro(0, 1, MacroParamDst, FBMix)
+	synth.UpdateMacro(0, 1, MacroParamMin, 0)
+	synth.UpdateMacro(0, 1, MacroParamMax, -.3)
+	synth.UpdateMacro(0, 1, MacroParamCrv, ModShapeExponential)
+
+	// Macros can not drive each other
+	synth.UpdateMacro(0, 2, MacroParamDst, Macro1)
+	if n := len(*synth.parameters[Macro1].ModInputs()); n != 0 {
+		t.Errorf("expected macro to macro target to be ignored, got %d inputs", n)
+	}
+
+	tests := []struct {
+		macro, cutoff, mix float32
+	}{
+		{0, cutoff.Default, mix.Default},
+		{.5, cutoff.Default + .25*(cutoff.Max-cutoff.Min), mix.Default - .3*.25*(mix.Max-mix.Min)},
+		{1, cutoff.Default + .5*(cutoff.Max-cutoff.Min), mix.Default - .3*(mix.Max-mix.Min)},
+	}
+
+	var cycle uint64
+	for _, tt := range tests {
+		// Macros are smoothed, let them settle
+		synth.SetParam(Macro0, tt.macro)
+		for i := 0; i < 8; i++ {
+			cycle++
+			synth.parameters[Macro0].Resolve(cycle)
+		}
+
+		if v := synth.parameters[LPFCutoff].Resolve(cycle)[0]; !almostEqual(v, tt.cutoff) {
+			t.Errorf("macro %g: expected cutoff %g, got %g", tt.macro, tt.cutoff, v)
+		}
+		if v := synth.voiceParams[0][LPFCutoff].Resolve(cycle)[0]; !almostEqual(v, tt.cutoff) {
+			t.Errorf("macro %g: expected voice cutoff %g, got %g", tt.macro, tt.cutoff, v)
+		}
+		if v := synth.parameters[FBMix].Resolve(cycle)[0]; !almostEqual(v, tt.mix) {
+			t.Errorf("macro %g: expected mix %g, got %g", tt.macro, tt.mix, v)
+		}
+	}
+
+	// Saved in the preset, and back
+	p := NewPresetFromProto(synth.HydratePreset(NewPreset()).ToProto())
+	if tgt := p.Macros[0].Targets[1]; tgt.Destination != FBMix || tgt.Max != -.3 || tgt.Curve != ModShapeExponential {
+		t.Errorf("unexpected macro target after round trip: %+v", tgt)
+	}
+
+	synth.UpdateMacro(0, 0, MacroParamDst, ParamNone)
+	if n := len(*synth.parameters[LPFCutoff].ModInputs()); n != 0 {
+		t.Errorf("expected 0 mod inputs once detached, got %d", n)
+	}
+}
+
+func almostEqual(a, b float32) bool {
+	d := a - b
+	return d < 1e-3 && d > -1e-3
+}
+
+// maxStep returns the largest sample to sample difference of the left channel over n blocks
+func maxStep(synth *Polysynth, block *dsp.Block, n int) float32 {
+	var step float32
+	prev := block.L[dsp.BlockSize-1]
+
+	for i := 0; i < n; i++ {
+		block.Cycle++
+		synth.Process(block)
+		for _, x := range block.L {
+			step = max(step, float32(math.Abs(float64(x-prev))))
+			prev = x
+		}
+	}
+
+	return step
+}
+
+func TestPolysynth_SmoothedParamNoStep(t *testing.T) {
+	for _, id := range []uint8{Osc0Gain, VoicesGain} {
+		synth := NewPolysynth(44100)
+		synth.SetParam(Osc0Gain, 1)
+		synth.NoteOn(69, 1)
+
+		// Settle, then measure the steps of the steady sine
+		var block dsp.Block
+		maxStep(synth, &block, 16)
+		steady := maxStep(synth, &block, 4)
+
+		// Mute mid-stream, the ramp must not step more than the sine itself
+		synth.SetParam(id, 0)
+		if step := maxStep(synth, &block, 8); step > steady*1.05 {
+			t.Errorf("%s: expected steps under %g, got %g", GetParamMeta(id).Label(), steady, step)
+		}
+	}
+}
+
+func TestPolysynth_NoteOnAtOffset(t *testing.T) {
+	for _, offset := range []int{0, 1, 100, dsp.BlockSize - 1} {
+		synth := NewPolysynth(44100)
+		synth.SetParam(Osc0Phase, .25) // starts at the sine peak, not at zero
+
+		synth.NoteOnAt(69, 1, offset)
+
+		var block dsp.Block
+		block.Cycle++
+		synth.Process(&block)
+
+		first := -1
+		for i, v := range block.L {
+			if v != 0 {
+				first = i
+				break
+			}
+		}
+		if first != offset {
+			t.Errorf("expected note to start at %d, got %d", offset, first)
+		}
+	}
+}
+
+func TestPolysynth_ParallelMatchesSerial(t *testing.T) {
+	renderer := dsp.NewParallelRenderer(3)
+	defer renderer.Close()
+
+	serial := NewPolysynth(44100)
+	parallel := NewPolysynth(44100)
+	parallel.SetRenderer(renderer)
+
+	for _, synth := range []*Polysynth{serial, parallel} {
+		synth.SetParam(UnisonOnOff, 1)
+		synth.SetParam(Osc1Gain, .5)
+		synth.SetParam(LPFOnOff, 1)
+		synth.UpdateModSource(0, ModSrcLfo0)
+		synth.UpdateModDestination(0, LPFCutoff)
+		synth.UpdateModAmount(0, 1000)
+		synth.UpdateMacro(0, 0, MacroParamDst, Osc0Detune)
+		synth.SetParam(Macro0, .5)
+		for i := 0; i < 8; i++ {
+			synth.NoteOn(48+i, 1)
+		}
+	}
+
+	var bs, bp dsp.Block
+	for c := 0; c < 32; c++ {
+		bs.Cycle++
+		bp.Cycle++
+		serial.Process(&bs)
+		parallel.Process(&bp)
+
+		if bs.L != bp.L || bs.R != bp.R {
+			t.Fatalf("block %d: parallel output differs from serial", c)
+		}
+	}
+}
+
+func TestPolysynth_IsSilent(t *testing.T) {
+	synth := NewPolysynth(44100)
+	synth.SetParam(FBOnOff, 1)
+	synth.SetParam(FBMix, .5)
+
+	if !synth.IsSilent() {
+		t.Fatal("expected an idle synth to be silent")
+	}
+
+	synth.NoteOn(69, 1)
+	if synth.IsSilent() {
+		t.Fatal("expected a note on to wake the synth up")
+	}
+
+	var block dsp.Block
+	for n := 0; n < 20; n++ {
+		block.Cycle++
+		synth.Process(&block)
+	}
+	synth.NoteOff(69)
+
+	// Voices release first, the delay tail rings longer
+	released := 0
+	for ; released < 10000 && !synth.IsSilent(); released++ {
+		block.Cycle++
+		synth.Process(&block)
+	}
+
+	if !synth.IsSilent() {
+		t.Fatal("expected the synth to be silent after the delay tail")
+	}
+	if min := int(2 * 44100 / dsp.BlockSize); released < min {
+		t.Errorf("expected the delay tail to last at least %d blocks, got %d", min, released)
+	}
+}
@@ -6,6 +6,7 @@ type Preset struct {
 	Params   map[uint8]dsp.Param
 	Name     string
 	ModSlots map[int]*ModSlot
+	Macros   map[int]*Macro
 }
 
 func NewPreset() *Preset {
@@ -15,16 +16,23 @@ func NewPreset() *Preset {
 	return p
 }
 
+// NewPresetFromProto creates a preset from its proto representation,
+// unknown parameters are dropped and values are clamped, see ValidateProto.
 func NewPresetFromProto(pb *ProtoPreset) *Preset {
 	p := NewPreset()
 	p.Name = pb.Name
 
 	for _, e := range pb.Params {
-		p.Params[uint8(e.Id)] = dsp.NewParam(e.Value)
+		if meta := protoParamMeta(e.Id); meta != nil {
+			p.Params[meta.ID].SetBase(meta.Clamp(e.Value))
+		}
 	}
 
 	for i := 0; i < ModSlots && i < len(pb.ModSlots); i++ {
 		slot := pb.ModSlots[i]
+		if !validModSource(slot.Source) || !validModDestination(slot.Destination) {
+			continue
+		}
 		p.ModSlots[i] = &ModSlot{
 			Source:      uint8(slot.Source),
 			Destination: uint8(slot.Destination),
@@ -33,6 +41,22 @@ func NewPresetFromProto(pb *ProtoPreset) *Preset {
 		}
 	}
 
+	for i := 0; i < Macros && i < len(pb.Macros); i++ {
+		targets := pb.Macros[i].Targets
+		for j := 0; j < MacroTargets && j < len(targets); j++ {
+			t := targets[j]
+			if !validMacroDestination(t.Destination) || !validModShape(t.Curve) {
+				continue
+			}
+			p.Macros[i].Targets[j] = &MacroTarget{
+				Destination: uint8(t.Destination),
+				Min:         max(-1, min(1, t.Min)),
+				Max:         max(-1, min(1, t.Max)),
+				Curve:       uint8(t.Curve),
+			}
+		}
+	}
+
 	return p
 }
 
@@ -57,129 +81,28 @@ func (p *Preset) ToProto() *ProtoPreset {
 		})
 	}
 
+	for i := 0; i < Macros; i++ {
+		macro := &ProtoMacro{}
+		for _, t := range p.Macros[i].Targets {
+			macro.Targets = append(macro.Targets, &ProtoMacroTarget{
+				Destination: uint32(t.Destination),
+				Min:         t.Min,
+				Max:         t.Max,
+				Curve:       uint32(t.Curve),
+			})
+		}
+		msg.Macros = append(msg.Macros, macro)
+	}
+
 	return msg
 }
 
 func (p *Preset) setDefaults() {
 	p.Name = "01 Default"
 
-	p.Params[Osc0Shape] = dsp.NewParam(0)
-	p.Params[Osc1Shape] = dsp.NewParam(0)
-	p.Params[Osc2Shape] = dsp.NewParam(0)
-
-	p.Params[Osc0Gain] = dsp.NewParam(0.33)
-	p.Params[Osc1Gain] = dsp.NewParam(0)
-	p.Params[Osc2Gain] = dsp.NewParam(0)
-
-	p.Params[Osc0Detune] = dsp.NewParam(0)
-	p.Params[Osc1Detune] = dsp.NewParam(0)
-	p.Params[Osc2Detune] = dsp.NewParam(0)
-
-	p.Params[Osc0Phase] = dsp.NewParam(0)
-	p.Params[Osc1Phase] = dsp.NewParam(0)
-	p.Params[Osc2Phase] = dsp.NewParam(0)
-
-	p.Params[Osc0Pw] = dsp.NewParam(0.5)
-	p.Params[Osc1Pw] = dsp.NewParam(0.5)
-	p.Params[Osc2Pw] = dsp.NewParam(0.5)
-
-	// Pitch mod
-	p.Params[PitchLfoOnOff] = dsp.NewParam(0)
-	p.Params[PitchLfoAmount] = dsp.NewParam(0)
-	p.Params[PitchLfoShape] = dsp.NewParam(0)
-	p.Params[PitchLfoFreq] = dsp.NewParam(0)
-	p.Params[PitchLfoPhase] = dsp.NewParam(0)
-
-	p.Params[PitchAdsrOnOff] = dsp.NewParam(0)
-	p.Params[PitchAdsrAmount] = dsp.NewParam(0)
-	p.Params[PitchAdsrAttack] = dsp.NewParam(0)
-	p.Params[PitchAdsrDecay] = dsp.NewParam(0)
-	p.Params[PitchAdsrSustain] = dsp.NewParam(0)
-	p.Params[PitchAdsrRelease] = dsp.NewParam(0)
-
-	// Amplitude envelope
-	p.Params[AmpEnvAttack] = dsp.NewParam(10.0 / 1000)
-	p.Params[AmpEnvDecay] = dsp.NewParam(10.0 / 1000)
-	p.Params[AmpEnvSustain] = dsp.NewParam(.9)
-	p.Params[AmpEnvRelease] = dsp.NewParam(10.0 / 1000)
-
-	// Unison (all voices share)
-	p.Params[UnisonOnOff] = dsp.NewParam(0)
-	p.Params[UnisonPanSpread] = dsp.NewParam(1)
-	p.Params[UnisonPhaseSpread] = dsp.NewParam(.1)
-	p.Params[UnisonDetuneSpread] = dsp.NewParam(12)
-	p.Params[UnisonCurveGamma] = dsp.NewParam(1.5)
-	p.Params[UnisonVoices] = dsp.NewParam(4)
-
-	// Feedback Delay
-	p.Params[FBOnOff] = dsp.NewParam(0)
-	p.Params[FBDelayParam] = dsp.NewParam(350.0 / 1000)
-	p.Params[FBFeedBack] = dsp.NewParam(.3)
-	p.Params[FBMix] = dsp.NewParam(.3)
-	p.Params[FBTone] = dsp.NewParam(5000)
-
-	// Low pass filter
-	p.Params[LPFOnOff] = dsp.NewParam(0)
-	p.Params[LPFCutoff] = dsp.NewParam(3000)
-	p.Params[LPFResonance] = dsp.NewParam(1)
-
-	p.Params[LpfLfoOnOff] = dsp.NewParam(0)
-	p.Params[LpfLfoAmount] = dsp.NewParam(800)
-	p.Params[LpfLfoShape] = dsp.NewParam(0)
-	p.Params[LpfLfoFreq] = dsp.NewParam(.3)
-	p.Params[LpfLfoPhase] = dsp.NewParam(0)
-
-	p.Params[LpfAdsrOnOff] = dsp.NewParam(0)
-	p.Params[LpfAdsrAmount] = dsp.NewParam(0)
-	p.Params[LpfAdsrAttack] = dsp.NewParam(0)
-	p.Params[LpfAdsrDecay] = dsp.NewParam(0)
-	p.Params[LpfAdsrSustain] = dsp.NewParam(0)
-	p.Params[LpfAdsrRelease] = dsp.NewParam(0)
-
-	// Voices
-	p.Params[VoicesStealMode] = dsp.NewParam(dsp.PolyStealOldest)
-	p.Params[VoicesActive] = dsp.NewParam(8)
-	p.Params[VoicesPitchGlide] = dsp.NewParam(0.000)
-	p.Params[VoicesGain] = dsp.NewParam(1.0)
-	p.Params[VoicesPitch] = dsp.NewParam(0)
-
-	// Noise oscillator
-	p.Params[NoiseGain] = dsp.NewParam(0)
-	p.Params[NoiseType] = dsp.NewParam(dsp.NoiseWhite)
-
-	// Sub oscillator
-	p.Params[SubOscGain] = dsp.NewParam(0)
-	p.Params[SubOscShape] = dsp.NewParam(0)
-	p.Params[SubOscTranspose] = dsp.NewParam(0)
-
-	// LFOs
-	p.Params[Lfo0rate] = dsp.NewParam(0.33)
-	p.Params[Lfo0Phase] = dsp.NewParam(0)
-	p.Params[Lfo0Shape] = dsp.NewParam(0)
-
-	p.Params[Lfo1rate] = dsp.NewParam(0.33)
-	p.Params[Lfo1Phase] = dsp.NewParam(0)
-	p.Params[Lfo1Shape] = dsp.NewParam(0)
-
-	p.Params[Lfo2rate] = dsp.NewParam(0.33)
-	p.Params[Lfo2Phase] = dsp.NewParam(0)
-	p.Params[Lfo2Shape] = dsp.NewParam(0)
-
-	// ADSRs
-	p.Params[Adsr0Attack] = dsp.NewParam(10.0 / 1000)
-	p.Params[Adsr0Decay] = dsp.NewParam(10.0 / 1000)
-	p.Params[Adsr0Sustain] = dsp.NewParam(.9)
-	p.Params[Adsr0Release] = dsp.NewParam(10.0 / 1000)
-
-	p.Params[Adsr1Attack] = dsp.NewParam(10.0 / 1000)
-	p.Params[Adsr1Decay] = dsp.NewParam(10.0 / 1000)
-	p.Params[Adsr1Sustain] = dsp.NewParam(.9)
-	p.Params[Adsr1Release] = dsp.NewParam(10.0 / 1000)
-
-	p.Params[Adsr2Attack] = dsp.NewParam(10.0 / 1000)
-	p.Params[Adsr2Decay] = dsp.NewParam(10.0 / 1000)
-	p.Params[Adsr2Sustain] = dsp.NewParam(.9)
-	p.Params[Adsr2Release] = dsp.NewParam(10.0 / 1000)
+	for _, meta := range paramsMeta {
+		p.Params[meta.ID] = dsp.NewParam(meta.Default)
+	}
 
 	// Reset modulation slots
 	p.ModSlots = make(map[int]*ModSlot)
@@ -191,4 +114,10 @@ func (p *Preset) setDefaults() {
 			Shape:       ModShapeLinear,
 		}
 	}
+
+	// Reset macros
+	p.Macros = make(map[int]*Macro)
+	for i := 0; i < Macros; i++ {
+		p.Macros[i] = newMacro()
+	}
 }
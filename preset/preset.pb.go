@@ -141,18 +141,131 @@ func (x *ProtoParamEntry) GetValue() float32 {
 	return 0
 }
 
+type ProtoMacroTarget struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Destination   uint32                 `protobuf:"varint,1,opt,name=destination,proto3" json:"destination,omitempty"`
+	Min           float32                `protobuf:"fixed32,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float32                `protobuf:"fixed32,3,opt,name=max,proto3" json:"max,omitempty"`
+	Curve         uint32                 `protobuf:"varint,4,opt,name=curve,proto3" json:"curve,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProtoMacroTarget) Reset() {
+	*x = ProtoMacroTarget{}
+	mi := &file_preset_preset_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProtoMacroTarget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtoMacroTarget) ProtoMessage() {}
+
+func (x *ProtoMacroTarget) ProtoReflect() protoreflect.Message {
+	mi := &file_preset_preset_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtoMacroTarget.ProtoReflect.Descriptor instead.
+func (*ProtoMacroTarget) Descriptor() ([]byte, []int) {
+	return file_preset_preset_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProtoMacroTarget) GetDestination() uint32 {
+	if x != nil {
+		return x.Destination
+	}
+	return 0
+}
+
+func (x *ProtoMacroTarget) GetMin() float32 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *ProtoMacroTarget) GetMax() float32 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *ProtoMacroTarget) GetCurve() uint32 {
+	if x != nil {
+		return x.Curve
+	}
+	return 0
+}
+
+type ProtoMacro struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Targets       []*ProtoMacroTarget    `protobuf:"bytes,1,rep,name=targets,proto3" json:"targets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProtoMacro) Reset() {
+	*x = ProtoMacro{}
+	mi := &file_preset_preset_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProtoMacro) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProtoMacro) ProtoMessage() {}
+
+func (x *ProtoMacro) ProtoReflect() protoreflect.Message {
+	mi := &file_preset_preset_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProtoMacro.ProtoReflect.Descriptor instead.
+func (*ProtoMacro) Descriptor() ([]byte, []int) {
+	return file_preset_preset_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProtoMacro) GetTargets() []*ProtoMacroTarget {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
 type ProtoPreset struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Params        []*ProtoParamEntry     `protobuf:"bytes,1,rep,name=params,proto3" json:"params,omitempty"`
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	ModSlots      []*ProtoModSlot        `protobuf:"bytes,3,rep,name=modSlots,proto3" json:"modSlots,omitempty"`
+	Macros        []*ProtoMacro          `protobuf:"bytes,4,rep,name=macros,proto3" json:"macros,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ProtoPreset) Reset() {
 	*x = ProtoPreset{}
-	mi := &file_preset_preset_proto_msgTypes[2]
+	mi := &file_preset_preset_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -164,7 +277,7 @@ func (x *ProtoPreset) String() string {
 func (*ProtoPreset) ProtoMessage() {}
 
 func (x *ProtoPreset) ProtoReflect() protoreflect.Message {
-	mi := &file_preset_preset_proto_msgTypes[2]
+	mi := &file_preset_preset_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -177,7 +290,7 @@ func (x *ProtoPreset) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProtoPreset.ProtoReflect.Descriptor instead.
 func (*ProtoPreset) Descriptor() ([]byte, []int) {
-	return file_preset_preset_proto_rawDescGZIP(), []int{2}
+	return file_preset_preset_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ProtoPreset) GetParams() []*ProtoParamEntry {
@@ -201,6 +314,13 @@ func (x *ProtoPreset) GetModSlots() []*ProtoModSlot {
 	return nil
 }
 
+func (x *ProtoPreset) GetMacros() []*ProtoMacro {
+	if x != nil {
+		return x.Macros
+	}
+	return nil
+}
+
 var File_preset_preset_proto protoreflect.FileDescriptor
 
 const file_preset_preset_proto_rawDesc = "" +
@@ -213,11 +333,20 @@ const file_preset_preset_proto_rawDesc = "" +
 	"\x05shape\x18\x04 \x01(\rR\x05shape\"7\n" +
 	"\x0fProtoParamEntry\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\rR\x02id\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x02R\x05value\"\x84\x01\n" +
+	"\x05value\x18\x02 \x01(\x02R\x05value\"n\n" +
+	"\x10ProtoMacroTarget\x12 \n" +
+	"\vdestination\x18\x01 \x01(\rR\vdestination\x12\x10\n" +
+	"\x03min\x18\x02 \x01(\x02R\x03min\x12\x10\n" +
+	"\x03max\x18\x03 \x01(\x02R\x03max\x12\x14\n" +
+	"\x05curve\x18\x04 \x01(\rR\x05curve\"@\n" +
+	"\n" +
+	"ProtoMacro\x122\n" +
+	"\atargets\x18\x01 \x03(\v2\x18.preset.ProtoMacroTargetR\atargets\"\xb0\x01\n" +
 	"\vProtoPreset\x12/\n" +
 	"\x06params\x18\x01 \x03(\v2\x17.preset.ProtoParamEntryR\x06params\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x120\n" +
-	"\bmodSlots\x18\x03 \x03(\v2\x14.preset.ProtoModSlotR\bmodSlotsB\tZ\a/presetb\x06proto3"
+	"\bmodSlots\x18\x03 \x03(\v2\x14.preset.ProtoModSlotR\bmodSlots\x12*\n" +
+	"\x06macros\x18\x04 \x03(\v2\x12.preset.ProtoMacroR\x06macrosB\tZ\a/presetb\x06proto3"
 
 var (
 	file_preset_preset_proto_rawDescOnce sync.Once
@@ -231,20 +360,24 @@ func file_preset_preset_proto_rawDescGZIP() []byte {
 	return file_preset_preset_proto_rawDescData
 }
 
-var file_preset_preset_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_preset_preset_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_preset_preset_proto_goTypes = []any{
-	(*ProtoModSlot)(nil),    // 0: preset.ProtoModSlot
-	(*ProtoParamEntry)(nil), // 1: preset.ProtoParamEntry
-	(*ProtoPreset)(nil),     // 2: preset.ProtoPreset
+	(*ProtoModSlot)(nil),     // 0: preset.ProtoModSlot
+	(*ProtoParamEntry)(nil),  // 1: preset.ProtoParamEntry
+	(*ProtoMacroTarget)(nil), // 2: preset.ProtoMacroTarget
+	(*ProtoMacro)(nil),       // 3: preset.ProtoMacro
+	(*ProtoPreset)(nil),      // 4: preset.ProtoPreset
 }
 var file_preset_preset_proto_depIdxs = []int32{
-	1, // 0: preset.ProtoPreset.params:type_name -> preset.ProtoParamEntry
-	0, // 1: preset.ProtoPreset.modSlots:type_name -> preset.ProtoModSlot
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2, // 0: preset.ProtoMacro.targets:type_name -> preset.ProtoMacroTarget
+	1, // 1: preset.ProtoPreset.params:type_name -> preset.ProtoParamEntry
+	0, // 2: preset.ProtoPreset.modSlots:type_name -> preset.ProtoModSlot
+	3, // 3: preset.ProtoPreset.macros:type_name -> preset.ProtoMacro
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_preset_preset_proto_init() }
@@ -258,7 +391,7 @@ func file_preset_preset_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_preset_preset_proto_rawDesc), len(file_preset_preset_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
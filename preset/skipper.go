@@ -24,6 +24,17 @@ func (s *NodeSkipper) Process(block *dsp.Block) {
 	s.normal.Process(block)
 }
 
+// Reset resets both nodes, the inactive one may share sources or be
+// switched on later
 func (s *NodeSkipper) Reset(soft bool) {
 	s.normal.Reset(soft)
+	s.skipped.Reset(soft)
+}
+
+// IsSilent reports the silence of the active node
+func (s *NodeSkipper) IsSilent() bool {
+	if s.GetBase() < 0.5 {
+		return dsp.IsSilent(s.skipped)
+	}
+	return dsp.IsSilent(s.normal)
 }
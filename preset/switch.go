@@ -0,0 +1,82 @@
+package preset
+
+import "synth/dsp"
+
+// Preset switch modes, see settings.PresetSwitch
+const (
+	SwitchCut       = 0 // all notes off on the former preset
+	SwitchRingOut   = 1 // held notes and effect tails of the former preset ring out
+	SwitchCrossfade = 2 // the former preset fades out while the new one fades in
+)
+
+// switchVoice a part moved from a preset voice to another, -1 none.
+// The former voice is left alone while another part plays it.
+func (m *Manager) switchVoice(from, to int) {
+	if from == to {
+		return
+	}
+
+	if to >= 0 {
+		m.voices[to].fadeIn(m.switchMode == SwitchCrossfade)
+	}
+	if from < 0 || m.voiceUsed(from) {
+		return
+	}
+
+	switch m.switchMode {
+	case SwitchCut:
+		m.silence(from)
+	case SwitchCrossfade:
+		m.voices[from].fadeDir = -1
+	}
+}
+
+// silence stops the notes of the voice at once, effect tails go on
+func (m *Manager) silence(voice int) {
+	m.voices[voice].voice.AllNotesOff()
+	m.forget(voice)
+}
+
+// fadeIn brings the voice back to its level, from silence when crossfading
+func (v *presetVoice) fadeIn(crossfade bool) {
+	if !crossfade {
+		v.fade, v.fadeDir = 1, 0
+		return
+	}
+
+	if v.voice.IsSilent() {
+		v.fade = 0
+	}
+	if v.fade < 1 {
+		v.fadeDir = 1
+	}
+}
+
+// advanceFades moves the crossfades by a block, faded out voices are silenced
+func (m *Manager) advanceFades() {
+	step := float32(1)
+	if m.switchTime > 0 {
+		step = float32(dsp.BlockSize / (float64(m.switchTime) * m.sr))
+	}
+
+	for i, v := range m.voices {
+		if v.fadeDir == 0 {
+			continue
+		}
+
+		v.fade += float32(v.fadeDir) * step
+		if v.fade >= 1 {
+			v.fade, v.fadeDir = 1, 0
+		} else if v.fade <= 0 {
+			v.fade, v.fadeDir = 0, 0
+			m.silence(i)
+		}
+		v.gain.SetBase(v.level * v.fade)
+	}
+}
+
+// step loads the next (+1) or previous (-1) preset, wrapping around
+func (m *Manager) step(dir int) {
+	n := len(m.voices)
+	m.loadPreset(((m.current+dir)%n + n) % n)
+}
@@ -0,0 +1,123 @@
+package preset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// SysEx framing of the synth presets, the body between F0 and F7:
+//
+//	7D 50 57 <command> <data>
+//
+// 7D is the manufacturer ID reserved for non-commercial use, 50 57 ("PW")
+// tells the synth apart from other devices using it. Commands:
+//
+//	01 <bank>        dump request: bank 0..126 dumps every preset of the bank,
+//	                 7F the current preset, answered by one preset dump each
+//	02 <data> <sum>  preset dump: a marshalled ProtoPreset packed in 7 bits,
+//	                 see pack7, and a checksum making the sum of the packed
+//	                 data and the checksum a multiple of 128
+const (
+	SysExManufacturer  = 0x7d
+	SysExDumpRequest   = 0x01
+	SysExPresetDump    = 0x02
+	SysExCurrentPreset = 0x7f // dump request bank
+)
+
+var sysExHeader = []byte{SysExManufacturer, 0x50, 0x57}
+
+var ErrSysExForeign = errors.New("sysex of another device")
+var ErrSysExInvalid = errors.New("invalid sysex")
+
+// DumpRequestSysEx body of a dump request, see SysExCurrentPreset
+func DumpRequestSysEx(bank uint8) []byte {
+	return append(bytes.Clone(sysExHeader), SysExDumpRequest, bank&0x7f)
+}
+
+// PresetDumpSysEx body of a preset dump of marshalled preset bytes
+func PresetDumpSysEx(raw []byte) []byte {
+	body := append(bytes.Clone(sysExHeader), SysExPresetDump)
+	body = pack7(body, raw)
+
+	var sum byte
+	for _, b := range body[len(sysExHeader)+1:] {
+		sum += b
+	}
+	return append(body, -sum&0x7f)
+}
+
+// ParseSysEx command and data of a SysEx body, the unpacked bytes for a preset
+// dump. ErrSysExForeign when the body is not for the synth.
+func ParseSysEx(body []byte) (byte, []byte, error) {
+	if !bytes.HasPrefix(body, sysExHeader) {
+		return 0, nil, ErrSysExForeign
+	}
+	if len(body) < len(sysExHeader)+2 {
+		return 0, nil, ErrSysExInvalid
+	}
+
+	cmd, data := body[len(sysExHeader)], body[len(sysExHeader)+1:]
+	switch cmd {
+	case SysExDumpRequest:
+		if len(data) != 1 {
+			return 0, nil, ErrSysExInvalid
+		}
+		return cmd, data, nil
+	case SysExPresetDump:
+		var sum byte
+		for _, b := range data {
+			sum += b
+		}
+		if sum&0x7f != 0 {
+			return 0, nil, fmt.Errorf("%w: bad checksum", ErrSysExInvalid)
+		}
+		raw, ok := unpack7(data[:len(data)-1])
+		if !ok {
+			return 0, nil, ErrSysExInvalid
+		}
+		return cmd, raw, nil
+	}
+	return 0, nil, ErrSysExInvalid
+}
+
+// pack7 appends 8 bits data as groups of up to 7 bytes, each preceded by a
+// byte of their most significant bits, bit i for byte i
+func pack7(dst, data []byte) []byte {
+	for len(data) > 0 {
+		n := min(len(data), 7)
+		var msbs byte
+		for i, b := range data[:n] {
+			msbs |= b >> 7 << i
+		}
+		dst = append(dst, msbs)
+		for _, b := range data[:n] {
+			dst = append(dst, b&0x7f)
+		}
+		data = data[n:]
+	}
+	return dst
+}
+
+// unpack7 reverses pack7, false on a byte over 7 bits or a lone MSB byte
+func unpack7(data []byte) ([]byte, bool) {
+	raw := make([]byte, 0, len(data)*7/8)
+	for len(data) > 0 {
+		n := min(len(data), 8)
+		if n < 2 {
+			return nil, false
+		}
+		msbs := data[0]
+		if msbs > 0x7f {
+			return nil, false
+		}
+		for i, b := range data[1:n] {
+			if b > 0x7f {
+				return nil, false
+			}
+			raw = append(raw, b|msbs>>i&1<<7)
+		}
+		data = data[n:]
+	}
+	return raw, true
+}
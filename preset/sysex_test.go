@@ -0,0 +1,45 @@
+package preset
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSysEx_PresetDump(t *testing.T) {
+	raw := make([]byte, 300)
+	for i := range raw {
+		raw[i] = byte(i * 37)
+	}
+
+	body := PresetDumpSysEx(raw)
+	for i, b := range body {
+		if b > 0x7f {
+			t.Fatalf("byte %d over 7 bits: %x", i, b)
+		}
+	}
+
+	cmd, data, err := ParseSysEx(body)
+	if err != nil || cmd != SysExPresetDump || !bytes.Equal(data, raw) {
+		t.Fatalf("expected the dump back, got %x %v", cmd, err)
+	}
+
+	body[10] ^= 1
+	if _, _, err := ParseSysEx(body); !errors.Is(err, ErrSysExInvalid) {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+}
+
+func TestSysEx_Parse(t *testing.T) {
+	cmd, data, err := ParseSysEx(DumpRequestSysEx(SysExCurrentPreset))
+	if err != nil || cmd != SysExDumpRequest || len(data) != 1 || data[0] != SysExCurrentPreset {
+		t.Fatalf("expected a current preset request, got %x %v %v", cmd, data, err)
+	}
+
+	if _, _, err := ParseSysEx([]byte{0x41, 0x10, 0x42, 0x12}); !errors.Is(err, ErrSysExForeign) {
+		t.Fatalf("expected a foreign sysex, got %v", err)
+	}
+	if _, _, err := ParseSysEx([]byte{0x7d, 0x50, 0x57, 0x09, 0x00}); !errors.Is(err, ErrSysExInvalid) {
+		t.Fatalf("expected an unknown command, got %v", err)
+	}
+}
@@ -5,4 +5,120 @@ const SettingUpdateKind = 30
 const (
 	MasterGain     = 1
 	PitchBendRange = 2
+	ScopeTrigger   = 3
+	ScopeLevel     = 4
+	ScopeView      = 5
+	ScopeFreeze    = 6 // not persisted
+	MasterCeiling  = 7 // dBFS
 )
+
+// Master bus compressor, see dsp.CompressorOpts
+const (
+	CompEnabled   = 8
+	CompThreshold = 9
+	CompRatio     = 10
+	CompAttack    = 11
+	CompRelease   = 12
+	CompKnee      = 13
+	CompMakeup    = 14
+	CompDetector  = 15
+	DuckMode      = 16
+	DuckDepth     = 17
+	DuckRelease   = 18
+	Tempo         = 19
+)
+
+// Master EQ and stereo width, see dsp.EqualizerOpts
+const (
+	EqLowCut        = 20 // Hz, dsp.EqLowCutOff is off
+	EqLowShelfFreq  = 21
+	EqLowShelfGain  = 22
+	EqPeak1Freq     = 23
+	EqPeak1Gain     = 24
+	EqPeak1Q        = 25
+	EqPeak2Freq     = 26
+	EqPeak2Gain     = 27
+	EqPeak2Q        = 28
+	EqHighShelfFreq = 29
+	EqHighShelfGain = 30
+	EqHighCut       = 31 // Hz, dsp.EqHighCutOff is off
+	StereoWidth     = 32
+)
+
+const (
+	PresetSwitch     = 33 // preset.Switch*
+	PresetSwitchTime = 34 // seconds, crossfade
+	ProgramChannel   = 35 // program change and bank select channel, 0 omni, 1..16
+)
+
+// Tuning, see tuning.Tuner
+const (
+	MasterTune      = 36 // Hz, A4
+	MasterTranspose = 37 // semitones
+	MasterFineTune  = 38 // cents
+	Tuning          = 39 // tuning.Tuner index, 0 equal temperament
+)
+
+// MIDI file playback, see midi.FilePlayer
+const (
+	FileLoop  = 40 // 0 = off, 1 = on
+	FileTempo = 41 // bpm, 0 = tempo of the file
+)
+
+// MIDI output, see midi.Output
+const (
+	MidiOutPort    = 42 // 0 = none, n = out port n-1
+	MidiOutChannel = 43 // 1..16
+	MidiOutParams  = 44 // see MidiOutParams*
+	MidiThru       = 45 // 0 = off, 1 = on
+)
+
+const (
+	MidiOutParamsOff  = 0
+	MidiOutParamsCC   = 1 // mapped parameters only
+	MidiOutParamsNRPN = 2 // mapped parameters as CC, the others as NRPN
+)
+
+// Multitimbral parts, key = PartKeys + part*PartKeysSpacing + param,
+// see preset.Manager
+const (
+	MaxParts        = 4
+	PartKeys        = 64
+	PartKeysSpacing = 16
+)
+
+const (
+	PartEnabled   = 0
+	PartPreset    = 1 // preset index, ignored by the first part which plays the edited preset
+	PartChannel   = 2 // 0 omni, 1..16
+	PartKeyLow    = 3
+	PartKeyHigh   = 4
+	PartVelLow    = 5 // 0..127
+	PartVelHigh   = 6
+	PartTranspose = 7 // semitones
+	PartVolume    = 8 // linear
+	PartPan       = 9 // -1..+1
+)
+
+func PartKey(part, param int) uint8 {
+	return uint8(PartKeys + part*PartKeysSpacing + param)
+}
+
+// MIDI input devices, key = DeviceKeys + device*DeviceKeysSpacing + param,
+// see midi.Listener. A device keeps its slot across restarts.
+const (
+	MaxDevices        = 8
+	DeviceKeys        = 128
+	DeviceKeysSpacing = 8
+)
+
+const (
+	DeviceID        = 0 // hash of the port name, 0 = free slot
+	DeviceEnabled   = 1
+	DeviceChannel   = 2 // input channel filter, 0 omni, 1..16
+	DeviceTranspose = 3 // semitones
+)
+
+func DeviceKey(device, param int) uint8 {
+	return uint8(DeviceKeys + device*DeviceKeysSpacing + param)
+}
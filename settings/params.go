@@ -0,0 +1,20 @@
+package settings
+
+import (
+	"synth/dsp"
+	"synth/msg"
+)
+
+// Params applies setting updates to audio parameters, register it on the
+// audio messenger
+type Params map[uint8]dsp.Param
+
+func (p Params) HandleMessage(m msg.Message) {
+	if m.Kind != SettingUpdateKind {
+		return
+	}
+
+	if param, ok := p[m.Key]; ok {
+		param.SetBase(m.ValF)
+	}
+}
@@ -162,6 +162,75 @@ func (s *Settings) loadDefaults() {
 
 	s.settings[MasterGain] = 1.0
 	s.settings[PitchBendRange] = 4.0
+	s.settings[ScopeTrigger] = 1 // rising edge
+	s.settings[ScopeLevel] = 0
+	s.settings[ScopeView] = 0
+	s.settings[MasterCeiling] = -0.3
+
+	s.settings[CompEnabled] = 0
+	s.settings[CompThreshold] = -18
+	s.settings[CompRatio] = 3
+	s.settings[CompAttack] = 0.01
+	s.settings[CompRelease] = 0.15
+	s.settings[CompKnee] = 6
+	s.settings[CompMakeup] = 0
+	s.settings[CompDetector] = 0
+	s.settings[DuckMode] = 0
+	s.settings[DuckDepth] = -12
+	s.settings[DuckRelease] = 0.25
+	s.settings[Tempo] = 120
+
+	s.settings[EqLowCut] = 20
+	s.settings[EqLowShelfFreq] = 120
+	s.settings[EqLowShelfGain] = 0
+	s.settings[EqPeak1Freq] = 500
+	s.settings[EqPeak1Gain] = 0
+	s.settings[EqPeak1Q] = 1
+	s.settings[EqPeak2Freq] = 3000
+	s.settings[EqPeak2Gain] = 0
+	s.settings[EqPeak2Q] = 1
+	s.settings[EqHighShelfFreq] = 8000
+	s.settings[EqHighShelfGain] = 0
+	s.settings[EqHighCut] = 18000
+	s.settings[StereoWidth] = 1
+
+	s.settings[PresetSwitch] = 1 // ring out
+	s.settings[PresetSwitchTime] = .5
+	s.settings[ProgramChannel] = 0
+
+	s.settings[MasterTune] = 440
+	s.settings[MasterTranspose] = 0
+	s.settings[MasterFineTune] = 0
+	s.settings[Tuning] = 0
+
+	s.settings[FileLoop] = 0
+	s.settings[FileTempo] = 0
+
+	s.settings[MidiOutPort] = 0
+	s.settings[MidiOutChannel] = 1
+	s.settings[MidiOutParams] = MidiOutParamsNRPN
+	s.settings[MidiThru] = 0
+
+	for i := 0; i < MaxParts; i++ {
+		s.settings[PartKey(i, PartEnabled)] = 0
+		s.settings[PartKey(i, PartPreset)] = float32(i)
+		s.settings[PartKey(i, PartChannel)] = 0
+		s.settings[PartKey(i, PartKeyLow)] = 0
+		s.settings[PartKey(i, PartKeyHigh)] = 127
+		s.settings[PartKey(i, PartVelLow)] = 0
+		s.settings[PartKey(i, PartVelHigh)] = 127
+		s.settings[PartKey(i, PartTranspose)] = 0
+		s.settings[PartKey(i, PartVolume)] = 1
+		s.settings[PartKey(i, PartPan)] = 0
+	}
+	s.settings[PartKey(0, PartEnabled)] = 1
+
+	for i := 0; i < MaxDevices; i++ {
+		s.settings[DeviceKey(i, DeviceID)] = 0
+		s.settings[DeviceKey(i, DeviceEnabled)] = 1
+		s.settings[DeviceKey(i, DeviceChannel)] = 0
+		s.settings[DeviceKey(i, DeviceTranspose)] = 0
+	}
 }
 
 func (s *Settings) periodicPersist() {
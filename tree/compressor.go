@@ -0,0 +1,65 @@
+package tree
+
+import (
+	"synth/dsp"
+	"synth/meter"
+	"synth/msg"
+	"synth/settings"
+)
+
+// CompressorNode gain reduction meter of the master bus compressor,
+// see meter.CompressorReductionKind
+type CompressorNode interface {
+	FeatureNode
+	Reduction() float32 // dB, <= 0
+}
+
+type compressorNode struct {
+	FeatureNode
+	reduction float32
+}
+
+// NewMasterBusNodes gain reduction meter followed by the compressor and ducking settings
+func NewMasterBusNodes(label string) Node {
+	const kind = settings.SettingUpdateKind
+
+	return NewNode(label,
+		&compressorNode{FeatureNode: NewFeatureNode("Gain reduction", FeatureCompressor)},
+		NewSelectorNode("Status", kind, settings.CompEnabled,
+			NewSelectorOption("Off", "", 0),
+			NewSelectorOption("On", "", 1),
+		),
+		NewSliderNode("Threshold", kind, settings.CompThreshold, -60, 0, .5, formatDecibel),
+		NewSliderNode("Ratio", kind, settings.CompRatio, 1, 20, .1, formatRatio),
+		NewSliderNode("Attack", kind, settings.CompAttack, .0005, .2, .0005, formatMillisecond),
+		NewSliderNode("Release", kind, settings.CompRelease, .01, 2, .01, formatMillisecond),
+		NewSliderNode("Knee", kind, settings.CompKnee, 0, 24, .5, formatDecibel),
+		NewSliderNode("Makeup", kind, settings.CompMakeup, 0, 24, .5, formatDecibel),
+		NewSelectorNode("Detector", kind, settings.CompDetector,
+			NewSelectorOption("Peak", "", dsp.CompDetectPeak),
+			NewSelectorOption("RMS", "", dsp.CompDetectRms),
+		),
+		NewSelectorNode("Ducking", kind, settings.DuckMode,
+			NewSelectorOption("Off", "", dsp.DuckOff),
+			NewSelectorOption("Note on", "", dsp.DuckNotes),
+			NewSelectorOption("Tempo", "", dsp.DuckTempo),
+		),
+		NewSliderNode("Duck depth", kind, settings.DuckDepth, -36, 0, .5, formatDecibel),
+		NewSliderNode("Duck release", kind, settings.DuckRelease, .02, 2, .01, formatMillisecond),
+		NewSliderNode("Tempo", kind, settings.Tempo, 40, 240, 1, formatBpm),
+	)
+}
+
+func (c *compressorNode) Reduction() float32 {
+	return c.reduction
+}
+
+func (c *compressorNode) HandleMessage(m msg.Message) {
+	if m.Kind == meter.CompressorReductionKind {
+		c.reduction = m.ValF
+	}
+}
+
+func (c *compressorNode) AttachMessenger(m *msg.Messenger) {
+	m.RegisterHandler(c)
+}
@@ -0,0 +1,66 @@
+package tree
+
+import (
+	"fmt"
+	"synth/dsp"
+	"synth/meter"
+	"synth/msg"
+)
+
+// CpuNode keeps the DSP loads published by the audio profiler,
+// see meter.CpuLoadKind. No loads when profiling is disabled.
+type CpuNode interface {
+	FeatureNode
+	Loads() []dsp.ProfileLoad
+}
+
+type cpuNode struct {
+	FeatureNode
+	loads []dsp.ProfileLoad
+}
+
+// NewCpuNode names: profiler entries in message index order, see dsp.Profiler.Names
+func NewCpuNode(label string, names []string) CpuNode {
+	c := &cpuNode{
+		FeatureNode: NewFeatureNode(label, FeatureCpu),
+		loads:       make([]dsp.ProfileLoad, len(names)),
+	}
+	for i, name := range names {
+		c.loads[i].Name = name
+	}
+
+	c.AttachPreview(func() (string, string) {
+		if len(c.loads) == 0 {
+			return "Off", ""
+		}
+		return fmt.Sprintf("%.0f%%", c.loads[0].Avg*100), ""
+	})
+
+	return c
+}
+
+func (c *cpuNode) Loads() []dsp.ProfileLoad {
+	return c.loads
+}
+
+func (c *cpuNode) HandleMessage(m msg.Message) {
+	if m.Kind != meter.CpuLoadKind {
+		return
+	}
+
+	i := int(m.Key / meter.CpuKeysSpacing)
+	if i >= len(c.loads) {
+		return
+	}
+
+	switch m.Key % meter.CpuKeysSpacing {
+	case meter.CpuParamAvg:
+		c.loads[i].Avg = m.ValF
+	case meter.CpuParamPeak:
+		c.loads[i].Peak = m.ValF
+	}
+}
+
+func (c *cpuNode) AttachMessenger(m *msg.Messenger) {
+	m.RegisterHandler(c)
+}
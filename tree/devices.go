@@ -0,0 +1,43 @@
+package tree
+
+import (
+	"fmt"
+	"synth/settings"
+)
+
+// DeviceLister names the MIDI input devices of the settings slots, see midi.Listener
+type DeviceLister interface {
+	Device(slot int) (name string, connected bool)
+}
+
+// NewMidiDevicesNodes one page per device slot, labeled with the name of its
+// port once seen, with its enabled state, input channel and transpose
+func NewMidiDevicesNodes(label string, devices DeviceLister) Node {
+	const kind = settings.SettingUpdateKind
+
+	nodes := make([]Node, settings.MaxDevices)
+	for i := range nodes {
+		key := func(param int) uint8 { return settings.DeviceKey(i, param) }
+
+		nodes[i] = NewNode(fmt.Sprintf("Device %d", i+1),
+			NewSelectorNode("Enabled", kind, key(settings.DeviceEnabled),
+				NewSelectorOption("OFF", "", 0),
+				NewSelectorOption("ON", "", 1),
+			),
+			NewSliderNode("Channel", kind, key(settings.DeviceChannel), 0, 16, 1, formatChannel),
+			NewSliderNode("Transpose", kind, key(settings.DeviceTranspose), -48, 48, 1, formatTranspose),
+		)
+		nodes[i].AttachPreview(func() (string, string) {
+			name, connected := devices.Device(i)
+			switch {
+			case connected:
+				return "Connected", name
+			case name != "":
+				return "Disconnected", name
+			}
+			return "", ""
+		})
+	}
+
+	return NewNode(label, nodes...)
+}
@@ -0,0 +1,27 @@
+package tree
+
+import (
+	"synth/dsp"
+	"synth/settings"
+)
+
+// NewMasterEqNodes cuts, shelves and peaking bands of the master EQ, then the stereo width
+func NewMasterEqNodes(label string) Node {
+	const kind = settings.SettingUpdateKind
+
+	return NewNode(label,
+		NewSliderNode("Low cut", kind, settings.EqLowCut, dsp.EqLowCutOff, 500, 5, formatLowCut),
+		NewSliderNode("Low shelf", kind, settings.EqLowShelfFreq, 30, 500, 5, formatHertz),
+		NewSliderNode("Low gain", kind, settings.EqLowShelfGain, -18, 18, .5, formatDecibel),
+		NewSliderNode("Peak 1", kind, settings.EqPeak1Freq, 100, 2000, 10, formatHertz),
+		NewSliderNode("Peak 1 gain", kind, settings.EqPeak1Gain, -18, 18, .5, formatDecibel),
+		NewSliderNode("Peak 1 width", kind, settings.EqPeak1Q, .3, 8, .05, formatQ),
+		NewSliderNode("Peak 2", kind, settings.EqPeak2Freq, 1000, 10000, 50, formatHertz),
+		NewSliderNode("Peak 2 gain", kind, settings.EqPeak2Gain, -18, 18, .5, formatDecibel),
+		NewSliderNode("Peak 2 width", kind, settings.EqPeak2Q, .3, 8, .05, formatQ),
+		NewSliderNode("High shelf", kind, settings.EqHighShelfFreq, 2000, 16000, 100, formatHertz),
+		NewSliderNode("High gain", kind, settings.EqHighShelfGain, -18, 18, .5, formatDecibel),
+		NewSliderNode("High cut", kind, settings.EqHighCut, 2000, dsp.EqHighCutOff, 100, formatHighCut),
+		NewSliderNode("Stereo width", kind, settings.StereoWidth, 0, 2, .05, formatPercent),
+	)
+}
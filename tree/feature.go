@@ -2,6 +2,10 @@ package tree
 
 const (
 	FeatureOscilloscope = iota
+	FeatureCpu
+	FeatureLevels
+	FeatureSpectrum
+	FeatureCompressor
 )
 
 type FeatureNode interface {
@@ -0,0 +1,28 @@
+package tree
+
+import (
+	"synth/midi"
+	"synth/settings"
+)
+
+// NewFilesNodes loop and tempo override followed by the MIDI files, see midi.FilePlayer
+func NewFilesNodes(files []string) []Node {
+	const kind = settings.SettingUpdateKind
+
+	nodes := []Node{
+		NewSelectorNode("Loop", kind, settings.FileLoop,
+			NewSelectorOption("OFF", "", 0),
+			NewSelectorOption("ON", "", 1),
+		),
+		NewSliderNode("Tempo", kind, settings.FileTempo, 0, 240, 1, formatFileTempo),
+	}
+
+	for i, f := range files {
+		nodes = append(nodes, NewSelectorNode(f, midi.FilePlayKind, uint8(i),
+			NewSelectorOption("Stop", "", 0),
+			NewSelectorOption("Play", "", 1),
+		))
+	}
+
+	return nodes
+}
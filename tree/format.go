@@ -1,6 +1,21 @@
 package tree
 
-import "fmt"
+import (
+	"fmt"
+	"synth/dsp"
+	"synth/preset"
+)
+
+var unitFormats = map[preset.Unit]FormatFunc{
+	preset.UnitSemiTone: formatSemiTon,
+	preset.UnitOctave:   formatOctave,
+	preset.UnitSecond:   formatMillisecond,
+	preset.UnitHertz:    formatHertz,
+	preset.UnitLowHertz: formatLowHertz,
+	preset.UnitCycle:    formatCycle,
+	preset.UnitCent:     formatCent,
+	preset.UnitVoice:    formatVoice,
+}
 
 func formatSemiTon(v float32) string {
 	return fmt.Sprintf("%.2f st", v)
@@ -26,6 +41,22 @@ func formatCycle(v float32) string {
 	return fmt.Sprintf("%.0f%% cycle", v*100)
 }
 
+func formatPercent(v float32) string {
+	return fmt.Sprintf("%.0f%%", v*100)
+}
+
+func formatDecibel(v float32) string {
+	return fmt.Sprintf("%.1f dB", v)
+}
+
+func formatRatio(v float32) string {
+	return fmt.Sprintf("%.1f:1", v)
+}
+
+func formatBpm(v float32) string {
+	return fmt.Sprintf("%.0f bpm", v)
+}
+
 func formatCent(v float32) string {
 	return fmt.Sprintf("%.1f cent", v)
 }
@@ -37,3 +68,65 @@ func formatVoice(v float32) string {
 
 	return fmt.Sprintf("%.0f voices", v)
 }
+
+func formatLowCut(v float32) string {
+	if v <= dsp.EqLowCutOff {
+		return "Off"
+	}
+	return formatHertz(v)
+}
+
+func formatHighCut(v float32) string {
+	if v >= dsp.EqHighCutOff {
+		return "Off"
+	}
+	return formatHertz(v)
+}
+
+func formatQ(v float32) string {
+	return fmt.Sprintf("Q %.2f", v)
+}
+
+var noteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// formatKey MIDI key as a note name, 60 is C4
+func formatKey(v float32) string {
+	k := int(v)
+	return fmt.Sprintf("%s%d", noteNames[k%12], k/12-1)
+}
+
+func formatChannel(v float32) string {
+	if v == 0 {
+		return "Omni"
+	}
+	return fmt.Sprintf("Ch %.0f", v)
+}
+
+func formatInteger(v float32) string {
+	return fmt.Sprintf("%.0f", v)
+}
+
+func formatTranspose(v float32) string {
+	return fmt.Sprintf("%+.0f st", v)
+}
+
+func formatPan(v float32) string {
+	switch {
+	case v < -.005:
+		return fmt.Sprintf("L %.0f%%", -v*100)
+	case v > .005:
+		return fmt.Sprintf("R %.0f%%", v*100)
+	}
+	return "Center"
+}
+
+func formatTune(v float32) string {
+	return fmt.Sprintf("A4 %.1f Hz", v)
+}
+
+func formatFileTempo(v float32) string {
+	if v == 0 {
+		return "File"
+	}
+	return formatBpm(v)
+}
@@ -0,0 +1,40 @@
+package tree
+
+import (
+	"synth/harmony"
+	"synth/preset"
+)
+
+// NewHarmonyNodes scale lock, chord memory and strum of the preset, see harmony.Stage
+func NewHarmonyNodes(label string) Node {
+	const kind = preset.UpdateParameterKind
+
+	roots := make([]*SelectorOption, len(noteNames))
+	for i, n := range noteNames {
+		roots[i] = NewSelectorOption(n, "", float32(i))
+	}
+
+	scales := make([]*SelectorOption, len(harmony.Scales))
+	for i, s := range harmony.Scales {
+		scales[i] = NewSelectorOption(s.Name, "", float32(i))
+	}
+
+	return NewNode(label,
+		NewSelectorNode("Scale lock", kind, preset.HarmonyScaleLock,
+			NewSelectorOption("OFF", "", 0),
+			NewSelectorOption("ON", "", 1),
+		),
+		NewSelectorNode("Root", kind, preset.HarmonyRoot, roots...),
+		NewSelectorNode("Scale", kind, preset.HarmonyScale, scales...),
+		NewSelectorNode("Chord memory", kind, preset.HarmonyChord,
+			NewSelectorOption("OFF", "", preset.ChordOff),
+			NewSelectorOption("ON", "", preset.ChordOn),
+			NewSelectorOption("Learn", "", preset.ChordLearn),
+		),
+		NewParamSliderNode(preset.HarmonyStrum),
+		NewSelectorNode("Strum direction", kind, preset.HarmonyStrumDir,
+			NewSelectorOption("Up", "", 0),
+			NewSelectorOption("Down", "", 1),
+		),
+	)
+}
@@ -0,0 +1,52 @@
+package tree
+
+import (
+	"synth/meter"
+	"synth/msg"
+)
+
+// LevelsNode output levels page, keeps the master safety events,
+// see meter.SafetyEventKind
+type LevelsNode interface {
+	FeatureNode
+
+	Resets() int
+	Limit() float32 // limiter gain reduction in dB
+}
+
+type levelsNode struct {
+	FeatureNode
+	resets int
+	limit  float32
+}
+
+func NewLevelsNode(label string) LevelsNode {
+	return &levelsNode{
+		FeatureNode: NewFeatureNode(label, FeatureLevels),
+	}
+}
+
+func (l *levelsNode) Resets() int {
+	return l.resets
+}
+
+func (l *levelsNode) Limit() float32 {
+	return l.limit
+}
+
+func (l *levelsNode) HandleMessage(m msg.Message) {
+	if m.Kind != meter.SafetyEventKind {
+		return
+	}
+
+	switch m.Key {
+	case meter.SafetyReset:
+		l.resets = int(m.ValF)
+	case meter.SafetyLimit:
+		l.limit = m.ValF
+	}
+}
+
+func (l *levelsNode) AttachMessenger(m *msg.Messenger) {
+	m.RegisterHandler(l)
+}
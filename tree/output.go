@@ -0,0 +1,27 @@
+package tree
+
+import "synth/settings"
+
+// NewMidiOutputNodes out port, channel, parameters echo and thru, see midi.Output
+func NewMidiOutputNodes(label string, ports []string) Node {
+	const kind = settings.SettingUpdateKind
+
+	options := []*SelectorOption{NewSelectorOption("None", "", 0)}
+	for i, p := range ports {
+		options = append(options, NewSelectorOption(p, "", float32(i+1)))
+	}
+
+	return NewNode(label,
+		NewSelectorNode("Port", kind, settings.MidiOutPort, options...),
+		NewSliderNode("Channel", kind, settings.MidiOutChannel, 1, 16, 1, formatChannel),
+		NewSelectorNode("Parameters", kind, settings.MidiOutParams,
+			NewSelectorOption("OFF", "", settings.MidiOutParamsOff),
+			NewSelectorOption("CC", "", settings.MidiOutParamsCC),
+			NewSelectorOption("CC + NRPN", "", settings.MidiOutParamsNRPN),
+		),
+		NewSelectorNode("MIDI thru", kind, settings.MidiThru,
+			NewSelectorOption("OFF", "", 0),
+			NewSelectorOption("ON", "", 1),
+		),
+	)
+}
@@ -0,0 +1,61 @@
+package tree
+
+import (
+	"fmt"
+	"synth/preset"
+	"synth/settings"
+)
+
+// NewPerformanceNodes preset switch and program change settings followed by the parts
+func NewPerformanceNodes(presets []string) []Node {
+	const kind = settings.SettingUpdateKind
+
+	return append([]Node{
+		NewSelectorNode("Preset switch", kind, settings.PresetSwitch,
+			NewSelectorOption("Cut", "", preset.SwitchCut),
+			NewSelectorOption("Ring out", "", preset.SwitchRingOut),
+			NewSelectorOption("Crossfade", "", preset.SwitchCrossfade),
+		),
+		NewSliderNode("Crossfade time", kind, settings.PresetSwitchTime, .05, 4, .05, formatMillisecond),
+		NewSliderNode("Program channel", kind, settings.ProgramChannel, 0, 16, 1, formatChannel),
+	}, NewPartsNodes(presets)...)
+}
+
+// NewPartsNodes one page per multitimbral part, the first part plays the
+// edited preset, the others pick theirs
+func NewPartsNodes(presets []string) []Node {
+	const kind = settings.SettingUpdateKind
+
+	nodes := make([]Node, settings.MaxParts)
+	for i := range nodes {
+		key := func(param int) uint8 { return settings.PartKey(i, param) }
+
+		children := []Node{
+			NewSelectorNode("Status", kind, key(settings.PartEnabled),
+				NewSelectorOption("OFF", "", 0),
+				NewSelectorOption("ON", "", 1),
+			),
+		}
+		if i > 0 {
+			options := make([]*SelectorOption, len(presets))
+			for j, p := range presets {
+				options[j] = NewSelectorOption(p, "", float32(j))
+			}
+			children = append(children, NewSelectorNode("Preset", kind, key(settings.PartPreset), options...))
+		}
+		children = append(children,
+			NewSliderNode("Channel", kind, key(settings.PartChannel), 0, 16, 1, formatChannel),
+			NewSliderNode("Lowest key", kind, key(settings.PartKeyLow), 0, 127, 1, formatKey),
+			NewSliderNode("Highest key", kind, key(settings.PartKeyHigh), 0, 127, 1, formatKey),
+			NewSliderNode("Lowest velocity", kind, key(settings.PartVelLow), 0, 127, 1, formatInteger),
+			NewSliderNode("Highest velocity", kind, key(settings.PartVelHigh), 0, 127, 1, formatInteger),
+			NewSliderNode("Transpose", kind, key(settings.PartTranspose), -48, 48, 1, formatTranspose),
+			NewSliderNode("Volume", kind, key(settings.PartVolume), 0, 2, .01, formatPercent),
+			NewSliderNode("Pan", kind, key(settings.PartPan), -1, 1, .01, formatPan),
+		)
+
+		nodes[i] = NewNode(fmt.Sprintf("Part %d", i+1), children...)
+	}
+
+	return nodes
+}
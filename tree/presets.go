@@ -0,0 +1,51 @@
+package tree
+
+import (
+	"synth/msg"
+	"synth/preset"
+)
+
+// SelectionNode a list whose cursor follows the selected child, -1 none
+type SelectionNode interface {
+	Node
+	Selected() int
+}
+
+type presetsNode struct {
+	Node
+	presets []string
+	current int
+}
+
+// NewPresetsNode presets page following the loaded preset, see preset.PresetLoadedKind
+func NewPresetsNode(label string, presets []string) SelectionNode {
+	p := &presetsNode{
+		Node:    NewNode(label, NewPresetsNodes(presets)...),
+		presets: presets,
+		current: -1,
+	}
+
+	p.AttachPreview(func() (string, string) {
+		if p.current < 0 || p.current >= len(p.presets) {
+			return "", ""
+		}
+		return p.presets[p.current], ""
+	})
+
+	return p
+}
+
+func (p *presetsNode) Selected() int {
+	return p.current
+}
+
+func (p *presetsNode) HandleMessage(m msg.Message) {
+	if m.Kind == preset.PresetLoadedKind {
+		p.current = int(m.ValF)
+	}
+}
+
+func (p *presetsNode) AttachMessenger(m *msg.Messenger) {
+	p.Node.AttachMessenger(m)
+	m.RegisterHandler(p)
+}
@@ -0,0 +1,106 @@
+package tree
+
+import (
+	"synth/dsp"
+	"synth/midi"
+	"synth/msg"
+	"synth/settings"
+)
+
+// ScopeNode oscilloscope display, its options and the last played note
+type ScopeNode interface {
+	FeatureNode
+
+	TriggerMode() int // see scope.TriggerMode
+	TriggerLevel() float32
+	View() int
+	Frozen() bool
+
+	// NoteFreq frequency of the last played note, 0 if none
+	NoteFreq() float64
+}
+
+// Scope views
+const (
+	ScopeViewMono = iota
+	ScopeViewStereo
+	ScopeViewXY
+)
+
+type scopeNode struct {
+	FeatureNode
+	trigger, level, view, freeze ValueNode
+	note                         int
+}
+
+// NewScopeNodes oscilloscope page followed by its options, the options are settings
+func NewScopeNodes(label string) Node {
+	trigger := NewSelectorNode("Trigger", settings.SettingUpdateKind, settings.ScopeTrigger,
+		NewSelectorOption("Free", "", 0),
+		NewSelectorOption("Rising", "", 1),
+		NewSelectorOption("Falling", "", 2),
+		NewSelectorOption("Pitch", "", 3),
+	)
+	level := NewSliderNode("Trigger level", settings.SettingUpdateKind, settings.ScopeLevel, -1, 1, .01, nil)
+	view := NewSelectorNode("View", settings.SettingUpdateKind, settings.ScopeView,
+		NewSelectorOption("Mono", "", ScopeViewMono),
+		NewSelectorOption("L / R", "", ScopeViewStereo),
+		NewSelectorOption("XY", "", ScopeViewXY),
+	)
+	freeze := NewSelectorNode("Freeze", settings.SettingUpdateKind, settings.ScopeFreeze,
+		NewSelectorOption("Off", "", 0),
+		NewSelectorOption("On", "", 1),
+	)
+
+	display := &scopeNode{
+		FeatureNode: NewFeatureNode("Display", FeatureOscilloscope),
+		trigger:     trigger,
+		level:       level,
+		view:        view,
+		freeze:      freeze,
+		note:        -1,
+	}
+
+	parent := NewNode(label, display, trigger, level, view, freeze)
+	parent.AttachPreview(func() (string, string) {
+		if display.Frozen() {
+			return "Frozen", ""
+		}
+		return trigger.(SelectorNode).CurrentOption().Label(), ""
+	})
+
+	return parent
+}
+
+func (s *scopeNode) TriggerMode() int {
+	return int(s.trigger.Val())
+}
+
+func (s *scopeNode) TriggerLevel() float32 {
+	return s.level.Val()
+}
+
+func (s *scopeNode) View() int {
+	return int(s.view.Val())
+}
+
+func (s *scopeNode) Frozen() bool {
+	return s.freeze.Val() != 0
+}
+
+func (s *scopeNode) NoteFreq() float64 {
+	if s.note < 0 {
+		return 0
+	}
+	return float64(dsp.KeyFreq(s.note))
+}
+
+func (s *scopeNode) HandleMessage(m msg.Message) {
+	if m.Kind == midi.NoteOnKind && m.Val8 > 0 {
+		s.note = int(m.Key)
+	}
+}
+
+func (s *scopeNode) AttachMessenger(m *msg.Messenger) {
+	m.RegisterHandler(s)
+}
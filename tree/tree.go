@@ -6,7 +6,10 @@ import (
 	"synth/settings"
 )
 
-func NewTree(presets []string) Node {
+// NewTree cpu: profiler entries for the CPU page, nil when profiling is disabled,
+// tunings: names of the tuning.Tuner tunings, files: names of the midi.FilePlayer files,
+// outPorts: names of the midi.Output ports, devices: the midi.Listener input devices
+func NewTree(presets []string, cpu []string, tunings []string, files []string, outPorts []string, devices DeviceLister) Node {
 	tree := NewNode("",
 		NewNode("Oscillators",
 			NewOscillatorNode("Osc 01", preset.Osc0Shape, preset.Osc0Detune, preset.Osc0Gain, preset.Osc0Phase, preset.Osc0Pw),
@@ -20,12 +23,12 @@ func NewTree(presets []string) Node {
 					NewSelectorOption("Blue", "", dsp.NoiseBlue),
 					NewSelectorOption("Gaussian", "", dsp.NoiseGaussian),
 				),
-				NewSliderNode("Gain", preset.UpdateParameterKind, preset.NoiseGain, 0, 1, .01, nil),
+				NewParamSliderNode(preset.NoiseGain),
 			),
 			NewNode("Sub",
 				NewWaveFormNode(preset.SubOscShape),
-				NewSliderNode("Gain", preset.UpdateParameterKind, preset.SubOscGain, 0, 1, .01, nil),
-				NewSliderNode("Transpose", preset.UpdateParameterKind, preset.SubOscTranspose, -48, 48, 12, formatOctave),
+				NewParamSliderNode(preset.SubOscGain),
+				NewParamSliderNode(preset.SubOscTranspose),
 			),
 		),
 		NewNode("Modulation",
@@ -38,26 +41,29 @@ func NewTree(presets []string) Node {
 			NewAdsrNode("ADSR 02", preset.Adsr1Attack, preset.Adsr1Decay, preset.Adsr1Sustain, preset.Adsr1Release),
 			NewAdsrNode("ADSR 03", preset.Adsr2Attack, preset.Adsr2Decay, preset.Adsr2Sustain, preset.Adsr2Release),
 		),
+		NewNode("Macros",
+			NewMacrosNodes()...,
+		),
 		NewNode("Effects",
 			NewNode("Feedback delay",
 				NewOnOffNode(preset.FBOnOff),
-				NewSliderNode("Delay", preset.UpdateParameterKind, preset.FBDelayParam, 0, 2, .001, formatMillisecond),
-				NewSliderNode("Feedback", preset.UpdateParameterKind, preset.FBFeedBack, 0, 0.95, .01, nil),
-				NewSliderNode("Mix", preset.UpdateParameterKind, preset.FBMix, 0, 1, .01, nil),
-				NewSliderNode("Tone", preset.UpdateParameterKind, preset.FBTone, 200, 8000, 1, formatHertz),
+				NewParamSliderNode(preset.FBDelayParam),
+				NewParamSliderNode(preset.FBFeedBack),
+				NewParamSliderNode(preset.FBMix),
+				NewParamSliderNode(preset.FBTone),
 			),
 			NewNode("Low pass filter",
 				NewOnOffNode(preset.LPFOnOff),
-				NewSliderNode("Cutoff", preset.UpdateParameterKind, preset.LPFCutoff, 20, 20000, 1, formatHertz),
-				NewSliderNode("Resonance", preset.UpdateParameterKind, preset.LPFResonance, 0.01, 10, .01, nil),
+				NewParamSliderNode(preset.LPFCutoff),
+				NewParamSliderNode(preset.LPFResonance),
 			),
 			NewNode("Unison",
 				NewOnOffNode(preset.UnisonOnOff),
-				NewSliderNode("Voices", preset.UpdateParameterKind, preset.UnisonVoices, 1, 16, 1, formatVoice),
-				NewSliderNode("Pan spread", preset.UpdateParameterKind, preset.UnisonPanSpread, 0, 1, .01, nil),
-				NewSliderNode("Phase spread", preset.UpdateParameterKind, preset.UnisonPhaseSpread, 0, 1, .01, formatCycle),
-				NewSliderNode("Detune spread", preset.UpdateParameterKind, preset.UnisonDetuneSpread, 0, 100, .1, formatCent),
-				NewSliderNode("Curve gamma", preset.UpdateParameterKind, preset.UnisonCurveGamma, 0.1, 4, .1, nil),
+				NewParamSliderNode(preset.UnisonVoices),
+				NewParamSliderNode(preset.UnisonPanSpread),
+				NewParamSliderNode(preset.UnisonPhaseSpread),
+				NewParamSliderNode(preset.UnisonDetuneSpread),
+				NewParamSliderNode(preset.UnisonCurveGamma),
 			),
 		),
 		NewNode("Voices",
@@ -66,21 +72,34 @@ func NewTree(presets []string) Node {
 				NewSelectorOption("Lowest pitch", "", dsp.PolyStealLowest),
 				NewSelectorOption("Highest pitch", "", dsp.PolyStealHighest),
 			),
-			NewSliderNode("Active voices", preset.UpdateParameterKind, preset.VoicesActive, 1, preset.MaxVoices, 1, formatVoice),
-			NewSliderNode("Pitch glide", preset.UpdateParameterKind, preset.VoicesPitchGlide, 0, 1, .001, formatMillisecond),
-			NewSliderNode("Gain", preset.UpdateParameterKind, preset.VoicesGain, 0, 1, .01, nil),
-			NewSliderNode("Pitch", preset.UpdateParameterKind, preset.VoicesPitch, -48, 48, .01, formatSemiTon),
+			NewParamSliderNode(preset.VoicesActive),
+			NewParamSliderNode(preset.VoicesPitchGlide),
+			NewParamSliderNode(preset.VoicesGain),
+			NewParamSliderNode(preset.VoicesPitch),
 		),
+		NewHarmonyNodes("Harmony"),
 		NewNode("Visualizer",
-			NewFeatureNode("Spectrum", 0), // todo implement spectrum analyzer
-			NewFeatureNode("Oscilloscope", FeatureOscilloscope),
+			NewFeatureNode("Spectrum", FeatureSpectrum),
+			NewScopeNodes("Oscilloscope"),
+			NewLevelsNode("Levels"),
+			NewCpuNode("CPU", cpu),
+		),
+		NewPresetsNode("Presets", presets),
+		NewNode("MIDI files",
+			NewFilesNodes(files)...,
 		),
-		NewNode("Presets",
-			NewPresetsNodes(presets)...,
+		NewNode("Performance",
+			NewPerformanceNodes(presets)...,
 		),
 		NewNode("Settings",
 			NewSliderNode("Master gain", settings.SettingUpdateKind, settings.MasterGain, 0, 3, .01, nil),
 			NewSliderNode("Pitch bend range", settings.SettingUpdateKind, settings.PitchBendRange, 1, 24, 1, formatSemiTon),
+			NewTuningNodes("Tuning", tunings),
+			NewSliderNode("Output ceiling", settings.SettingUpdateKind, settings.MasterCeiling, -12, 0, .1, formatDecibel),
+			NewMasterBusNodes("Master bus"),
+			NewMasterEqNodes("Master EQ"),
+			NewMidiDevicesNodes("MIDI", devices),
+			NewMidiOutputNodes("MIDI output", outPorts),
 		),
 	)
 
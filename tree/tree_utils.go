@@ -8,13 +8,23 @@ import (
 func NewOscillatorNode(label string, shape, detune, gain, phase, pw uint8) Node {
 	return NewNode(label,
 		NewWaveFormNode(shape),
-		NewSliderNode("Detune", preset.UpdateParameterKind, detune, -100, 100, .01, formatSemiTon),
-		NewSliderNode("Gain", preset.UpdateParameterKind, gain, 0, 1, .01, nil),
-		NewSliderNode("Phase", preset.UpdateParameterKind, phase, 0, 1, .01, formatCycle),
-		NewSliderNode("Pulse width", preset.UpdateParameterKind, pw, 0.01, 0.5, .01, nil),
+		NewParamSliderNode(detune),
+		NewParamSliderNode(gain),
+		NewParamSliderNode(phase),
+		NewParamSliderNode(pw),
 	)
 }
 
+// NewParamSliderNode builds the slider of a preset parameter from its metadata
+func NewParamSliderNode(id uint8) SliderNode {
+	m := preset.GetParamMeta(id)
+	if m == nil {
+		panic(fmt.Sprintf("no metadata for parameter %d", id))
+	}
+
+	return NewSliderNode(m.Name, preset.UpdateParameterKind, m.ID, m.Min, m.Max, m.Step, unitFormats[m.Unit])
+}
+
 func NewWaveFormNode(key uint8) Node {
 	return NewSelectorNode("Waveform", preset.UpdateParameterKind, key,
 		NewSelectorOption("Sine", "ui/icons/sine_wave", 0),
@@ -26,10 +36,10 @@ func NewWaveFormNode(key uint8) Node {
 
 func NewAdsrNode(label string, att, dec, sus, rel uint8, children ...Node) Node {
 	n := NewNode(label,
-		NewSliderNode("Attack", preset.UpdateParameterKind, att, 0, 10, .001, formatMillisecond),
-		NewSliderNode("Decay", preset.UpdateParameterKind, dec, 0, 10, .001, formatMillisecond),
-		NewSliderNode("Sustain", preset.UpdateParameterKind, sus, 0, 1, .01, nil),
-		NewSliderNode("Release", preset.UpdateParameterKind, rel, 0, 10, .001, formatMillisecond),
+		NewParamSliderNode(att),
+		NewParamSliderNode(dec),
+		NewParamSliderNode(sus),
+		NewParamSliderNode(rel),
 	)
 
 	for _, c := range children {
@@ -61,8 +71,8 @@ func NewPresetsNodes(presets []string) []Node {
 func NewLfoNode(label string, shape, rate, phase uint8) Node {
 	return NewNode(label,
 		NewWaveFormNode(shape),
-		NewSliderNode("Rate", preset.UpdateParameterKind, rate, 0.01, 20, .01, formatLowHertz),
-		NewSliderNode("Phase", preset.UpdateParameterKind, phase, 0, 1, .01, formatCycle),
+		NewParamSliderNode(rate),
+		NewParamSliderNode(phase),
 	)
 }
 
@@ -82,31 +92,11 @@ func NewModulationMatrixNode(label string) Node {
 			),
 			NewRedirectionNode("Destination new"),
 			NewSelectorNode("Destination", preset.ModulationUpdateKind, preset.ModKeysSpacing*i+preset.ModParamDst, // TODO remove already assigned destinations with the same source
-				NewSelectorOption("NONE", "", preset.ParamNone),
-				NewSelectorOption("Osc 1 > Gain", "", preset.Osc0Gain),
-				NewSelectorOption("Osc 1 > Phase", "", preset.Osc0Phase),
-				NewSelectorOption("Osc 1 > Detune", "", preset.Osc0Detune),
-				NewSelectorOption("Osc 1 > Pw", "", preset.Osc0Pw),
-				NewSelectorOption("Osc 1 > Gain", "", preset.Osc0Gain),
-				NewSelectorOption("Osc 2 > Gain", "", preset.Osc1Gain),
-				NewSelectorOption("Osc 2 > Phase", "", preset.Osc1Phase),
-				NewSelectorOption("Osc 2 > Detune", "", preset.Osc1Detune),
-				NewSelectorOption("Osc 2 > Pw", "", preset.Osc1Pw),
-				NewSelectorOption("Osc 2 > Gain", "", preset.Osc1Gain),
-				NewSelectorOption("Osc 3 > Gain", "", preset.Osc2Gain),
-				NewSelectorOption("Osc 3 > Phase", "", preset.Osc2Phase),
-				NewSelectorOption("Osc 3 > Detune", "", preset.Osc2Detune),
-				NewSelectorOption("Osc 3 > Pw", "", preset.Osc2Pw),
-				NewSelectorOption("Osc 3 > Gain", "", preset.Osc2Gain),
-				NewSelectorOption("Voices > Pitch", "", preset.VoicesPitch),
-				NewSelectorOption("LPF > Cutoff", "", preset.LPFCutoff),
-				NewSelectorOption("LPF > Resonance", "", preset.LPFResonance),
+				NewModDestinationOptions()...,
 			),
 			NewSliderNode("Amount", preset.ModulationUpdateKind, preset.ModKeysSpacing*i+preset.ModParamAmt, -1000, 1000, .01, formatSemiTon),
 			NewSelectorNode("Shape", preset.ModulationUpdateKind, preset.ModKeysSpacing*i+preset.ModParamShp,
-				NewSelectorOption("Linear", "", preset.ModShapeLinear),
-				NewSelectorOption("Exponential", "", preset.ModShapeExponential),
-				NewSelectorOption("Logarithmic", "", preset.ModShapeLogarithmic),
+				NewModShapeOptions()...,
 			),
 		)
 
@@ -126,3 +116,81 @@ func NewModulationMatrixNode(label string) Node {
 
 	return matrix
 }
+
+// NewModDestinationOptions lists every modulation destination, built from the parameters metadata
+func NewModDestinationOptions() []*SelectorOption {
+	dsts := preset.ModDestinations()
+
+	opts := make([]*SelectorOption, 0, len(dsts)+1)
+	opts = append(opts, NewSelectorOption("NONE", "", preset.ParamNone))
+	for _, m := range dsts {
+		opts = append(opts, NewSelectorOption(m.Label(), "", float32(m.ID)))
+	}
+
+	return opts
+}
+
+// NewModShapeOptions lists the curves shared by mod slots and macro targets
+func NewModShapeOptions() []*SelectorOption {
+	return []*SelectorOption{
+		NewSelectorOption("Linear", "", preset.ModShapeLinear),
+		NewSelectorOption("Exponential", "", preset.ModShapeExponential),
+		NewSelectorOption("Logarithmic", "", preset.ModShapeLogarithmic),
+	}
+}
+
+func NewMacrosNodes() []Node {
+	nodes := make([]Node, preset.Macros)
+	for i := range nodes {
+		nodes[i] = NewMacroNode(i)
+	}
+
+	return nodes
+}
+
+func NewMacroNode(macro int) Node {
+	m := preset.GetParamMeta(uint8(preset.Macro0 + macro))
+	macroNode := NewNode(m.Name,
+		NewSliderNode("Value", preset.UpdateParameterKind, m.ID, m.Min, m.Max, m.Step, unitFormats[m.Unit]),
+	)
+
+	for i := 0; i < preset.MacroTargets; i++ {
+		key := uint8(preset.MacroKeysSpacing*macro + preset.MacroTargetSpacing*i)
+		targetNode := NewNode(fmt.Sprintf("Target %d", i+1),
+			NewSelectorNode("Destination", preset.MacroUpdateKind, key+preset.MacroParamDst,
+				NewMacroDestinationOptions()...,
+			),
+			NewSliderNode("Min", preset.MacroUpdateKind, key+preset.MacroParamMin, -1, 1, .01, formatPercent),
+			NewSliderNode("Max", preset.MacroUpdateKind, key+preset.MacroParamMax, -1, 1, .01, formatPercent),
+			NewSelectorNode("Curve", preset.MacroUpdateKind, key+preset.MacroParamCrv,
+				NewModShapeOptions()...,
+			),
+		)
+
+		targetNode.AttachPreview(func() (string, string) {
+			dst := targetNode.QueryAll("Destination")[0].(SelectorNode)
+			if dst.Val() != preset.ParamNone {
+				return dst.CurrentOption().Label(), ""
+			}
+
+			return "", "EMPTY"
+		})
+
+		macroNode.Append(targetNode)
+	}
+
+	return macroNode
+}
+
+// NewMacroDestinationOptions lists every macro destination, built from the parameters metadata
+func NewMacroDestinationOptions() []*SelectorOption {
+	dsts := preset.MacroDestinations()
+
+	opts := make([]*SelectorOption, 0, len(dsts)+1)
+	opts = append(opts, NewSelectorOption("NONE", "", preset.ParamNone))
+	for _, m := range dsts {
+		opts = append(opts, NewSelectorOption(m.Label(), "", float32(m.ID)))
+	}
+
+	return opts
+}
@@ -0,0 +1,20 @@
+package tree
+
+import "synth/settings"
+
+// NewTuningNodes scale selection, master tune, transpose and fine tune, see tuning.Tuner
+func NewTuningNodes(label string, tunings []string) Node {
+	const kind = settings.SettingUpdateKind
+
+	options := make([]*SelectorOption, len(tunings))
+	for i, t := range tunings {
+		options[i] = NewSelectorOption(t, "", float32(i))
+	}
+
+	return NewNode(label,
+		NewSelectorNode("Scale", kind, settings.Tuning, options...),
+		NewSliderNode("Master tune", kind, settings.MasterTune, 415, 466, .1, formatTune),
+		NewSliderNode("Transpose", kind, settings.MasterTranspose, -24, 24, 1, formatTranspose),
+		NewSliderNode("Fine tune", kind, settings.MasterFineTune, -100, 100, .5, formatCent),
+	)
+}
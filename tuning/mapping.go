@@ -0,0 +1,170 @@
+package tuning
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+var ErrInvalidMapping = errors.New("invalid keyboard mapping")
+
+// Unmapped key of a Mapping, does not sound
+const Unmapped = -1
+
+// Mapping Scala keyboard mapping (.kbm), see
+// https://www.huygens-fokker.org/scala/help.htm#mappings
+type Mapping struct {
+	First, Last int     // retuned MIDI keys
+	Middle      int     // key of the first entry of Keys, scale degree 0
+	Reference   int     // key tuned to Frequency
+	Frequency   float64 // Hz
+	Octave      int     // scale degree of the formal octave, between two repeats of Keys
+	Keys        []int   // scale degree per key from Middle, Unmapped, empty maps every key linearly
+}
+
+// DefaultMapping every key on the next degree, degree 0 on C4 (60) and A4 (69) at 440 Hz
+func DefaultMapping(s *Scale) *Mapping {
+	return &Mapping{
+		First:     0,
+		Last:      127,
+		Middle:    60,
+		Reference: 69,
+		Frequency: 440,
+		Octave:    len(s.Cents),
+	}
+}
+
+func LoadMapping(path string) (*Mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseMapping(f)
+}
+
+// ParseMapping reads the Scala keyboard mapping format, entries missing at
+// the end of the map are unmapped
+func ParseMapping(r io.Reader) (*Mapping, error) {
+	var fields []string
+	for _, line := range scalaLines(r) {
+		if line != "" {
+			fields = append(fields, firstField(line))
+		}
+	}
+	if len(fields) < 7 {
+		return nil, fmt.Errorf("%w: expected 7 header lines, got %d", ErrInvalidMapping, len(fields))
+	}
+
+	var header [7]int
+	for i, f := range fields[:7] {
+		if i == 5 {
+			continue
+		}
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad header line %d %q", ErrInvalidMapping, i+1, f)
+		}
+		header[i] = v
+	}
+	freq, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil || freq <= 0 {
+		return nil, fmt.Errorf("%w: bad reference frequency %q", ErrInvalidMapping, fields[5])
+	}
+
+	size := header[0]
+	if size < 0 {
+		return nil, fmt.Errorf("%w: negative map size", ErrInvalidMapping)
+	}
+
+	m := &Mapping{
+		First:     header[1],
+		Last:      header[2],
+		Middle:    header[3],
+		Reference: header[4],
+		Frequency: freq,
+		Octave:    header[6],
+		Keys:      make([]int, size),
+	}
+
+	entries := fields[7:]
+	for i := range m.Keys {
+		m.Keys[i] = Unmapped
+		if i >= len(entries) || entries[i] == "x" || entries[i] == "X" {
+			continue
+		}
+		d, err := strconv.Atoi(entries[i])
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%w: bad map entry %q", ErrInvalidMapping, entries[i])
+		}
+		m.Keys[i] = d
+	}
+
+	return m, nil
+}
+
+// degree scale degree of the key, unbounded, false if unmapped
+func (m *Mapping) degree(key int) (int, bool) {
+	d := key - m.Middle
+	if len(m.Keys) == 0 {
+		return d, true
+	}
+
+	n := len(m.Keys)
+	entry := m.Keys[mod(d, n)]
+	if entry == Unmapped {
+		return 0, false
+	}
+	return entry + floorDiv(d, n)*m.Octave, true
+}
+
+// degreeCents pitch of the scale degree from degree 0, unbounded
+func (s *Scale) degreeCents(degree int) float64 {
+	n := len(s.Cents)
+	c := float64(floorDiv(degree, n)) * s.Cents[n-1]
+	if i := mod(degree, n); i > 0 {
+		c += s.Cents[i-1]
+	}
+	return c
+}
+
+// Frequencies of the 128 MIDI keys, 0 for unmapped keys. Pitches are
+// computed for key+transpose, the mapping range applies to the played key.
+func (s *Scale) Frequencies(m *Mapping, transpose int) ([128]float64, error) {
+	var freqs [128]float64
+
+	ref, ok := m.degree(m.Reference)
+	if !ok {
+		return freqs, fmt.Errorf("%w: reference key %d is unmapped", ErrInvalidMapping, m.Reference)
+	}
+	refCents := s.degreeCents(ref)
+
+	for key := range freqs {
+		if key < m.First || key > m.Last {
+			continue
+		}
+		d, ok := m.degree(key + transpose)
+		if !ok {
+			continue
+		}
+		freqs[key] = m.Frequency * math.Exp2((s.degreeCents(d)-refCents)/1200)
+	}
+
+	return freqs, nil
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func mod(a, b int) int {
+	return a - floorDiv(a, b)*b
+}
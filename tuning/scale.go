@@ -0,0 +1,124 @@
+package tuning
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidScale = errors.New("invalid scale")
+
+// Scale Scala scale (.scl), degrees in cents from the implicit 1/1, the last
+// one is the period (usually the octave)
+type Scale struct {
+	Description string
+	Cents       []float64
+}
+
+// EqualTemperament scale of n equal steps per octave
+func EqualTemperament(n int) *Scale {
+	s := &Scale{Description: fmt.Sprintf("%d-TET", n), Cents: make([]float64, n)}
+	for i := range s.Cents {
+		s.Cents[i] = 1200 * float64(i+1) / float64(n)
+	}
+	return s
+}
+
+func LoadScale(path string) (*Scale, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseScale(f)
+}
+
+// ParseScale reads the Scala scale format, see https://www.huygens-fokker.org/scala/scl_format.html
+func ParseScale(r io.Reader) (*Scale, error) {
+	lines := scalaLines(r)
+
+	s := &Scale{}
+	n := -1
+	for i, line := range lines {
+		switch i {
+		case 0:
+			s.Description = line
+		case 1:
+			count, err := strconv.Atoi(firstField(line))
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("%w: bad note count %q", ErrInvalidScale, line)
+			}
+			n = count
+			s.Cents = make([]float64, 0, n)
+		default:
+			if len(s.Cents) == n {
+				continue
+			}
+			c, err := parsePitch(firstField(line))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidScale, err)
+			}
+			s.Cents = append(s.Cents, c)
+		}
+	}
+
+	if n < 1 || len(s.Cents) != n {
+		return nil, fmt.Errorf("%w: expected %d notes, got %d", ErrInvalidScale, n, len(s.Cents))
+	}
+	if s.Cents[n-1] <= 0 {
+		return nil, fmt.Errorf("%w: period must be above 1/1", ErrInvalidScale)
+	}
+
+	return s, nil
+}
+
+// parsePitch cents if the value has a period, a ratio a/b or an integer otherwise
+func parsePitch(v string) (float64, error) {
+	if strings.Contains(v, ".") {
+		c, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad cents %q", v)
+		}
+		return c, nil
+	}
+
+	num, den, found := strings.Cut(v, "/")
+	a, err := strconv.ParseUint(num, 10, 64)
+	b := uint64(1)
+	if err == nil && found {
+		b, err = strconv.ParseUint(den, 10, 64)
+	}
+	if err != nil || a == 0 || b == 0 {
+		return 0, fmt.Errorf("bad ratio %q", v)
+	}
+
+	return 1200 * math.Log2(float64(a)/float64(b)), nil
+}
+
+// scalaLines lines without comments, the description line may be empty
+func scalaLines(r io.Reader) []string {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// firstField text before the first blank, the rest of a line is a comment
+func firstField(line string) string {
+	if f := strings.Fields(line); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
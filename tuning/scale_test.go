@@ -0,0 +1,186 @@
+package tuning
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"synth/dsp"
+	"testing"
+)
+
+// meanquar.scl, example of the Scala scale format documentation
+const meantone = `! meanquar.scl
+!
+1/4-comma meantone scale. Pietro Aaron's temperament (1523)
+ 12
+!
+ 76.04900
+ 193.15686
+ 310.26471
+ 5/4
+ 503.42157
+ 579.47057
+ 696.57843
+ 25/16
+ 889.73529
+ 1006.84314
+ 1082.89214
+ 2/1
+`
+
+// Just major scale on the white keys, C4 on degree 0 and A4 at 440 Hz
+const justMajor = `! just major
+Ptolemy's intense diatonic
+7
+9/8
+5/4
+4/3
+3/2
+5/3
+15/8
+2
+`
+
+const whiteKeys = `! white keys
+12
+0
+127
+60
+69
+440.0
+7
+! Mapping
+0
+x
+1
+x
+2
+3
+x
+4
+x
+5
+x
+6
+`
+
+func TestParseScale(t *testing.T) {
+	s, err := ParseScale(strings.NewReader(meantone))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Description != "1/4-comma meantone scale. Pietro Aaron's temperament (1523)" {
+		t.Fatalf("unexpected description %q", s.Description)
+	}
+	if len(s.Cents) != 12 {
+		t.Fatalf("expected 12 notes, got %d", len(s.Cents))
+	}
+
+	for _, test := range []struct {
+		degree int
+		exp    float64
+	}{
+		{0, 76.049},
+		{3, 386.3137}, // 5/4
+		{7, 772.6274}, // 25/16
+		{11, 1200},    // 2/1
+	} {
+		if math.Abs(s.Cents[test.degree]-test.exp) > 1e-3 {
+			t.Errorf("degree %d: expected %.4f cents, got %.4f", test.degree+1, test.exp, s.Cents[test.degree])
+		}
+	}
+}
+
+func TestParseScale_Invalid(t *testing.T) {
+	for name, scl := range map[string]string{
+		"missing notes": "desc\n3\n100.0\n200.0\n",
+		"bad ratio":     "desc\n2\n3/0\n2/1\n",
+		"bad count":     "desc\nmany\n",
+		"no period":     "desc\n1\n0.0\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseScale(strings.NewReader(scl)); !errors.Is(err, ErrInvalidScale) {
+				t.Fatalf("expected ErrInvalidScale, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFrequencies_EqualMatchesDsp(t *testing.T) {
+	s := EqualTemperament(12)
+	freqs, err := s.Frequencies(DefaultMapping(s), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := dsp.EqualTable(440)
+	for k, f := range freqs {
+		if math.Abs(f/float64(exp[k])-1) > 1e-6 {
+			t.Fatalf("key %d: expected %f, got %f", k, exp[k], f)
+		}
+	}
+}
+
+func TestFrequencies_Mapping(t *testing.T) {
+	s, err := ParseScale(strings.NewReader(justMajor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ParseMapping(strings.NewReader(whiteKeys))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	freqs, err := s.Frequencies(m, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		key int
+		exp float64
+	}{
+		{69, 440},
+		{60, 264}, // 440 / (5/3)
+		{64, 330}, // 5/4
+		{71, 495}, // 15/8
+		{72, 528}, // octave
+		{48, 132}, // octave below
+		{61, 0},   // black key, unmapped
+		{70, 0},   // black key, unmapped
+		{0, 8.25}, // C-1
+		{127, 12672},
+	} {
+		if math.Abs(freqs[test.key]-test.exp) > 1e-6*test.exp {
+			t.Errorf("key %d: expected %.3f Hz, got %.3f", test.key, test.exp, freqs[test.key])
+		}
+	}
+}
+
+func TestFrequencies_Transpose(t *testing.T) {
+	s := EqualTemperament(12)
+	freqs, err := s.Frequencies(DefaultMapping(s), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(freqs[67]-440) > 1e-9 {
+		t.Fatalf("expected G4 up to 440 Hz, got %f", freqs[67])
+	}
+}
+
+func TestParseMapping_Unmapped(t *testing.T) {
+	// Entries missing at the end of the map are unmapped
+	m, err := ParseMapping(strings.NewReader("3\n0\n127\n60\n60\n261.6\n2\n0\n1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Keys) != 3 || m.Keys[2] != Unmapped {
+		t.Fatalf("expected the last entry unmapped, got %v", m.Keys)
+	}
+
+	if _, err := ParseMapping(strings.NewReader("3\n0\n127\n")); !errors.Is(err, ErrInvalidMapping) {
+		t.Fatalf("expected ErrInvalidMapping, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package tuning
+
+import (
+	"errors"
+	"io/fs"
+	"math"
+	"path/filepath"
+	"strings"
+	"synth/dsp"
+	"synth/msg"
+	"synth/settings"
+
+	"github.com/rs/zerolog"
+)
+
+type tuning struct {
+	name    string
+	scale   *Scale
+	mapping *Mapping
+}
+
+// Tuner builds the key table of the tuning settings and sets it with
+// dsp.SetTuning. Tables are allocated, register it on a messenger processed
+// off the audio thread.
+type Tuner struct {
+	tunings []tuning
+	logger  zerolog.Logger
+
+	current   int
+	a4        float64
+	transpose int
+	fine      float64 // cents
+}
+
+// NewTuner 12-TET followed by the Scala scales of dir, a .kbm file of the
+// same name maps the keys of its scale
+func NewTuner(dir string, logger zerolog.Logger) *Tuner {
+	t := &Tuner{
+		logger: logger,
+		a4:     440,
+	}
+
+	equal := EqualTemperament(12)
+	t.tunings = append(t.tunings, tuning{name: "Equal", scale: equal, mapping: DefaultMapping(equal)})
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.scl"))
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to glob scale files")
+	}
+
+	for _, f := range files {
+		scale, err := LoadScale(f)
+		if err != nil {
+			logger.Error().Err(err).Str("file", f).Msg("failed to load scale")
+			continue
+		}
+
+		mapping := DefaultMapping(scale)
+		kbm := strings.TrimSuffix(f, ".scl") + ".kbm"
+		if m, err := LoadMapping(kbm); err == nil {
+			mapping = m
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			logger.Error().Err(err).Str("file", kbm).Msg("failed to load keyboard mapping, using the default one")
+		}
+
+		name := strings.TrimSuffix(filepath.Base(f), ".scl")
+		t.tunings = append(t.tunings, tuning{name: name, scale: scale, mapping: mapping})
+
+		logger.Info().Str("tuning", name).Str("description", scale.Description).Msg("tuning loaded")
+	}
+
+	return t
+}
+
+// Names of the tunings by settings.Tuning index
+func (t *Tuner) Names() []string {
+	names := make([]string, len(t.tunings))
+	for i, tn := range t.tunings {
+		names[i] = tn.name
+	}
+	return names
+}
+
+// Table key frequencies of the current settings
+func (t *Tuner) Table() (*dsp.KeyTable, error) {
+	tn := t.tunings[min(max(t.current, 0), len(t.tunings)-1)]
+
+	freqs, err := tn.scale.Frequencies(tn.mapping, t.transpose)
+	if err != nil {
+		return nil, err
+	}
+
+	// Master tune moves the reference frequency, 440 Hz being the standard
+	factor := t.a4 / 440 * math.Exp2(t.fine/1200)
+
+	table := &dsp.KeyTable{}
+	for k, f := range freqs {
+		table[k] = float32(f * factor)
+	}
+	return table, nil
+}
+
+func (t *Tuner) HandleMessage(m msg.Message) {
+	if m.Kind != settings.SettingUpdateKind {
+		return
+	}
+
+	switch m.Key {
+	case settings.Tuning:
+		t.current = int(m.ValF)
+	case settings.MasterTune:
+		t.a4 = float64(m.ValF)
+	case settings.MasterTranspose:
+		t.transpose = int(math.Round(float64(m.ValF)))
+	case settings.MasterFineTune:
+		t.fine = float64(m.ValF)
+	default:
+		return
+	}
+
+	table, err := t.Table()
+	if err != nil {
+		t.logger.Error().Err(err).Msg("failed to build the tuning")
+		return
+	}
+	dsp.SetTuning(table)
+}
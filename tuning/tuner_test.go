@@ -0,0 +1,39 @@
+package tuning
+
+import (
+	"math"
+	"synth/msg"
+	"synth/settings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestTuner_Table(t *testing.T) {
+	tuner := NewTuner(t.TempDir(), zerolog.Nop())
+
+	set := func(key uint8, val float32) {
+		tuner.HandleMessage(msg.Message{Kind: settings.SettingUpdateKind, Key: key, ValF: val})
+	}
+
+	for _, test := range []struct {
+		name  string
+		key   uint8
+		val   float32
+		exp69 float64 // A4
+	}{
+		{"master tune", settings.MasterTune, 432, 432},
+		{"fine tune", settings.MasterFineTune, 100, 432 * math.Exp2(1./12)},
+		{"transpose", settings.MasterTranspose, -1, 432}, // fine tune compensated
+	} {
+		set(test.key, test.val)
+
+		table, err := tuner.Table()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(float64(table[69])-test.exp69) > 1e-3 {
+			t.Fatalf("%s: expected A4 at %.3f Hz, got %.3f", test.name, test.exp69, table[69])
+		}
+	}
+}
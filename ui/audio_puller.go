@@ -4,17 +4,24 @@ import "synth/dsp"
 
 type AudioPuller struct {
 	dsp.Node
-	out *AudioQueue
+	out    *AudioQueue
+	levels *dsp.Levels
 }
 
-func NewAudioPuller(src dsp.Node, out *AudioQueue) *AudioPuller {
+// NewAudioPuller taps src into out, levels measures the tapped blocks, optional
+func NewAudioPuller(src dsp.Node, out *AudioQueue, levels *dsp.Levels) *AudioPuller {
 	return &AudioPuller{
-		Node: src,
-		out:  out,
+		Node:   src,
+		out:    out,
+		levels: levels,
 	}
 }
 
 func (a *AudioPuller) Process(block *dsp.Block) {
 	a.Node.Process(block)
 	a.out.TryWrite(*block)
+
+	if a.levels != nil {
+		a.levels.Measure(block)
+	}
 }
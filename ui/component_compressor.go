@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"synth/assets"
+	"synth/tree"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Todo get is from config
+const (
+	ReductionMeterX      = 20
+	ReductionMeterY      = 90
+	ReductionMeterWidth  = 335
+	ReductionMeterHeight = 24
+	ReductionMeterMaxDb  = 24
+)
+
+// ReductionMeter gain reduction of the master bus compressor, growing from the right
+type ReductionMeter struct {
+	face text.Face
+	node tree.CompressorNode
+}
+
+func NewReductionMeter(asts *assets.Loader, node tree.CompressorNode) (*ReductionMeter, error) {
+	face, err := asts.GetFace("ui/meter")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReductionMeter{
+		face: face,
+		node: node,
+	}, nil
+}
+
+func (r *ReductionMeter) Draw(image *ebiten.Image) {
+	red := min(-r.node.Reduction(), ReductionMeterMaxDb)
+
+	opts := &text.DrawOptions{}
+	opts.GeoM.Translate(ReductionMeterX, ReductionMeterY-40)
+	text.Draw(image, fmt.Sprintf("Gain reduction %.1f dB", -red), r.face, opts)
+
+	const x, y, w, h = ReductionMeterX, ReductionMeterY, ReductionMeterWidth, ReductionMeterHeight
+	vector.DrawFilledRect(image, x, y, w, h, levelMeterBg, false)
+
+	rw := w * red / ReductionMeterMaxDb
+	vector.DrawFilledRect(image, x+w-rw, y, rw, h, levelMeterHot, false)
+
+	// Scale, every 6 dB
+	for db := 0; db <= ReductionMeterMaxDb; db += 6 {
+		tx := float32(x + w - w*float32(db)/ReductionMeterMaxDb)
+		vector.StrokeLine(image, tx, y+h, tx, y+h+6, 1, levelMeterPeak, false)
+
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(float64(tx)-8, y+h+8)
+		text.Draw(image, fmt.Sprintf("%d", -db), r.face, opts)
+	}
+}
+
+func (r *ReductionMeter) Update()          {}
+func (r *ReductionMeter) Scroll(delta int) {}
+
+func (r *ReductionMeter) CurrentTarget() tree.Node {
+	return nil
+}
+
+func (r *ReductionMeter) Focus() {}
+func (r *ReductionMeter) Blur()  {}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"synth/assets"
+	"synth/tree"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Todo get is from config
+const (
+	CpuMeterStartX     = 20
+	CpuMeterStartY     = 20
+	CpuMeterRowHeight  = 34
+	CpuMeterLabelWidth = 110
+	CpuMeterBarWidth   = 150
+	CpuMeterBarHeight  = 10
+)
+
+var (
+	cpuMeterBarBg   = color.RGBA{R: 255, G: 255, B: 255, A: 40}
+	cpuMeterBarOver = color.RGBA{R: 230, G: 60, B: 60, A: 255}
+)
+
+// CpuMeter draws the profiler loads, average bar and peak mark, 100% = real-time budget
+type CpuMeter struct {
+	face text.Face
+	node tree.CpuNode
+}
+
+func NewCpuMeter(asts *assets.Loader, node tree.CpuNode) (*CpuMeter, error) {
+	face, err := asts.GetFace("ui/meter")
+	if err != nil {
+		return nil, err
+	}
+
+	return &CpuMeter{
+		face: face,
+		node: node,
+	}, nil
+}
+
+func (c *CpuMeter) Draw(image *ebiten.Image) {
+	loads := c.node.Loads()
+	if len(loads) == 0 {
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(CpuMeterStartX, CpuMeterStartY)
+		text.Draw(image, "Profiling disabled, run with -profile", c.face, opts)
+		return
+	}
+
+	for i, load := range loads {
+		y := float32(CpuMeterStartY + i*CpuMeterRowHeight)
+
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(CpuMeterStartX, float64(y))
+		text.Draw(image, load.Name, c.face, opts)
+
+		// Bar, average fill and peak mark
+		x := float32(CpuMeterStartX + CpuMeterLabelWidth)
+		by := y + 4
+		vector.DrawFilledRect(image, x, by, CpuMeterBarWidth, CpuMeterBarHeight, cpuMeterBarBg, false)
+
+		fill := color.Color(color.White)
+		if load.Peak >= 1 {
+			fill = cpuMeterBarOver
+		}
+		vector.DrawFilledRect(image, x, by, CpuMeterBarWidth*min(load.Avg, 1), CpuMeterBarHeight, fill, false)
+
+		px := x + CpuMeterBarWidth*min(load.Peak, 1)
+		vector.StrokeLine(image, px, by-2, px, by+CpuMeterBarHeight+2, 2, fill, false)
+
+		opts = &text.DrawOptions{}
+		opts.GeoM.Translate(float64(x+CpuMeterBarWidth+10), float64(y))
+		text.Draw(image, fmt.Sprintf("%.1f%% | %.1f%%", load.Avg*100, load.Peak*100), c.face, opts)
+	}
+}
+
+func (c *CpuMeter) Update()          {}
+func (c *CpuMeter) Scroll(delta int) {}
+
+func (c *CpuMeter) CurrentTarget() tree.Node {
+	return nil
+}
+
+func (c *CpuMeter) Focus() {}
+func (c *CpuMeter) Blur()  {}
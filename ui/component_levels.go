@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"synth/assets"
+	"synth/dsp"
+	"synth/tree"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Todo get is from config
+const (
+	LevelMeterStartX    = 20
+	LevelMeterStartY    = 20
+	LevelMeterRowHeight = 70
+	LevelMeterBarWidth  = 300
+	LevelMeterBarHeight = 20
+	LevelMeterMinDb     = -60
+	LevelMeterMaxDb     = 6
+
+	// Compact meter, top right of the background, next to "OUT"
+	CompactMeterX      = 400
+	CompactMeterY      = 39
+	CompactMeterWidth  = 30
+	CompactMeterHeight = 4
+)
+
+var (
+	levelMeterBg   = color.RGBA{R: 255, G: 255, B: 255, A: 40}
+	levelMeterPeak = color.RGBA{R: 255, G: 255, B: 255, A: 110}
+	levelMeterHot  = color.RGBA{R: 240, G: 190, B: 40, A: 255}
+	levelMeterClip = color.RGBA{R: 230, G: 60, B: 60, A: 255}
+
+	compactMeterBack = color.RGBA{R: 0, G: 20, B: 40, A: 200}
+)
+
+// levelX position of a linear level on a meter of width w, dB scale
+func levelX(level float32, w float32) float32 {
+	db := dsp.ToDb(level, LevelMeterMinDb)
+	return w * min(1, (db-LevelMeterMinDb)/(LevelMeterMaxDb-LevelMeterMinDb))
+}
+
+func levelColor(level float32) color.Color {
+	switch {
+	case level > 1:
+		return levelMeterClip
+	case level > 0.5: // -6 dB
+		return levelMeterHot
+	}
+	return color.White
+}
+
+// drawLevel RMS bar, peak bar behind it and hold mark
+func drawLevel(image *ebiten.Image, l dsp.ChannelLevel, x, y, w, h float32) {
+	vector.DrawFilledRect(image, x, y, w, h, levelMeterBg, false)
+	vector.DrawFilledRect(image, x, y, levelX(l.Peak, w), h, levelMeterPeak, false)
+	vector.DrawFilledRect(image, x, y, levelX(l.Rms, w), h, levelColor(l.Rms), false)
+
+	if l.Hold > 0 {
+		hx := x + levelX(l.Hold, w)
+		vector.StrokeLine(image, hx, y, hx, y+h, 2, levelColor(l.Hold), false)
+	}
+}
+
+// LevelMeter peak, RMS and hold of the output per channel with clip and NaN
+// counters and the master safety events
+type LevelMeter struct {
+	face   text.Face
+	node   tree.LevelsNode
+	levels *dsp.Levels
+	input  *dsp.Levels
+}
+
+// NewLevelMeter levels: output meters, input: clip and NaN counters, optional
+func NewLevelMeter(asts *assets.Loader, node tree.LevelsNode, levels, input *dsp.Levels) (*LevelMeter, error) {
+	face, err := asts.GetFace("ui/meter")
+	if err != nil {
+		return nil, err
+	}
+
+	return &LevelMeter{
+		face:   face,
+		node:   node,
+		levels: levels,
+		input:  input,
+	}, nil
+}
+
+func (m *LevelMeter) Draw(image *ebiten.Image) {
+	if m.levels == nil {
+		return
+	}
+
+	for ch, l := range m.levels.Read() {
+		y := float32(LevelMeterStartY + ch*LevelMeterRowHeight)
+
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(LevelMeterStartX, float64(y))
+		text.Draw(image, fmt.Sprintf("%s  peak %.1f dB  rms %.1f dB",
+			[]string{"L", "R"}[ch],
+			dsp.ToDb(l.Hold, LevelMeterMinDb),
+			dsp.ToDb(l.Rms, LevelMeterMinDb),
+		), m.face, opts)
+
+		drawLevel(image, l, LevelMeterStartX, y+22, LevelMeterBarWidth, LevelMeterBarHeight)
+
+		// 0 dBFS mark
+		zx := float32(LevelMeterStartX) + levelX(1, LevelMeterBarWidth)
+		vector.StrokeLine(image, zx, y+18, zx, y+22+LevelMeterBarHeight+4, 1, levelMeterClip, false)
+	}
+
+	opts := &text.DrawOptions{}
+	opts.GeoM.Translate(LevelMeterStartX, LevelMeterStartY+2*LevelMeterRowHeight)
+	counters := m.levels
+	if m.input != nil {
+		counters = m.input
+	}
+	text.Draw(image, fmt.Sprintf("clips %d  nan %d  limit %.1f dB  resets %d",
+		counters.Clips(), counters.NaNs(), m.node.Limit(), m.node.Resets()), m.face, opts)
+}
+
+func (m *LevelMeter) Update()          {}
+func (m *LevelMeter) Scroll(delta int) {}
+
+func (m *LevelMeter) CurrentTarget() tree.Node {
+	return nil
+}
+
+func (m *LevelMeter) Focus() {}
+func (m *LevelMeter) Blur()  {}
+
+// CompactMeter L/R bars drawn in the screen chrome
+type CompactMeter struct {
+	levels *dsp.Levels
+}
+
+func NewCompactMeter(levels *dsp.Levels) *CompactMeter {
+	return &CompactMeter{levels: levels}
+}
+
+func (c *CompactMeter) Draw(screen *ebiten.Image) {
+	// Hide the static meter of the background
+	vector.DrawFilledRect(screen, CompactMeterX-2, CompactMeterY-2,
+		CompactMeterWidth+4, 2*CompactMeterHeight+6, compactMeterBack, false)
+
+	for ch, l := range c.levels.Read() {
+		y := float32(CompactMeterY + ch*(CompactMeterHeight+2))
+		drawLevel(screen, l, CompactMeterX, y, CompactMeterWidth, CompactMeterHeight)
+	}
+}
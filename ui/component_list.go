@@ -43,6 +43,8 @@ type List struct {
 	animT           float64
 	scrollingUp     bool
 	scrollingDown   bool
+
+	followed int // last selection of a tree.SelectionNode
 }
 
 func NewList(asts *assets.Loader, node tree.Node) (*List, error) {
@@ -91,12 +93,18 @@ func NewList(asts *assets.Loader, node tree.Node) (*List, error) {
 	l.cursorPos = 0
 	l.cursorY = float64(l.cursorPos)*ListEntrySpacing + ListPaddingTop
 	l.targetCursorY = l.cursorY
+	l.followed = -1
 
 	return l, nil
 }
 
 func (l *List) Update() {
 	const speed = 0.22 // todo move to config
+
+	if sel, ok := l.node.(tree.SelectionNode); ok && sel.Selected() != l.followed {
+		l.followed = sel.Selected()
+		l.jumpTo(l.followed)
+	}
 	if l.animatingWin {
 		l.animT += speed
 		if l.animT >= 1 {
@@ -216,6 +224,26 @@ func (l *List) moveCursor(dir int) {
 	l.targetCursorY = float64(l.cursorPos)*ListEntrySpacing + ListPaddingTop
 }
 
+// jumpTo moves the cursor to the child at idx, scrolling the window without animation
+func (l *List) jumpTo(idx int) {
+	total := len(l.node.Children())
+	if idx < 0 || idx >= total {
+		return
+	}
+
+	if l.animatingWin {
+		l.windowOffset, l.animT, l.animatingWin = 0, 0, false
+		l.scrollingUp, l.scrollingDown = false, false
+	}
+
+	if idx < l.firstIndex {
+		l.firstIndex = idx
+	} else if idx >= l.firstIndex+l.visible {
+		l.firstIndex = idx - l.visible + 1
+	}
+	l.moveCursor(idx - l.firstIndex - l.cursorPos)
+}
+
 func (l *List) startScroll(offset float64, up bool) {
 	l.animatingWin = true
 	l.targetWinOffset = offset
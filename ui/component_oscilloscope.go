@@ -4,206 +4,169 @@ import (
 	"image/color"
 	"synth/dsp"
 	"synth/tree"
+	"synth/ui/scope"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
+var (
+	scopeLeft  = color.RGBA{R: 120, G: 220, B: 255, A: 255}
+	scopeRight = color.RGBA{R: 255, G: 170, B: 90, A: 255}
+	scopeXY    = color.RGBA{R: 255, G: 255, B: 255, A: 140}
+	scopeLevel = color.RGBA{R: 255, G: 255, B: 255, A: 40}
+)
+
 type Oscilloscope struct {
-	in *AudioQueue
+	in   *AudioQueue
+	node tree.ScopeNode
+	sr   float64
 
-	// Mono sample ring buffer
-	ring    []float32
-	mask    int
-	write   int
-	tmpMono []float32
+	// Sample rings, mono is the trigger source
+	mono, left, right *scope.Ring
+	trigger           scope.Trigger
 
 	// Display params
-	zoom float64 // samples per pixel
-	gain float64 // amplitude scale
-
-	// Trigger params
-	trigger       bool
-	triggerLevel  float32
-	triggerRising bool
+	zoom    float64 // samples per pixel
+	periods int     // periods displayed in pitch mode
+	gain    float64 // amplitude scale
 }
 
 // NewOscilloscope expects a power of two ringSize
-func NewOscilloscope(in *AudioQueue, ringSize int) (*Oscilloscope, error) {
-	n := 1
-	for n < ringSize {
-		n <<= 1
-	}
+func NewOscilloscope(node tree.ScopeNode, in *AudioQueue, sr float64, ringSize int) (*Oscilloscope, error) {
 	return &Oscilloscope{
-		in:            in,
-		ring:          make([]float32, n),
-		mask:          n - 1,
-		tmpMono:       make([]float32, dsp.BlockSize),
-		zoom:          1.0,
-		gain:          1.8,
-		trigger:       true,
-		triggerRising: true,
+		in:      in,
+		node:    node,
+		sr:      sr,
+		mono:    scope.NewRing(ringSize),
+		left:    scope.NewRing(ringSize),
+		right:   scope.NewRing(ringSize),
+		zoom:    1.0,
+		periods: 2,
+		gain:    1.8,
 	}, nil
 }
 
-// Update drains the audio queue into the ring buffer
+// Update drains the audio queue into the rings, dropped while frozen
 func (o *Oscilloscope) Update() {
 	if o.in == nil {
 		return
 	}
 
+	frozen := o.node.Frozen()
 	o.in.Drain(0, func(b dsp.Block) {
-		// Mix L/R to mono
-		for i := 0; i < dsp.BlockSize; i++ {
-			o.tmpMono[i] = 0.5 * (b.L[i] + b.R[i])
+		if frozen {
+			return
 		}
 
-		// Write samples to ring
 		for i := 0; i < dsp.BlockSize; i++ {
-			o.ring[o.write&o.mask] = o.tmpMono[i]
-			o.write++
+			o.mono.Push(0.5 * (b.L[i] + b.R[i]))
 		}
+		o.left.Push(b.L[:]...)
+		o.right.Push(b.R[:]...)
 	})
 }
 
-// Draw renders the oscilloscope waveform
+// Draw renders the waveform of the current view
 func (o *Oscilloscope) Draw(screen *ebiten.Image) {
-	if o.ring == nil || len(o.ring) == 0 {
-		return
-	}
-
 	bds := screen.Bounds()
 	w, h := bds.Dx(), bds.Dy()
 	if w <= 1 || h <= 1 {
 		return
 	}
 
-	// Integer samples-per-pixel step
-	step := int(o.zoom + 0.5)
-	if step < 1 {
-		step = 1
+	o.trigger.Mode = scope.TriggerMode(o.node.TriggerMode())
+	o.trigger.Level = o.node.TriggerLevel()
+	o.trigger.Period = 0
+	if f := o.node.NoteFreq(); f > 0 {
+		o.trigger.Period = o.sr / f
 	}
 
-	// We draw w pixels => w-1 segments => (w-1)*step samples
+	// Samples per pixel, whole periods in pitch mode
 	segments := w - 1
-	totalSamples := step * segments
-	if totalSamples <= 0 {
-		return
+	step := max(o.zoom, 0.1)
+	if o.trigger.Mode == scope.TriggerPitch && o.trigger.Period > 0 {
+		step = float64(o.periods) * o.trigger.Period / float64(segments)
 	}
 
-	// Clamp to ring size
-	if totalSamples > len(o.ring) {
-		segments = len(o.ring) / step
-		if segments <= 0 {
-			return
-		}
-		totalSamples = step * segments
+	span := int(step * float64(segments))
+	if span >= o.mono.Len()/2 {
+		span = o.mono.Len()/2 - 1
+		step = float64(span) / float64(segments)
 	}
-
-	mid := float64(h) / 2
-	scale := mid * o.gain
-
-	searchEnd := o.write // exclusive
-	level := o.triggerLevel
-
-	// Limit backwards search to ring size
-	maxLookback := totalSamples * 2
-	if maxLookback > len(o.ring) {
-		maxLookback = len(o.ring)
+	if span <= 0 {
+		return
 	}
-	searchStart := searchEnd - maxLookback
 
-	// Last sample used must be <= searchEnd-1
-	// windowStart + segments*step <= searchEnd-1
-	lastPossibleStart := searchEnd - 1 - segments*step
-
-	var windowStart int
-
-	if o.trigger {
-		found := false
-
-		// Trigger must leave enough samples after it
-		triggerSearchEnd := lastPossibleStart
-		if triggerSearchEnd <= searchStart {
-			triggerSearchEnd = searchStart
-		}
-
-		prev := o.sampleAt(searchStart)
-		for pos := searchStart + 1; pos <= triggerSearchEnd; pos++ {
-			cur := o.sampleAt(pos)
-
-			if o.triggerRising {
-				if prev < level && cur >= level {
-					windowStart = pos
-					found = true
-					break
-				}
-			} else {
-				if prev > level && cur <= level {
-					windowStart = pos
-					found = true
-					break
-				}
-			}
-			prev = cur
+	start := o.trigger.Start(o.mono, span, 2*span)
+
+	switch o.node.View() {
+	case tree.ScopeViewStereo:
+		quarter := float64(h) / 4
+		o.drawTrace(screen, o.left, start, step, segments, quarter, quarter*o.gain, scopeLeft)
+		o.drawTrace(screen, o.right, start, step, segments, 3*quarter, quarter*o.gain, scopeRight)
+	case tree.ScopeViewXY:
+		o.drawXY(screen, start, span, float64(w)/2, float64(h)/2, float64(h)/2)
+	default:
+		mid := float64(h) / 2
+		if o.trigger.Mode == scope.TriggerRising || o.trigger.Mode == scope.TriggerFalling {
+			y := float32(mid - float64(o.trigger.Level)*mid*o.gain)
+			vector.StrokeLine(screen, 0, y, float32(w), y, 1, scopeLevel, false)
 		}
+		o.drawTrace(screen, o.mono, start, step, segments, mid, mid*o.gain, color.White)
+	}
 
-		if !found {
-			// Fallback to last window before end
-			windowStart = lastPossibleStart
-		}
-	} else {
-		windowStart = lastPossibleStart
+	if o.node.Frozen() {
+		ebitenutil.DebugPrintAt(screen, "FROZEN", 6, 4)
 	}
+}
 
-	// Draw waveform slightly outside the visible area
+// drawTrace waveform of r around the mid height
+func (o *Oscilloscope) drawTrace(screen *ebiten.Image, r *scope.Ring, start int, step float64, segments int, mid, scale float64, clr color.Color) {
+	prev := mid - float64(r.At(start))*scale
 	for x := 0; x < segments; x++ {
-		samplePos0 := windowStart + x*step
-		samplePos1 := windowStart + (x+1)*step
-
-		s0 := o.sampleAt(samplePos0)
-		s1 := o.sampleAt(samplePos1)
-
-		y0 := mid - float64(s0)*scale
-		y1 := mid - float64(s1)*scale
+		s := r.At(start + int(float64(x+1)*step))
+		y := mid - float64(s)*scale
 
 		vector.StrokeLine(
 			screen,
-			float32(x), float32(y0),
-			float32(x+1), float32(y1),
+			float32(x), float32(prev),
+			float32(x+1), float32(y),
 			3.0,
-			color.White,
+			clr,
 			true,
 		)
+		prev = y
 	}
 }
 
-// sampleAt reads a logical sample from the ring
-func (o *Oscilloscope) sampleAt(pos int) float32 {
-	idx := pos & o.mask
-	return o.ring[idx]
+// drawXY Lissajous of the window, L horizontal and R vertical
+func (o *Oscilloscope) drawXY(screen *ebiten.Image, start, span int, cx, cy, radius float64) {
+	scale := radius * o.gain / 2
+	px := cx + float64(o.left.At(start))*scale
+	py := cy - float64(o.right.At(start))*scale
+	for pos := start + 1; pos <= start+span; pos++ {
+		x := cx + float64(o.left.At(pos))*scale
+		y := cy - float64(o.right.At(pos))*scale
+		vector.StrokeLine(screen, float32(px), float32(py), float32(x), float32(y), 1.5, scopeXY, true)
+		px, py = x, y
+	}
 }
 
-// Scroll updates the time zoom factor
+// Scroll updates the time zoom factor, the number of periods in pitch mode
 func (o *Oscilloscope) Scroll(delta int) {
 	if delta == 0 {
 		return
 	}
 
-	step := 0.1
-
-	if delta > 0 {
-		o.zoom -= step * float64(delta)
-	} else {
-		o.zoom -= step * float64(delta)
+	if o.trigger.Mode == scope.TriggerPitch && o.trigger.Period > 0 {
+		o.periods = min(max(o.periods-delta, 1), 16)
+		return
 	}
 
-	if o.zoom < 0.1 {
-		o.zoom = 0.1
-	}
-	if o.zoom > 10.0 {
-		o.zoom = 10.0
-	}
+	o.zoom -= 0.1 * float64(delta)
+	o.zoom = min(max(o.zoom, 0.1), 10.0)
 }
 
 func (o *Oscilloscope) CurrentTarget() tree.Node {
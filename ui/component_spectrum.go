@@ -0,0 +1,275 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"synth/assets"
+	"synth/dsp"
+	"synth/fft"
+	"synth/tree"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Todo get is from config
+const (
+	SpectrumSize       = 4096 // FFT points
+	SpectrumMinFreq    = 20
+	SpectrumMaxFreq    = 20000
+	SpectrumMinDb      = -90
+	SpectrumDecay      = .015 // display height per frame
+	SpectrumHoldFrames = 60
+)
+
+const (
+	spectrumViewBars = iota
+	spectrumViewHold
+	spectrumViewGram
+	spectrumViews
+)
+
+var (
+	spectrumViewNames = [spectrumViews]string{"Spectrum", "Spectrum + hold", "Spectrogram"}
+	spectrumGrid      = color.RGBA{R: 255, G: 255, B: 255, A: 30}
+	spectrumHold      = color.RGBA{R: 240, G: 190, B: 40, A: 255}
+)
+
+// spectrumColumn bins of a display column, interpolated when narrower than a bin
+type spectrumColumn struct {
+	lo, hi int
+	interp bool
+	frac   float32
+}
+
+// Spectrum log frequency FFT analyzer with smoothing, peak hold and spectrogram,
+// scroll to switch view
+type Spectrum struct {
+	in   *AudioQueue
+	face text.Face
+	sr   float64
+
+	fft    *fft.FFT
+	window []float32
+
+	// Mono sample ring buffer, one FFT long
+	ring  []float32
+	write int
+	frame []float32
+	mags  []float32
+	fresh bool
+
+	// Display columns, 0..1 of the dB range
+	columns []spectrumColumn
+	level   []float32
+	hold    []float32
+	holdAge []int
+
+	view    int
+	gram    *ebiten.Image
+	gramPix []byte
+}
+
+// NewSpectrum expects a power of two size
+func NewSpectrum(asts *assets.Loader, in *AudioQueue, sr float64, size int) (*Spectrum, error) {
+	face, err := asts.GetFace("ui/meter")
+	if err != nil {
+		return nil, err
+	}
+
+	f := fft.New(size)
+	n := f.Size()
+
+	s := &Spectrum{
+		in:      in,
+		face:    face,
+		sr:      sr,
+		fft:     f,
+		window:  fft.NewWindow(fft.WindowHann, n),
+		ring:    make([]float32, n),
+		frame:   make([]float32, n),
+		mags:    make([]float32, n/2+1),
+		columns: make([]spectrumColumn, BodyWidth),
+		level:   make([]float32, BodyWidth),
+		hold:    make([]float32, BodyWidth),
+		holdAge: make([]int, BodyWidth),
+		gramPix: make([]byte, BodyWidth*BodyHeight*4),
+	}
+
+	// Log frequency axis
+	binHz := sr / float64(n)
+	for x := range s.columns {
+		k0 := s.freqAt(float64(x)) / binHz
+		k1 := min(s.freqAt(float64(x+1))/binHz, float64(n/2))
+		lo, hi := int(math.Ceil(k0)), int(k1)
+		if hi < lo {
+			// Narrower than a bin, interpolate at the column center
+			c := (k0 + k1) / 2
+			lo, hi = int(c), int(c)+1
+			s.columns[x].interp = true
+			s.columns[x].frac = float32(c - math.Floor(c))
+		}
+		s.columns[x].lo, s.columns[x].hi = lo, min(hi, n/2)
+	}
+
+	return s, nil
+}
+
+// freqAt frequency of a display x position
+func (s *Spectrum) freqAt(x float64) float64 {
+	return SpectrumMinFreq * math.Pow(SpectrumMaxFreq/SpectrumMinFreq, x/BodyWidth)
+}
+
+// xAt display x position of a frequency
+func (s *Spectrum) xAt(f float64) float32 {
+	return float32(BodyWidth * math.Log(f/SpectrumMinFreq) / math.Log(SpectrumMaxFreq/SpectrumMinFreq))
+}
+
+// Update drains the audio queue, analyses the last FFT window and updates the display
+func (s *Spectrum) Update() {
+	if s.in == nil {
+		return
+	}
+
+	s.in.Drain(0, func(b dsp.Block) {
+		for i := 0; i < dsp.BlockSize; i++ {
+			s.ring[s.write] = 0.5 * (b.L[i] + b.R[i])
+			s.write = (s.write + 1) % len(s.ring)
+		}
+		s.fresh = true
+	})
+
+	if !s.fresh {
+		return
+	}
+	s.fresh = false
+
+	// Unroll the ring, oldest first
+	n := copy(s.frame, s.ring[s.write:])
+	copy(s.frame[n:], s.ring[:s.write])
+	s.fft.Spectrum(s.frame, s.window, s.mags)
+
+	for x, col := range s.columns {
+		var mag float32
+		if col.interp {
+			mag = s.mags[col.lo] + (s.mags[col.hi]-s.mags[col.lo])*col.frac
+		} else {
+			for k := col.lo; k <= col.hi; k++ {
+				mag = max(mag, s.mags[k])
+			}
+		}
+
+		v := 1 - dsp.ToDb(mag, SpectrumMinDb)/SpectrumMinDb
+
+		// Smoothing, instant attack, linear fall back
+		s.level[x] = max(v, s.level[x]-SpectrumDecay)
+
+		s.holdAge[x]++
+		if v >= s.hold[x] {
+			s.hold[x] = v
+			s.holdAge[x] = 0
+		} else if s.holdAge[x] > SpectrumHoldFrames {
+			s.hold[x] = max(s.level[x], s.hold[x]-SpectrumDecay)
+		}
+	}
+
+	if s.view == spectrumViewGram {
+		s.scrollGram()
+	}
+}
+
+// scrollGram moves the spectrogram one row up and adds the current spectrum at the bottom
+func (s *Spectrum) scrollGram() {
+	row := BodyWidth * 4
+	copy(s.gramPix, s.gramPix[row:])
+
+	last := s.gramPix[len(s.gramPix)-row:]
+	for x, v := range s.level {
+		c := spectrogramColor(v)
+		last[x*4], last[x*4+1], last[x*4+2], last[x*4+3] = c.R, c.G, c.B, c.A
+	}
+
+	if s.gram == nil {
+		s.gram = ebiten.NewImage(BodyWidth, BodyHeight)
+	}
+	s.gram.WritePixels(s.gramPix)
+}
+
+// spectrogramColor dark blue, cyan then white as v goes from 0 to 1
+func spectrogramColor(v float32) color.RGBA {
+	v = min(max(v, 0), 1)
+	if v < .6 {
+		t := v / .6
+		return color.RGBA{R: 0, G: uint8(200 * t), B: uint8(30 + 200*t), A: uint8(255 * t)}
+	}
+
+	t := (v - .6) / .4
+	return color.RGBA{R: uint8(255 * t), G: uint8(200 + 55*t), B: uint8(230 + 25*t), A: 255}
+}
+
+// Draw renders the current view
+func (s *Spectrum) Draw(screen *ebiten.Image) {
+	bds := screen.Bounds()
+	h := float32(bds.Dy())
+
+	if s.view == spectrumViewGram {
+		if s.gram != nil {
+			screen.DrawImage(s.gram, nil)
+		}
+	} else {
+		for x, v := range s.level {
+			fx := float32(x) + .5
+			vector.StrokeLine(screen, fx, h, fx, h-v*h, 1, color.White, false)
+		}
+
+		if s.view == spectrumViewHold {
+			for x, v := range s.hold {
+				vector.DrawFilledRect(screen, float32(x), h-v*h-1, 1, 2, spectrumHold, false)
+			}
+		}
+	}
+
+	// Frequency grid
+	for _, f := range []float64{100, 1000, 10000} {
+		x := s.xAt(f)
+		vector.StrokeLine(screen, x, 0, x, h, 1, spectrumGrid, false)
+
+		opts := &text.DrawOptions{}
+		opts.GeoM.Translate(float64(x)+3, float64(h)-20)
+		text.Draw(screen, formatFreq(f), s.face, opts)
+	}
+
+	name := spectrumViewNames[s.view]
+	w, _ := text.Measure(name, s.face, 0)
+	opts := &text.DrawOptions{}
+	opts.GeoM.Translate(float64(BodyWidth)-w-6, 4)
+	text.Draw(screen, name, s.face, opts)
+}
+
+func formatFreq(f float64) string {
+	if f >= 1000 {
+		return fmt.Sprintf("%.0fk", f/1000)
+	}
+	return fmt.Sprintf("%.0f", f)
+}
+
+// Scroll cycles through the views
+func (s *Spectrum) Scroll(delta int) {
+	if delta == 0 {
+		return
+	}
+
+	s.view = ((s.view+delta)%spectrumViews + spectrumViews) % spectrumViews
+	if s.view == spectrumViewGram {
+		clear(s.gramPix)
+	}
+}
+
+func (s *Spectrum) CurrentTarget() tree.Node {
+	return nil
+}
+
+func (s *Spectrum) Focus() {}
+func (s *Spectrum) Blur()  {}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"synth/assets"
+	"synth/dsp"
 	"synth/tree"
 )
 
@@ -11,10 +12,18 @@ var ErrorUnknownFeatureType = fmt.Errorf("unknown feature type")
 
 type Components map[tree.Node]Component
 
-func NewComponents(asts *assets.Loader, node tree.Node, audioQ *AudioQueue) (Components, error) {
+// Taps audio outputs feeding the visualizers
+type Taps struct {
+	Audio      *AudioQueue
+	Levels     *dsp.Levels // output
+	Input      *dsp.Levels // before the master safety, clips and NaNs
+	SampleRate float64
+}
+
+func NewComponents(asts *assets.Loader, node tree.Node, taps Taps) (Components, error) {
 	c := make(Components)
 
-	err := c.build(asts, node, audioQ)
+	err := c.build(asts, node, taps)
 	if err != nil {
 		return nil, err
 	}
@@ -22,8 +31,8 @@ func NewComponents(asts *assets.Loader, node tree.Node, audioQ *AudioQueue) (Com
 	return c, nil
 }
 
-func (c Components) build(asts *assets.Loader, node tree.Node, aq *AudioQueue) error {
-	comp, err := c.nodeComponent(asts, node, aq)
+func (c Components) build(asts *assets.Loader, node tree.Node, taps Taps) error {
+	comp, err := c.nodeComponent(asts, node, taps)
 	if err != nil {
 		return err
 	}
@@ -31,7 +40,7 @@ func (c Components) build(asts *assets.Loader, node tree.Node, aq *AudioQueue) e
 	c[node] = comp
 
 	for _, child := range node.Children() {
-		err := c.build(asts, child, aq)
+		err := c.build(asts, child, taps)
 		if err != nil {
 			return err
 		}
@@ -40,16 +49,24 @@ func (c Components) build(asts *assets.Loader, node tree.Node, aq *AudioQueue) e
 	return nil
 }
 
-func (c Components) nodeComponent(asts *assets.Loader, node tree.Node, aq *AudioQueue) (Component, error) {
+func (c Components) nodeComponent(asts *assets.Loader, node tree.Node, taps Taps) (Component, error) {
 	switch node := node.(type) {
 	case tree.SliderNode:
 		return NewSlider(asts, node)
 	case tree.SelectorNode:
 		return NewSelector(asts, node)
+	case tree.CpuNode:
+		return NewCpuMeter(asts, node)
+	case tree.LevelsNode:
+		return NewLevelMeter(asts, node, taps.Levels, taps.Input)
+	case tree.CompressorNode:
+		return NewReductionMeter(asts, node)
+	case tree.ScopeNode:
+		return NewOscilloscope(node, taps.Audio, taps.SampleRate, 16384)
 	case tree.FeatureNode:
 		switch node.Feature() {
-		case tree.FeatureOscilloscope:
-			return NewOscilloscope(aq, 16384)
+		case tree.FeatureSpectrum:
+			return NewSpectrum(asts, taps.Audio, taps.SampleRate, SpectrumSize)
 		default:
 
 			return nil, ErrorUnknownFeatureType
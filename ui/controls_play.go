@@ -3,6 +3,7 @@ package ui
 import (
 	"synth/midi"
 	"synth/msg"
+	"synth/preset"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -64,6 +65,14 @@ func (p *PlayControls) Update() (horDelta, vertDelta int) {
 		p.AllOff()
 		p.oct--
 	}
+
+	// Quick preset switch, held notes stay on
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketRight) {
+		p.messenger.SendMessage(msg.Message{Kind: preset.PresetStepKind, ValF: 1})
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketLeft) {
+		p.messenger.SendMessage(msg.Message{Kind: preset.PresetStepKind, ValF: -1})
+	}
 	for _, pk := range p.keys {
 		if pk.down {
 			if !ebiten.IsKeyPressed(pk.key) {
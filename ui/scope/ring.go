@@ -0,0 +1,43 @@
+// Package scope oscilloscope sample buffering and triggering, independent
+// of the rendering.
+package scope
+
+// Ring keeps the last samples of a signal, addressed by absolute position
+type Ring struct {
+	buf   []float32
+	mask  int
+	write int // absolute position of the next sample
+}
+
+// NewRing expects a power of two size, rounded up otherwise
+func NewRing(size int) *Ring {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+	return &Ring{
+		buf:  make([]float32, n),
+		mask: n - 1,
+	}
+}
+
+func (r *Ring) Push(samples ...float32) {
+	for _, s := range samples {
+		r.buf[r.write&r.mask] = s
+		r.write++
+	}
+}
+
+// At sample at the absolute position pos, pos must be in [End-Len, End)
+func (r *Ring) At(pos int) float32 {
+	return r.buf[pos&r.mask]
+}
+
+// End absolute position after the last sample
+func (r *Ring) End() int {
+	return r.write
+}
+
+func (r *Ring) Len() int {
+	return len(r.buf)
+}
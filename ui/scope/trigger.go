@@ -0,0 +1,71 @@
+package scope
+
+import "math"
+
+type TriggerMode int
+
+const (
+	TriggerFree TriggerMode = iota
+	TriggerRising
+	TriggerFalling
+	TriggerPitch
+)
+
+// Trigger finds where the display window starts in a ring
+type Trigger struct {
+	Mode  TriggerMode
+	Level float32
+
+	// Period in samples of the played note for TriggerPitch, 0 if unknown
+	Period float64
+}
+
+// Start absolute position of a window of span samples, the window ends
+// before the ring end. Edge modes pick the most recent crossing of Level
+// within lookback samples, the pitch mode aligns the window on whole periods
+// counted from the start of the ring so the waveform stays still. Falls back
+// to the most recent window when nothing is found.
+func (t *Trigger) Start(r *Ring, span, lookback int) int {
+	last := r.End() - span - 1
+	first := max(r.End()-min(lookback, r.Len()), 0)
+
+	switch t.Mode {
+	case TriggerRising, TriggerFalling:
+		if pos, ok := t.edge(r, first, last); ok {
+			return pos
+		}
+	case TriggerPitch:
+		if t.Period >= 1 {
+			pos := int(math.Floor(float64(last)/t.Period) * t.Period)
+			if pos >= first {
+				return pos
+			}
+		}
+		// Unknown period, rising edge
+		rising := Trigger{Mode: TriggerRising, Level: t.Level}
+		if pos, ok := rising.edge(r, first, last); ok {
+			return pos
+		}
+	}
+
+	return max(last, first)
+}
+
+// edge most recent crossing in [first+1, last]
+func (t *Trigger) edge(r *Ring, first, last int) (int, bool) {
+	if last <= first {
+		return 0, false
+	}
+
+	cur := r.At(last)
+	for pos := last; pos > first; pos-- {
+		prev := r.At(pos - 1)
+		if t.Mode == TriggerRising && prev < t.Level && cur >= t.Level ||
+			t.Mode == TriggerFalling && prev > t.Level && cur <= t.Level {
+			return pos, true
+		}
+		cur = prev
+	}
+
+	return 0, false
+}
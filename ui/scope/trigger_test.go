@@ -0,0 +1,101 @@
+package scope
+
+import (
+	"math"
+	"testing"
+)
+
+// sineRing ring of n samples of a sine of the given period, starting at phase 0
+func sineRing(n int, period float64) *Ring {
+	r := NewRing(n)
+	for i := range n {
+		r.Push(float32(math.Sin(2 * math.Pi * float64(i) / period)))
+	}
+	return r
+}
+
+func TestRing_Wraps(t *testing.T) {
+	r := NewRing(3)
+	if r.Len() != 4 {
+		t.Fatalf("expected size 4, got %d", r.Len())
+	}
+
+	r.Push(1, 2, 3, 4, 5, 6)
+	if r.End() != 6 {
+		t.Fatalf("expected end 6, got %d", r.End())
+	}
+	for pos := 2; pos < 6; pos++ {
+		if v := r.At(pos); v != float32(pos+1) {
+			t.Errorf("at %d: expected %d, got %f", pos, pos+1, v)
+		}
+	}
+}
+
+func TestTrigger_Free(t *testing.T) {
+	r := sineRing(1024, 100)
+	tr := Trigger{Mode: TriggerFree}
+
+	if start := tr.Start(r, 200, 1024); start != 1024-201 {
+		t.Fatalf("expected the most recent window, got %d", start)
+	}
+}
+
+func TestTrigger_Edges(t *testing.T) {
+	r := sineRing(1024, 100)
+
+	for _, test := range []struct {
+		name  string
+		trig  Trigger
+		check func(prev, cur float32) bool
+	}{
+		{"rising", Trigger{Mode: TriggerRising, Level: .5}, func(p, c float32) bool { return p < .5 && c >= .5 }},
+		{"falling", Trigger{Mode: TriggerFalling, Level: -.2}, func(p, c float32) bool { return p > -.2 && c <= -.2 }},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			start := test.trig.Start(r, 200, 1024)
+			if !test.check(r.At(start-1), r.At(start)) {
+				t.Fatalf("no crossing at %d: %f, %f", start, r.At(start-1), r.At(start))
+			}
+			if start+200 >= r.End() {
+				t.Fatalf("window past the end, start %d", start)
+			}
+			if start < r.End()-200-1-100 {
+				t.Fatalf("expected the most recent crossing, got %d", start)
+			}
+		})
+	}
+}
+
+func TestTrigger_EdgeFallback(t *testing.T) {
+	r := sineRing(1024, 100)
+	tr := Trigger{Mode: TriggerRising, Level: 2} // never reached
+
+	if start := tr.Start(r, 200, 1024); start != 1024-201 {
+		t.Fatalf("expected the most recent window, got %d", start)
+	}
+}
+
+func TestTrigger_PitchLocked(t *testing.T) {
+	const period = 100.37
+	r := sineRing(4096, period)
+	tr := Trigger{Mode: TriggerPitch, Period: period}
+
+	// The phase at the window start stays put as samples come in
+	ref := r.At(tr.Start(r, 300, 4096))
+	for i := range 50 {
+		r.Push(float32(math.Sin(2 * math.Pi * float64(r.End()) / period)))
+		if v := r.At(tr.Start(r, 300, 4096)); math.Abs(float64(v-ref)) > .07 {
+			t.Fatalf("push %d: start sample %f drifted from %f", i, v, ref)
+		}
+	}
+}
+
+func TestTrigger_PitchUnknownPeriod(t *testing.T) {
+	r := sineRing(1024, 100)
+	tr := Trigger{Mode: TriggerPitch}
+
+	start := tr.Start(r, 200, 1024)
+	if !(r.At(start-1) < 0 && r.At(start) >= 0) {
+		t.Fatalf("expected a rising edge fallback at %d", start)
+	}
+}
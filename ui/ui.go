@@ -2,6 +2,7 @@ package ui
 
 import (
 	"synth/assets"
+	"synth/dsp"
 	"synth/msg"
 	"synth/tree"
 
@@ -35,7 +36,8 @@ type Ui struct {
 	transRight   *ebiten.Image
 	bodyClipMask *ebiten.Image
 
-	ftps *Ftps
+	ftps  *Ftps
+	meter *CompactMeter
 }
 
 func NewUi(
@@ -182,6 +184,10 @@ func (u *Ui) Draw(screen *ebiten.Image) {
 	ops.GeoM.Translate(BodyStartX, BodyStartY)
 	screen.DrawImage(u.bodyClipMask, ops)
 
+	if u.meter != nil {
+		u.meter.Draw(screen)
+	}
+
 	if u.ftps != nil {
 		u.ftps.Draw(screen)
 	}
@@ -198,3 +204,8 @@ func (u *Ui) ToggleFtpsDisplay() {
 	}
 	u.ftps = nil
 }
+
+// ShowLevels draws a compact output meter in the chrome
+func (u *Ui) ShowLevels(levels *dsp.Levels) {
+	u.meter = NewCompactMeter(levels)
+}